@@ -2,7 +2,11 @@ module github.com/krisdiano/gopgwal
 
 go 1.20
 
-require github.com/stretchr/testify v1.8.4
+require (
+	github.com/klauspost/compress v1.17.4
+	github.com/pierrec/lz4/v4 v4.1.18
+	github.com/stretchr/testify v1.8.4
+)
 
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
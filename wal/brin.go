@@ -0,0 +1,203 @@
+package wal
+
+const (
+	/*
+	 * WAL record definitions for BRIN's WAL operations
+	 *
+	 * XLOG allows to store some information in high 4 bits of log
+	 * record xl_info field.
+	 */
+	XLOG_BRIN_CREATE_INDEX    = 0x00
+	XLOG_BRIN_INSERT          = 0x10
+	XLOG_BRIN_UPDATE          = 0x20
+	XLOG_BRIN_SAMEPAGE_UPDATE = 0x30
+	XLOG_BRIN_REVMAP_EXTEND   = 0x40
+	XLOG_BRIN_DESUMMARIZE     = 0x50
+
+	XLOG_BRIN_OPMASK = 0x70
+	/*
+	 * When we insert the first item on a new page, we restore the entire page in
+	 * redo.
+	 */
+	XLOG_BRIN_INIT_PAGE = 0x80
+)
+
+/*
+ * This is what we need to know about a BRIN index create.
+ *
+ * Backup block 0: metapage
+ */
+type XlBrinCreateidx struct {
+	PagesPerRange BlockNumber
+	Version       uint16
+}
+
+func SizeofXlBrinCreateidx() int64 {
+	return 6
+}
+
+/*
+ * This is what we need to know about a BRIN tuple insert
+ *
+ * Backup block 0: main page, block data is the new BrinTuple.
+ * Backup block 1: revmap page
+ */
+type XlBrinInsert struct {
+	HeapBlk BlockNumber
+
+	/* extra information needed to update the revmap */
+	PagesPerRange BlockNumber
+
+	/* offset number in the main page to insert the tuple to. */
+	Offnum OffsetNumber
+}
+
+func SizeofXlBrinInsert() int64 {
+	return 10
+}
+
+/*
+ * A cross-page update is the same as an insert, but also stores information
+ * about the old tuple.
+ *
+ * Like in xl_brin_insert:
+ * Backup block 0: new page, block data includes the new BrinTuple.
+ * Backup block 1: revmap page
+ *
+ * And in addition:
+ * Backup block 2: old page
+ */
+type XlBrinUpdate struct {
+	/* offset number of old tuple on old page */
+	OldOffnum OffsetNumber
+
+	Insert XlBrinInsert
+}
+
+func SizeofXlBrinUpdate() int64 {
+	return 14
+}
+
+/*
+ * This is what we need to know about a BRIN tuple samepage update
+ *
+ * Backup block 0: updated page, with new BrinTuple as block data
+ */
+type XlBrinSamepageUpdate struct {
+	Offnum OffsetNumber
+}
+
+func SizeofXlBrinSamepageUpdate() int64 {
+	return 2
+}
+
+/*
+ * This is what we need to know about a revmap extension
+ *
+ * Backup block 0: metapage
+ * Backup block 1: new revmap page
+ */
+type XlBrinRevmapExtend struct {
+	/*
+	 * XXX: This is actually redundant - the block number is stored as part of
+	 * backup block 1.
+	 */
+	TargetBlk BlockNumber
+}
+
+func SizeofXlBrinRevmapExtend() int64 {
+	return 4
+}
+
+/*
+ * This is what we need to know about a range de-summarization
+ *
+ * Backup block 0: revmap page
+ * Backup block 1: regular page
+ */
+type XlBrinDesummarize struct {
+	PagesPerRange BlockNumber
+	/* page number location to set to invalid */
+	HeapBlk BlockNumber
+	/* offset of item to delete in regular index page */
+	RegOffset OffsetNumber
+}
+
+func SizeofXlBrinDesummarize() int64 {
+	return 10
+}
+
+type BrinCreateIdx struct {
+	XlBrinCreateidx
+}
+
+type BrinInsert struct {
+	XlBrinInsert
+	InitPage bool
+	Tuple    []byte
+}
+
+type BrinUpdate struct {
+	XlBrinUpdate
+	InitPage bool
+	Tuple    []byte
+}
+
+type BrinSamepageUpdate struct {
+	XlBrinSamepageUpdate
+	Tuple []byte
+}
+
+type BrinRevmapExtend struct {
+	XlBrinRevmapExtend
+}
+
+type BrinDesummarize struct {
+	XlBrinDesummarize
+}
+
+// DecodeBrin decodes the records of RM_BRIN_ID into the struct of their info
+// code, e.g. *BrinInsert.
+func DecodeBrin(record *Record) (interface{}, error) {
+	data := record.MainData
+	initPage := record.Info()&XLOG_BRIN_INIT_PAGE != 0
+	switch record.Info() & XLOG_BRIN_OPMASK {
+	case XLOG_BRIN_CREATE_INDEX:
+		xlrec, err := readStruct[XlBrinCreateidx](record, data, SizeofXlBrinCreateidx())
+		if err != nil {
+			return nil, err
+		}
+		return &BrinCreateIdx{XlBrinCreateidx: *xlrec}, nil
+	case XLOG_BRIN_INSERT:
+		xlrec, err := readStruct[XlBrinInsert](record, data, SizeofXlBrinInsert())
+		if err != nil {
+			return nil, err
+		}
+		return &BrinInsert{XlBrinInsert: *xlrec, InitPage: initPage, Tuple: record.BlockData(0)}, nil
+	case XLOG_BRIN_UPDATE:
+		xlrec, err := readStruct[XlBrinUpdate](record, data, SizeofXlBrinUpdate())
+		if err != nil {
+			return nil, err
+		}
+		return &BrinUpdate{XlBrinUpdate: *xlrec, InitPage: initPage, Tuple: record.BlockData(0)}, nil
+	case XLOG_BRIN_SAMEPAGE_UPDATE:
+		xlrec, err := readStruct[XlBrinSamepageUpdate](record, data, SizeofXlBrinSamepageUpdate())
+		if err != nil {
+			return nil, err
+		}
+		return &BrinSamepageUpdate{XlBrinSamepageUpdate: *xlrec, Tuple: record.BlockData(0)}, nil
+	case XLOG_BRIN_REVMAP_EXTEND:
+		xlrec, err := readStruct[XlBrinRevmapExtend](record, data, SizeofXlBrinRevmapExtend())
+		if err != nil {
+			return nil, err
+		}
+		return &BrinRevmapExtend{XlBrinRevmapExtend: *xlrec}, nil
+	case XLOG_BRIN_DESUMMARIZE:
+		xlrec, err := readStruct[XlBrinDesummarize](record, data, SizeofXlBrinDesummarize())
+		if err != nil {
+			return nil, err
+		}
+		return &BrinDesummarize{XlBrinDesummarize: *xlrec}, nil
+	}
+	return nil, record.unknownInfo()
+}
@@ -0,0 +1,44 @@
+package wal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeBrin(t *testing.T) {
+	t.Run("insert", func(t *testing.T) {
+		record := testRecord(t, RM_BRIN_ID, XLOG_BRIN_INSERT|XLOG_BRIN_INIT_PAGE,
+			le(uint32(256), uint32(128), uint16(1)), []byte{1, 2, 3, 4}, nil)
+		decoded, err := DecodeRmgrData(record)
+		require.NoError(t, err)
+		insert := decoded.(*BrinInsert)
+		assert.True(t, insert.InitPage)
+		assert.Equal(t, BlockNumber(256), insert.HeapBlk)
+		assert.Equal(t, []byte{1, 2, 3, 4}, insert.Tuple)
+	})
+	t.Run("update", func(t *testing.T) {
+		record := testRecord(t, RM_BRIN_ID, XLOG_BRIN_UPDATE,
+			le(uint16(3), uint16(0), uint32(256), uint32(128), uint16(1)), []byte{1, 2}, nil, nil)
+		decoded, err := DecodeRmgrData(record)
+		require.NoError(t, err)
+		update := decoded.(*BrinUpdate)
+		assert.False(t, update.InitPage)
+		assert.EqualValues(t, 3, update.OldOffnum)
+		assert.Equal(t, BlockNumber(128), update.Insert.PagesPerRange)
+	})
+	t.Run("desummarize", func(t *testing.T) {
+		record := testRecord(t, RM_BRIN_ID, XLOG_BRIN_DESUMMARIZE,
+			le(uint32(128), uint32(512), uint16(4)), nil, nil)
+		decoded, err := DecodeRmgrData(record)
+		require.NoError(t, err)
+		desummarize := decoded.(*BrinDesummarize)
+		assert.Equal(t, BlockNumber(512), desummarize.HeapBlk)
+		assert.EqualValues(t, 4, desummarize.RegOffset)
+	})
+	t.Run("unknown", func(t *testing.T) {
+		_, err := DecodeRmgrData(testRecord(t, RM_BRIN_ID, 0x60, []byte{1}))
+		assert.ErrorIs(t, err, ErrInvalidRecord)
+	})
+}
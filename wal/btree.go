@@ -0,0 +1,682 @@
+package wal
+
+const (
+	/*
+	 * XLOG records for btree operations
+	 *
+	 * XLOG allows to store some information in high 4 bits of log
+	 * record xl_info field
+	 */
+	XLOG_BTREE_INSERT_LEAF  = 0x00 /* add index tuple without split */
+	XLOG_BTREE_INSERT_UPPER = 0x10 /* same, on a non-leaf page */
+	XLOG_BTREE_INSERT_META  = 0x20 /* same, plus update metapage */
+	XLOG_BTREE_SPLIT_L      = 0x30 /* add index tuple with split */
+	XLOG_BTREE_SPLIT_R      = 0x40 /* as above, new item on right */
+	/* Since PostgreSQL 13 */
+	XLOG_BTREE_INSERT_POST = 0x50 /* add index tuple with posting split */
+	XLOG_BTREE_DEDUP       = 0x60 /* deduplicate tuples for a page */
+
+	XLOG_BTREE_DELETE             = 0x70 /* delete leaf index tuples for a page */
+	XLOG_BTREE_UNLINK_PAGE        = 0x80 /* delete a half-dead page */
+	XLOG_BTREE_UNLINK_PAGE_META   = 0x90 /* same, and update metapage */
+	XLOG_BTREE_NEWROOT            = 0xA0 /* new root page */
+	XLOG_BTREE_MARK_PAGE_HALFDEAD = 0xB0 /* mark a leaf as half-dead */
+	XLOG_BTREE_VACUUM             = 0xC0 /* delete entries on a page during
+	 * vacuum */
+	XLOG_BTREE_REUSE_PAGE = 0xD0 /* old page is about to be reused from
+	 * FSM */
+	XLOG_BTREE_META_CLEANUP = 0xE0 /* update cleanup-related data in the
+	 * metapage */
+)
+
+/*
+ * All that we need to regenerate the meta-data page
+ */
+type XlBtreeMetadata struct {
+	Version   uint32
+	Root      BlockNumber
+	Level     uint32
+	Fastroot  BlockNumber
+	Fastlevel uint32
+	/* oldest_btpo_xact up to PostgreSQL 13 */
+	LastCleanupNumDelpages   uint32
+	LastCleanupNumHeapTuples float64
+	/* Since PostgreSQL 13 */
+	Allequalimage bool
+}
+
+func SizeofXlBtreeMetadata(v PgVersion) int64 {
+	if v >= PG13 {
+		return 33
+	}
+	return 32
+}
+
+/*
+ * This is what we need to know about simple (without split) insert.
+ *
+ * This data record is used for INSERT_LEAF, INSERT_UPPER, INSERT_META, and
+ * INSERT_POST.  Note that INSERT_META and INSERT_UPPER implies it's not a
+ * leaf page, while INSERT_POST and INSERT_LEAF imply that it must be a leaf
+ * page.
+ *
+ * Backup Blk 0: original page
+ * Backup Blk 1: child's left sibling, if INSERT_UPPER or INSERT_META
+ * Backup Blk 2: xl_btree_metadata, if INSERT_META
+ *
+ * Note: The new tuple is actually the "original" new item in the posting
+ * list split insert case (i.e. the INSERT_POST case).  A split offset for
+ * the posting list is logged before the original new item.  Recovery needs
+ * both, since it must do an in-place update of the existing posting list
+ * that was split as an extra step.  Also, recovery generates a "final"
+ * newitem.  See _bt_swap_posting() for details on posting list splits.
+ */
+type XlBtreeInsert struct {
+	Offnum OffsetNumber
+
+	/* POSTING SPLIT OFFSET FOLLOWS (INSERT_POST case) */
+	/* NEW TUPLE ALWAYS FOLLOWS AT THE END */
+}
+
+func SizeofXlBtreeInsert() int64 {
+	return 2
+}
+
+/*
+ * On insert with split, we save all the items going into the right sibling
+ * so that we can restore it completely from the log record.  This way takes
+ * less xlog space than the normal approach, because if we did it standardly,
+ * XLogInsert would almost always think the right page is new and store its
+ * whole page image.  The left page, however, is handled in the normal
+ * incremental-update fashion.
+ *
+ * Backup Blk 0: original page / new left page
+ *
+ * The left page's data portion contains the new item, if it's the _L variant.
+ * _R variant split records generally do not have a newitem (_R variant leaf
+ * page split records that must deal with a posting list split will include an
+ * explicit newitem, though it is never used on the right page -- it is
+ * actually an orignewitem needed to update existing posting list).  The new
+ * high key of the left/original page appears last of all (and must always be
+ * present).
+ *
+ * Backup Blk 1: new right page
+ *
+ * The right page's data portion contains the right page's tuples in the form
+ * used by _bt_restore_page.  This includes the new item, if it's the _R
+ * variant.  The right page's tuples also include the right page's high key
+ * with either variant (moved from the left/original page during the split),
+ * unless the split happened to be of the rightmost page on its level, where
+ * there is no high key for new right page.
+ *
+ * Backup Blk 2: next block (orig page's rightlink), if any
+ * Backup Blk 3: child's left sibling, if non-leaf split
+ */
+type XlBtreeSplit struct {
+	Level         uint32       /* tree level of page being split */
+	Firstrightoff OffsetNumber /* first origpage item on rightpage */
+	Newitemoff    OffsetNumber /* new item's offset */
+	Postingoff    uint16       /* offset inside orig posting tuple, since PostgreSQL 13 */
+}
+
+func SizeofXlBtreeSplit(v PgVersion) int64 {
+	if v >= PG13 {
+		return 10
+	}
+	return 8
+}
+
+/*
+ * When page is deduplicated, consecutive groups of tuples with equal keys are
+ * merged together into posting list tuples.
+ *
+ * The WAL record represents a deduplication pass for a leaf page.  An array
+ * of BTDedupInterval structs follows.
+ */
+type XlBtreeDedup struct {
+	Nintervals uint16
+
+	/* DEDUPLICATION INTERVALS FOLLOW */
+}
+
+func SizeofXlBtreeDedup() int64 {
+	return 2
+}
+
+type BTDedupInterval struct {
+	Baseoff OffsetNumber
+	Nitems  uint16
+}
+
+/*
+ * This is what we need to know about page reuse within btree.  This record
+ * only exists to generate a conflict point for Hot Standby.
+ *
+ * Note that we must include a RelFileNode in the record because we don't
+ * actually register the buffer with the record.
+ */
+type XlBtreeReusePage struct {
+	Node  RelFileNode
+	Block BlockNumber
+	/* a TransactionId up to PostgreSQL 13 */
+	SnapshotConflictHorizon uint64
+	IsCatalogRel            bool /* Since PostgreSQL 16 */
+}
+
+/* xl_btree_reuse_page up to PostgreSQL 13 */
+type xlBtreeReusePage13 struct {
+	Node             RelFileNode
+	Block            BlockNumber
+	LatestRemovedXid TransactionId
+}
+
+func SizeofXlBtreeReusePage(v PgVersion) int64 {
+	switch {
+	case v >= PG16:
+		return 25
+	case v >= PG14:
+		return 24
+	}
+	return 20
+}
+
+/*
+ * xl_btree_vacuum and xl_btree_delete records describe deletion of index
+ * tuples on a leaf page.  The former variant is used by VACUUM, while the
+ * latter variant is used by the ad-hoc deletions that sometimes take place
+ * when btinsert() is called.
+ *
+ * The records are very similar.  The only difference is that xl_btree_delete
+ * has to include a snapshotConflictHorizon field to generate recovery
+ * conflicts.  (VACUUM operations can just rely on earlier conflicts
+ * generated during pruning of the table whose TIDs the to-be-deleted index
+ * tuples point to.  There are also small differences between each REDO
+ * routine that we don't go into here.)
+ *
+ * Both are followed by the deleted and updated offsets, and the updates, in
+ * the data of block 0.  Up to PostgreSQL 13 the deleted offsets of
+ * xl_btree_delete follow it in the main data instead.
+ */
+type XlBtreeVacuum struct {
+	Ndeleted uint16
+	Nupdated uint16
+}
+
+func SizeofXlBtreeVacuum() int64 {
+	return 4
+}
+
+type XlBtreeDelete struct {
+	SnapshotConflictHorizon TransactionId /* latestRemovedXid before 16 */
+	Ndeleted                uint16
+	Nupdated                uint16
+	IsCatalogRel            bool /* Since PostgreSQL 16 */
+}
+
+func SizeofXlBtreeDelete(v PgVersion) int64 {
+	if v >= PG16 {
+		return 9
+	}
+	return 8
+}
+
+/*
+ * The offsets that appear in xl_btree_update metadata are offsets into the
+ * original posting list from tuple, not page offset numbers.  These are
+ * 0-based.  The page offset number for the original posting list tuple comes
+ * from the main xl_btree_vacuum/xl_btree_delete record.
+ */
+type XlBtreeUpdate struct {
+	Ndeletedtids uint16
+
+	/* POSTING LIST uint16 OFFSETS TO A DELETED TID FOLLOW */
+}
+
+func SizeofXlBtreeUpdate() int64 {
+	return 2
+}
+
+/*
+ * This is what we need to know about marking an empty subtree for deletion.
+ * The target identifies the tuple removed from the parent page (note that we
+ * remove this tuple's downlink and the *following* tuple's key).  Note that
+ * the leaf page is empty, so we don't need to store its content --- it is
+ * just reinitialized during recovery using the rest of the fields.
+ *
+ * Backup Blk 0: leaf block
+ * Backup Blk 1: top parent
+ */
+type XlBtreeMarkPageHalfdead struct {
+	Poffset OffsetNumber /* deleted tuple id in parent page */
+
+	/* information needed to recreate the leaf page: */
+	Leafblk   BlockNumber /* leaf block ultimately being deleted */
+	Leftblk   BlockNumber /* leaf block's left sibling, if any */
+	Rightblk  BlockNumber /* leaf block's right sibling */
+	Topparent BlockNumber /* topmost internal page in the subtree */
+}
+
+func SizeofXlBtreeMarkPageHalfdead() int64 {
+	return 20
+}
+
+/*
+ * This is what we need to know about deletion of a btree page.  Note that we
+ * only leave behind a small amount of bookkeeping information in deleted
+ * pages (deleted pages must be kept around as tombstones for a while).  It is
+ * convenient for the REDO routine to regenerate its target page from scratch.
+ * This is why WAL record describes certain details that are actually directly
+ * available from the target page.
+ *
+ * Backup Blk 0: target block being deleted
+ * Backup Blk 1: target block's left sibling, if any
+ * Backup Blk 2: target block's right sibling
+ * Backup Blk 3: leaf block (if different from target)
+ * Backup Blk 4: metapage (if rightsib becomes new fast root)
+ */
+type XlBtreeUnlinkPage struct {
+	Leftsib  BlockNumber /* target block's left sibling, if any */
+	Rightsib BlockNumber /* target block's right sibling */
+	Level    uint32      /* target block's level, since PostgreSQL 14 */
+	Safexid  uint64      /* target block's BTPageSetDeleted() XID, btpo_xact before 14 */
+
+	/*
+	 * Information needed to recreate a half-dead leaf page with correct
+	 * topparent link.  The fields are only used when deletion operation's
+	 * target page is an internal page.  REDO routine creates half-dead page
+	 * from scratch to keep things simple (this is the same convenient
+	 * approach used for the target page itself).
+	 */
+	Leafleftsib   BlockNumber
+	Leafrightsib  BlockNumber
+	Leaftopparent BlockNumber /* next child down in the subtree */
+
+	/* xl_btree_metadata FOLLOWS IF XLOG_BTREE_UNLINK_PAGE_META */
+}
+
+/* xl_btree_unlink_page up to PostgreSQL 13 */
+type xlBtreeUnlinkPage13 struct {
+	Leftsib      BlockNumber
+	Rightsib     BlockNumber
+	Leafleftsib  BlockNumber
+	Leafrightsib BlockNumber
+	Topparent    BlockNumber
+	BtpoXact     TransactionId
+}
+
+func SizeofXlBtreeUnlinkPage(v PgVersion) int64 {
+	if v >= PG14 {
+		return 36
+	}
+	return 24
+}
+
+/*
+ * New root log record.  There are zero tuples if this is to establish an
+ * empty root, or two if it is the result of splitting an old root.
+ *
+ * Note that although this implies rewriting the metadata page, we don't need
+ * an XLOG record for that, as it is the same metapage update that is logged
+ * by inserting into the new root.
+ *
+ * Backup Blk 0: new root page (2 tuples as payload, if splitting old root)
+ * Backup Blk 1: left child (if splitting an old root)
+ * Backup Blk 2: metapage
+ */
+type XlBtreeNewroot struct {
+	Rootblk BlockNumber /* location of new root (redundant with blk 0) */
+	Level   uint32      /* its tree level */
+}
+
+func SizeofXlBtreeNewroot() int64 {
+	return 8
+}
+
+// BtreeInsert decodes the XLOG_BTREE_INSERT_* records.
+type BtreeInsert struct {
+	XlBtreeInsert
+	Postingoff uint16           // XLOG_BTREE_INSERT_POST only
+	NewItem    []byte           // nil when only a full-page image was logged
+	Metadata   *XlBtreeMetadata // XLOG_BTREE_INSERT_META only
+}
+
+// BtreeSplit decodes the XLOG_BTREE_SPLIT_L and XLOG_BTREE_SPLIT_R records.
+type BtreeSplit struct {
+	XlBtreeSplit
+	OnLeft     bool   // XLOG_BTREE_SPLIT_L
+	NewItem    []byte // the new item on the left page, or the original one of a posting split
+	LeftHikey  []byte
+	RightItems []byte // the items of the new right page, for _bt_restore_page
+}
+
+type BtreeDedup struct {
+	XlBtreeDedup
+	Intervals []BTDedupInterval
+}
+
+// BtreeUpdate is an xl_btree_update with the posting list offsets of the
+// deleted TIDs.
+type BtreeUpdate struct {
+	XlBtreeUpdate
+	DeletedTids []uint16
+}
+
+// BtreeVacuum decodes XLOG_BTREE_VACUUM. Up to PostgreSQL 12 only the
+// deleted offsets are logged, after LastBlockVacuumed.
+type BtreeVacuum struct {
+	XlBtreeVacuum
+	LastBlockVacuumed BlockNumber // up to PostgreSQL 12
+	Deleted           []OffsetNumber
+	Updated           []OffsetNumber
+	Updates           []BtreeUpdate
+}
+
+type BtreeDelete struct {
+	XlBtreeDelete
+	Deleted []OffsetNumber
+	Updated []OffsetNumber
+	Updates []BtreeUpdate
+}
+
+type BtreeMarkPageHalfdead struct {
+	XlBtreeMarkPageHalfdead
+}
+
+// BtreeUnlinkPage decodes XLOG_BTREE_UNLINK_PAGE and
+// XLOG_BTREE_UNLINK_PAGE_META.
+type BtreeUnlinkPage struct {
+	XlBtreeUnlinkPage
+	Metadata *XlBtreeMetadata // XLOG_BTREE_UNLINK_PAGE_META only
+}
+
+type BtreeNewroot struct {
+	XlBtreeNewroot
+	Items    []byte // the tuples of the new root, if splitting an old root
+	Metadata *XlBtreeMetadata
+}
+
+type BtreeReusePage struct {
+	XlBtreeReusePage
+}
+
+type BtreeMetaCleanup struct {
+	Metadata *XlBtreeMetadata
+}
+
+// DecodeBtree decodes the records of RM_BTREE_ID into the struct of their
+// info code, e.g. *BtreeInsert.
+func DecodeBtree(record *Record) (interface{}, error) {
+	data := record.MainData
+	switch info := record.Info(); info {
+	case XLOG_BTREE_INSERT_LEAF, XLOG_BTREE_INSERT_UPPER, XLOG_BTREE_INSERT_META, XLOG_BTREE_INSERT_POST:
+		if info == XLOG_BTREE_INSERT_POST && record.Version < PG13 {
+			break
+		}
+		xlrec, err := readStruct[XlBtreeInsert](record, data, SizeofXlBtreeInsert())
+		if err != nil {
+			return nil, err
+		}
+		ret := &BtreeInsert{XlBtreeInsert: *xlrec, NewItem: record.BlockData(0)}
+		if info == XLOG_BTREE_INSERT_POST && ret.NewItem != nil {
+			postingoff, err := readStruct[uint16](record, ret.NewItem, 2)
+			if err != nil {
+				return nil, err
+			}
+			ret.Postingoff = *postingoff
+			ret.NewItem = ret.NewItem[2:]
+		}
+		if info == XLOG_BTREE_INSERT_META {
+			ret.Metadata, err = readBtreeMetadata(record, 2)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return ret, nil
+	case XLOG_BTREE_SPLIT_L, XLOG_BTREE_SPLIT_R:
+		return decodeBtreeSplit(record)
+	case XLOG_BTREE_DEDUP:
+		if record.Version < PG13 {
+			break
+		}
+		xlrec, err := readStruct[XlBtreeDedup](record, data, SizeofXlBtreeDedup())
+		if err != nil {
+			return nil, err
+		}
+		ret := &BtreeDedup{XlBtreeDedup: *xlrec}
+		if blockData := record.BlockData(0); blockData != nil {
+			ret.Intervals, err = readArray[BTDedupInterval](record, blockData, int(xlrec.Nintervals))
+			if err != nil {
+				return nil, err
+			}
+		}
+		return ret, nil
+	case XLOG_BTREE_VACUUM:
+		return decodeBtreeVacuum(record)
+	case XLOG_BTREE_DELETE:
+		return decodeBtreeDelete(record)
+	case XLOG_BTREE_MARK_PAGE_HALFDEAD:
+		xlrec, err := readStruct[XlBtreeMarkPageHalfdead](record, data, SizeofXlBtreeMarkPageHalfdead())
+		if err != nil {
+			return nil, err
+		}
+		return &BtreeMarkPageHalfdead{XlBtreeMarkPageHalfdead: *xlrec}, nil
+	case XLOG_BTREE_UNLINK_PAGE, XLOG_BTREE_UNLINK_PAGE_META:
+		ret := &BtreeUnlinkPage{}
+		if record.Version >= PG14 {
+			xlrec, err := readStruct[XlBtreeUnlinkPage](record, data, SizeofXlBtreeUnlinkPage(record.Version))
+			if err != nil {
+				return nil, err
+			}
+			ret.XlBtreeUnlinkPage = *xlrec
+		} else {
+			xlrec, err := readStruct[xlBtreeUnlinkPage13](record, data, SizeofXlBtreeUnlinkPage(record.Version))
+			if err != nil {
+				return nil, err
+			}
+			ret.XlBtreeUnlinkPage = XlBtreeUnlinkPage{
+				Leftsib:       xlrec.Leftsib,
+				Rightsib:      xlrec.Rightsib,
+				Safexid:       uint64(xlrec.BtpoXact),
+				Leafleftsib:   xlrec.Leafleftsib,
+				Leafrightsib:  xlrec.Leafrightsib,
+				Leaftopparent: xlrec.Topparent,
+			}
+		}
+		if info == XLOG_BTREE_UNLINK_PAGE_META {
+			var err error
+			ret.Metadata, err = readBtreeMetadata(record, 4)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return ret, nil
+	case XLOG_BTREE_NEWROOT:
+		xlrec, err := readStruct[XlBtreeNewroot](record, data, SizeofXlBtreeNewroot())
+		if err != nil {
+			return nil, err
+		}
+		ret := &BtreeNewroot{XlBtreeNewroot: *xlrec, Items: record.BlockData(0)}
+		ret.Metadata, err = readBtreeMetadata(record, 2)
+		if err != nil {
+			return nil, err
+		}
+		return ret, nil
+	case XLOG_BTREE_REUSE_PAGE:
+		if record.Version >= PG14 {
+			xlrec, err := readStruct[XlBtreeReusePage](record, data, SizeofXlBtreeReusePage(record.Version))
+			if err != nil {
+				return nil, err
+			}
+			return &BtreeReusePage{XlBtreeReusePage: *xlrec}, nil
+		}
+		xlrec, err := readStruct[xlBtreeReusePage13](record, data, SizeofXlBtreeReusePage(record.Version))
+		if err != nil {
+			return nil, err
+		}
+		return &BtreeReusePage{XlBtreeReusePage: XlBtreeReusePage{
+			Node:                    xlrec.Node,
+			Block:                   xlrec.Block,
+			SnapshotConflictHorizon: uint64(xlrec.LatestRemovedXid),
+		}}, nil
+	case XLOG_BTREE_META_CLEANUP:
+		metadata, err := readBtreeMetadata(record, 0)
+		if err != nil {
+			return nil, err
+		}
+		return &BtreeMetaCleanup{Metadata: metadata}, nil
+	}
+	return nil, record.unknownInfo()
+}
+
+// readBtreeMetadata decodes the xl_btree_metadata registered with the
+// metapage, block reference id.
+func readBtreeMetadata(record *Record, id uint8) (*XlBtreeMetadata, error) {
+	data := record.BlockData(id)
+	if data == nil {
+		return nil, nil
+	}
+	metadata, err := readStruct[XlBtreeMetadata](record, data, SizeofXlBtreeMetadata(record.Version))
+	if err != nil {
+		return nil, err
+	}
+	if record.Version < PG13 {
+		metadata.Allequalimage = false
+	}
+	return metadata, nil
+}
+
+func decodeBtreeSplit(record *Record) (*BtreeSplit, error) {
+	xlrec, err := readStruct[XlBtreeSplit](record, record.MainData, SizeofXlBtreeSplit(record.Version))
+	if err != nil {
+		return nil, err
+	}
+	ret := &BtreeSplit{
+		XlBtreeSplit: *xlrec,
+		OnLeft:       record.Info() == XLOG_BTREE_SPLIT_L,
+		RightItems:   record.BlockData(1),
+	}
+	if record.Version < PG13 {
+		ret.Postingoff = 0
+	}
+	datapos := record.BlockData(0)
+	if datapos == nil {
+		return ret, nil
+	}
+	if ret.OnLeft || ret.Postingoff != 0 {
+		ret.NewItem, datapos, err = readIndexTuple(record, datapos, true)
+		if err != nil {
+			return nil, err
+		}
+	}
+	ret.LeftHikey, _, err = readIndexTuple(record, datapos, true)
+	if err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// readBtreeDeletion decodes the deleted and updated offsets and the updates
+// following them in the data of block 0.
+func readBtreeDeletion(record *Record, ndeleted, nupdated int) (deleted, updated []OffsetNumber, updates []BtreeUpdate, err error) {
+	data := record.BlockData(0)
+	if data == nil {
+		return nil, nil, nil, nil
+	}
+	deleted, err = readArray[OffsetNumber](record, data, ndeleted)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	data = data[2*ndeleted:]
+	updated, err = readArray[OffsetNumber](record, data, nupdated)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	data = data[2*nupdated:]
+	for i := 0; i < nupdated; i++ {
+		xlupdate, err := readStruct[XlBtreeUpdate](record, data, SizeofXlBtreeUpdate())
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		data = data[SizeofXlBtreeUpdate():]
+		tids, err := readArray[uint16](record, data, int(xlupdate.Ndeletedtids))
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		data = data[2*len(tids):]
+		updates = append(updates, BtreeUpdate{XlBtreeUpdate: *xlupdate, DeletedTids: tids})
+	}
+	return deleted, updated, updates, nil
+}
+
+func decodeBtreeVacuum(record *Record) (*BtreeVacuum, error) {
+	data := record.MainData
+	ret := &BtreeVacuum{}
+	switch {
+	case record.Version < PG13:
+		last, err := readStruct[BlockNumber](record, data, 4)
+		if err != nil {
+			return nil, err
+		}
+		ret.LastBlockVacuumed = *last
+		if blockData := record.BlockData(0); blockData != nil {
+			ret.Deleted, err = readArray[OffsetNumber](record, blockData, len(blockData)/2)
+			if err != nil {
+				return nil, err
+			}
+		}
+		ret.Ndeleted = uint16(len(ret.Deleted))
+		return ret, nil
+	case record.Version < PG14:
+		// nupdated came first in PostgreSQL 13
+		counts, err := readArray[uint16](record, data, 2)
+		if err != nil {
+			return nil, err
+		}
+		ret.Nupdated, ret.Ndeleted = counts[0], counts[1]
+	default:
+		xlrec, err := readStruct[XlBtreeVacuum](record, data, SizeofXlBtreeVacuum())
+		if err != nil {
+			return nil, err
+		}
+		ret.XlBtreeVacuum = *xlrec
+	}
+	var err error
+	ret.Deleted, ret.Updated, ret.Updates, err = readBtreeDeletion(record, int(ret.Ndeleted), int(ret.Nupdated))
+	if err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+func decodeBtreeDelete(record *Record) (*BtreeDelete, error) {
+	data := record.MainData
+	ret := &BtreeDelete{}
+	if record.Version < PG14 {
+		// latestRemovedXid and a uint32 ndeleted up to PostgreSQL 13
+		fields, err := readArray[uint32](record, data, 2)
+		if err != nil {
+			return nil, err
+		}
+		ret.SnapshotConflictHorizon = TransactionId(fields[0])
+		if fields[1] > 0xFFFF {
+			return nil, record.errorf("invalid ndeleted %d", fields[1])
+		}
+		ret.Ndeleted = uint16(fields[1])
+		// the offsets follow in the main data
+		ret.Deleted, err = readArray[OffsetNumber](record, data[8:], int(ret.Ndeleted))
+		if err != nil {
+			return nil, err
+		}
+		return ret, nil
+	}
+	xlrec, err := readStruct[XlBtreeDelete](record, data, SizeofXlBtreeDelete(record.Version))
+	if err != nil {
+		return nil, err
+	}
+	ret.XlBtreeDelete = *xlrec
+	ret.Deleted, ret.Updated, ret.Updates, err = readBtreeDeletion(record, int(ret.Ndeleted), int(ret.Nupdated))
+	if err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
@@ -0,0 +1,195 @@
+package wal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// indexTuple returns an IndexTuple of 16 bytes pointing to (0,blkno).
+func indexTuple(blkno uint16) []byte {
+	return le(uint16(0), blkno, uint16(1), uint16(16), uint64(0xAB))
+}
+
+func TestDecodeBtree(t *testing.T) {
+	metadata := le(uint32(4), uint32(3), uint32(1), uint32(3), uint32(1), uint32(0),
+		uint64(0), uint8(1), []byte{0, 0, 0, 0, 0, 0, 0})
+
+	t.Run("insert meta", func(t *testing.T) {
+		record := testRecord(t, RM_BTREE_ID, XLOG_BTREE_INSERT_META, le(uint16(5)),
+			indexTuple(1), nil, metadata)
+		decoded, err := DecodeRmgrData(record)
+		require.NoError(t, err)
+		insert := decoded.(*BtreeInsert)
+		assert.EqualValues(t, 5, insert.Offnum)
+		assert.Equal(t, indexTuple(1), insert.NewItem)
+		require.NotNil(t, insert.Metadata)
+		assert.Equal(t, BlockNumber(3), insert.Metadata.Root)
+		assert.True(t, insert.Metadata.Allequalimage)
+	})
+	t.Run("insert post", func(t *testing.T) {
+		record := testRecord(t, RM_BTREE_ID, XLOG_BTREE_INSERT_POST, le(uint16(2)),
+			le(uint16(7), indexTuple(1)))
+		decoded, err := DecodeRmgrData(record)
+		require.NoError(t, err)
+		insert := decoded.(*BtreeInsert)
+		assert.EqualValues(t, 7, insert.Postingoff)
+		assert.Equal(t, indexTuple(1), insert.NewItem)
+		assert.Nil(t, insert.Metadata)
+
+		_, err = DecodeRmgrData(versioned(record, PG12))
+		assert.ErrorIs(t, err, ErrInvalidRecord)
+	})
+	t.Run("split", func(t *testing.T) {
+		record := testRecord(t, RM_BTREE_ID, XLOG_BTREE_SPLIT_L,
+			le(uint32(0), uint16(10), uint16(4), uint16(0)),
+			concat(indexTuple(1), indexTuple(2)), indexTuple(3))
+		decoded, err := DecodeRmgrData(record)
+		require.NoError(t, err)
+		split := decoded.(*BtreeSplit)
+		assert.True(t, split.OnLeft)
+		assert.EqualValues(t, 10, split.Firstrightoff)
+		assert.Equal(t, indexTuple(1), split.NewItem)
+		assert.Equal(t, indexTuple(2), split.LeftHikey)
+		assert.Equal(t, indexTuple(3), split.RightItems)
+
+		record = testRecord(t, RM_BTREE_ID, XLOG_BTREE_SPLIT_R,
+			le(uint32(0), uint16(10), uint16(12), uint16(3)),
+			concat(indexTuple(1), indexTuple(2)), indexTuple(3))
+		decoded, err = DecodeRmgrData(record)
+		require.NoError(t, err)
+		split = decoded.(*BtreeSplit)
+		assert.False(t, split.OnLeft)
+		assert.EqualValues(t, 3, split.Postingoff)
+		assert.Equal(t, indexTuple(1), split.NewItem)
+		assert.Equal(t, indexTuple(2), split.LeftHikey)
+	})
+	t.Run("dedup", func(t *testing.T) {
+		record := testRecord(t, RM_BTREE_ID, XLOG_BTREE_DEDUP, le(uint16(2)),
+			le(uint16(1), uint16(3), uint16(5), uint16(2)))
+		decoded, err := DecodeRmgrData(record)
+		require.NoError(t, err)
+		assert.Equal(t, []BTDedupInterval{{1, 3}, {5, 2}}, decoded.(*BtreeDedup).Intervals)
+	})
+	t.Run("vacuum", func(t *testing.T) {
+		record := testRecord(t, RM_BTREE_ID, XLOG_BTREE_VACUUM, le(uint16(2), uint16(1)),
+			le(uint16(3), uint16(4), uint16(6), uint16(2), uint16(0), uint16(5)))
+		decoded, err := DecodeRmgrData(record)
+		require.NoError(t, err)
+		vacuum := decoded.(*BtreeVacuum)
+		assert.Equal(t, []OffsetNumber{3, 4}, vacuum.Deleted)
+		assert.Equal(t, []OffsetNumber{6}, vacuum.Updated)
+		require.Len(t, vacuum.Updates, 1)
+		assert.Equal(t, []uint16{0, 5}, vacuum.Updates[0].DeletedTids)
+
+		// nupdated came first in PostgreSQL 13
+		record = versioned(testRecord(t, RM_BTREE_ID, XLOG_BTREE_VACUUM, le(uint16(0), uint16(1)),
+			le(uint16(3))), PG13)
+		decoded, err = DecodeRmgrData(record)
+		require.NoError(t, err)
+		assert.Equal(t, []OffsetNumber{3}, decoded.(*BtreeVacuum).Deleted)
+
+		record = versioned(testRecord(t, RM_BTREE_ID, XLOG_BTREE_VACUUM, le(uint32(9)),
+			le(uint16(3), uint16(8))), PG12)
+		decoded, err = DecodeRmgrData(record)
+		require.NoError(t, err)
+		vacuum = decoded.(*BtreeVacuum)
+		assert.Equal(t, BlockNumber(9), vacuum.LastBlockVacuumed)
+		assert.Equal(t, []OffsetNumber{3, 8}, vacuum.Deleted)
+	})
+	t.Run("delete", func(t *testing.T) {
+		record := testRecord(t, RM_BTREE_ID, XLOG_BTREE_DELETE,
+			le(uint32(900), uint16(1), uint16(0), uint8(1)), le(uint16(7)))
+		decoded, err := DecodeRmgrData(record)
+		require.NoError(t, err)
+		del := decoded.(*BtreeDelete)
+		assert.Equal(t, TransactionId(900), del.SnapshotConflictHorizon)
+		assert.True(t, del.IsCatalogRel)
+		assert.Equal(t, []OffsetNumber{7}, del.Deleted)
+		assert.Empty(t, del.Updates)
+
+		// the offsets follow the header in the main data up to PostgreSQL 13
+		for _, v := range []PgVersion{PG12, PG13} {
+			record = versioned(testRecord(t, RM_BTREE_ID, XLOG_BTREE_DELETE,
+				le(uint32(900), uint32(2), uint16(7), uint16(8)), nil), v)
+			decoded, err = DecodeRmgrData(record)
+			require.NoError(t, err)
+			del = decoded.(*BtreeDelete)
+			assert.Equal(t, TransactionId(900), del.SnapshotConflictHorizon)
+			assert.Equal(t, []OffsetNumber{7, 8}, del.Deleted)
+		}
+		record = versioned(testRecord(t, RM_BTREE_ID, XLOG_BTREE_DELETE,
+			le(uint32(900), uint32(2), uint16(7)), nil), PG13)
+		_, err = DecodeRmgrData(record)
+		assert.ErrorIs(t, err, ErrInvalidRecord)
+	})
+	t.Run("mark page halfdead", func(t *testing.T) {
+		record := testRecord(t, RM_BTREE_ID, XLOG_BTREE_MARK_PAGE_HALFDEAD,
+			le(uint16(2), uint16(0), uint32(10), uint32(9), uint32(11), uint32(4)), nil, nil)
+		decoded, err := DecodeRmgrData(record)
+		require.NoError(t, err)
+		halfdead := decoded.(*BtreeMarkPageHalfdead)
+		assert.EqualValues(t, 2, halfdead.Poffset)
+		assert.Equal(t, BlockNumber(10), halfdead.Leafblk)
+		assert.Equal(t, BlockNumber(4), halfdead.Topparent)
+	})
+	t.Run("unlink page", func(t *testing.T) {
+		record := testRecord(t, RM_BTREE_ID, XLOG_BTREE_UNLINK_PAGE_META,
+			le(uint32(9), uint32(11), uint32(1), uint32(0), uint64(1<<32|700),
+				uint32(20), uint32(21), uint32(22)),
+			nil, nil, nil, nil, metadata)
+		decoded, err := DecodeRmgrData(record)
+		require.NoError(t, err)
+		unlink := decoded.(*BtreeUnlinkPage)
+		assert.Equal(t, uint64(1<<32|700), unlink.Safexid)
+		assert.Equal(t, BlockNumber(22), unlink.Leaftopparent)
+		require.NotNil(t, unlink.Metadata)
+
+		record = versioned(testRecord(t, RM_BTREE_ID, XLOG_BTREE_UNLINK_PAGE,
+			le(uint32(9), uint32(11), uint32(20), uint32(21), uint32(22), uint32(700)), nil), PG13)
+		decoded, err = DecodeRmgrData(record)
+		require.NoError(t, err)
+		unlink = decoded.(*BtreeUnlinkPage)
+		assert.Equal(t, BlockNumber(11), unlink.Rightsib)
+		assert.Equal(t, uint64(700), unlink.Safexid)
+		assert.Equal(t, BlockNumber(22), unlink.Leaftopparent)
+		assert.Nil(t, unlink.Metadata)
+	})
+	t.Run("newroot", func(t *testing.T) {
+		record := testRecord(t, RM_BTREE_ID, XLOG_BTREE_NEWROOT, le(uint32(0), uint32(0)),
+			nil, nil, metadata)
+		decoded, err := DecodeRmgrData(record)
+		require.NoError(t, err)
+		newroot := decoded.(*BtreeNewroot)
+		assert.Nil(t, newroot.Items)
+		assert.Equal(t, uint32(4), newroot.Metadata.Version)
+	})
+	t.Run("reuse page", func(t *testing.T) {
+		record := testRecord(t, RM_BTREE_ID, XLOG_BTREE_REUSE_PAGE,
+			le(uint32(1663), uint32(5), uint32(16384), uint32(7), uint64(800), uint8(0)))
+		decoded, err := DecodeRmgrData(record)
+		require.NoError(t, err)
+		reuse := decoded.(*BtreeReusePage)
+		assert.Equal(t, RelFileNode{1663, 5, 16384}, reuse.Node)
+		assert.Equal(t, uint64(800), reuse.SnapshotConflictHorizon)
+
+		record = versioned(testRecord(t, RM_BTREE_ID, XLOG_BTREE_REUSE_PAGE,
+			le(uint32(1663), uint32(5), uint32(16384), uint32(7), uint32(800))), PG12)
+		decoded, err = DecodeRmgrData(record)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(800), decoded.(*BtreeReusePage).SnapshotConflictHorizon)
+	})
+	t.Run("meta cleanup", func(t *testing.T) {
+		record := testRecord(t, RM_BTREE_ID, XLOG_BTREE_META_CLEANUP, nil, metadata)
+		decoded, err := DecodeRmgrData(record)
+		require.NoError(t, err)
+		assert.Equal(t, BlockNumber(3), decoded.(*BtreeMetaCleanup).Metadata.Fastroot)
+	})
+	t.Run("truncated", func(t *testing.T) {
+		record := testRecord(t, RM_BTREE_ID, XLOG_BTREE_SPLIT_L,
+			le(uint32(0), uint16(10), uint16(4), uint16(0)), indexTuple(1))
+		_, err := DecodeRmgrData(record)
+		assert.ErrorIs(t, err, ErrInvalidRecord)
+	})
+}
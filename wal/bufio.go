@@ -1,6 +1,7 @@
 package wal
 
 import (
+	"io"
 	"os"
 )
 
@@ -16,3 +17,24 @@ func (b *BufFile) Discard(n int) (int, error) {
 	v := make([]byte, n)
 	return b.Read(v)
 }
+
+// segmentReader reads a segment file from any WALSource, which may be
+// compressed or held in memory.
+type segmentReader struct {
+	io.ReadCloser
+}
+
+func (b *segmentReader) Discard(n int) (int, error) {
+	if n == 0 {
+		return 0, nil
+	}
+
+	if seeker, ok := b.ReadCloser.(io.Seeker); ok {
+		_, err := seeker.Seek(int64(n), io.SeekCurrent)
+		if err == nil {
+			return n, nil
+		}
+	}
+	m, err := io.CopyN(io.Discard, b, int64(n))
+	return int(m), err
+}
@@ -0,0 +1,185 @@
+package wal
+
+import (
+	"encoding/binary"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// walBuilder lays records out in segments the same way XLogInsert does, so
+// tests do not depend on a running server.
+type walBuilder struct {
+	tli         TimeLineID
+	segmentSize uint32
+	blockSize   uint32
+	magic       uint16
+
+	start XLogRecPtr
+	buf   []byte
+	prev  XLogRecPtr
+}
+
+func newWalBuilder(tli TimeLineID, start XLogRecPtr) *walBuilder {
+	return newWalBuilderVersion(tli, start, PG12)
+}
+
+// newWalBuilderVersion returns a builder writing the page magic of version v.
+func newWalBuilderVersion(tli TimeLineID, start XLogRecPtr, v PgVersion) *walBuilder {
+	b := &walBuilder{tli: tli, segmentSize: 1024 * 1024, blockSize: 8192, magic: v.PageMagic(), start: start}
+	b.pageHeader(0)
+	return b
+}
+
+// fork returns a copy of the builder which goes on writing on timeline tli.
+func (b *walBuilder) fork(tli TimeLineID) *walBuilder {
+	ret := *b
+	ret.buf = append([]byte(nil), b.buf...)
+	ret.tli = tli
+	return &ret
+}
+
+func (b *walBuilder) pos() XLogRecPtr {
+	return b.start + XLogRecPtr(len(b.buf))
+}
+
+func (b *walBuilder) pageHeader(remLen uint32) {
+	info := uint16(0)
+	if remLen > 0 {
+		info |= XLP_FIRST_IS_CONTRECORD
+	}
+	b.pageHeaderInfo(info, remLen)
+}
+
+func (b *walBuilder) pageHeaderInfo(info uint16, remLen uint32) {
+	pos := b.pos()
+	size := SizeofXLogPageHeaderData()
+	if pos%XLogRecPtr(b.segmentSize) == 0 {
+		info |= XLP_LONG_HEADER
+		size = SizeofXLogLongPageHeaderData()
+	}
+	hdr := make([]byte, size)
+	binary.LittleEndian.PutUint16(hdr[0:], b.magic)
+	binary.LittleEndian.PutUint16(hdr[2:], info)
+	binary.LittleEndian.PutUint32(hdr[4:], uint32(b.tli))
+	binary.LittleEndian.PutUint64(hdr[8:], uint64(pos))
+	binary.LittleEndian.PutUint32(hdr[16:], remLen)
+	if size == SizeofXLogLongPageHeaderData() {
+		binary.LittleEndian.PutUint64(hdr[24:], 7318234115429318656)
+		binary.LittleEndian.PutUint32(hdr[32:], b.segmentSize)
+		binary.LittleEndian.PutUint32(hdr[36:], b.blockSize)
+	}
+	b.buf = append(b.buf, hdr...)
+}
+
+// write appends data, breaking it up with page headers where needed.
+func (b *walBuilder) write(data []byte) {
+	for len(data) > 0 {
+		if b.pos()%XLogRecPtr(b.blockSize) == 0 {
+			b.pageHeader(uint32(len(data)))
+		}
+		free := int(b.blockSize - uint32(b.pos()%XLogRecPtr(b.blockSize)))
+		if free > len(data) {
+			free = len(data)
+		}
+		b.buf = append(b.buf, data[:free]...)
+		data = data[free:]
+	}
+}
+
+func (b *walBuilder) align() {
+	for b.pos()%8 != 0 {
+		b.buf = append(b.buf, 0)
+	}
+	if b.pos()%XLogRecPtr(b.blockSize) == 0 {
+		b.pageHeader(0)
+	}
+}
+
+// record appends a record whose payload after the XLogRecord header is body
+// and returns its lsn.
+func (b *walBuilder) record(rmid RmgrId, info uint8, xid TransactionId, body []byte) XLogRecPtr {
+	b.align()
+	lsn := b.pos()
+
+	rec := make([]byte, SizeofXLogRecord(), int(SizeofXLogRecord())+len(body))
+	binary.LittleEndian.PutUint32(rec[0:], uint32(len(rec)+len(body)))
+	binary.LittleEndian.PutUint32(rec[4:], uint32(xid))
+	binary.LittleEndian.PutUint64(rec[8:], uint64(b.prev))
+	rec[16] = info
+	rec[17] = uint8(rmid)
+	rec = append(rec, body...)
+	crc := crc32.Checksum(rec[SizeofXLogRecord():], crc32.MakeTable(crc32.Castagnoli))
+	crc = crc32.Update(crc, crc32.MakeTable(crc32.Castagnoli), rec[:20])
+	binary.LittleEndian.PutUint32(rec[20:], crc)
+
+	b.write(rec)
+	b.prev = lsn
+	return lsn
+}
+
+// abortedRecord appends the beginning of a record which never got finished,
+// the next page tells that it has been overwritten.
+func (b *walBuilder) abortedRecord() {
+	b.align()
+	rec := make([]byte, SizeofXLogRecord())
+	binary.LittleEndian.PutUint32(rec[0:], uint32(b.blockSize))
+	binary.LittleEndian.PutUint64(rec[8:], uint64(b.prev))
+	rec[17] = uint8(RM_HEAP_ID)
+	b.buf = append(b.buf, rec...)
+	for b.pos()%XLogRecPtr(b.blockSize) != 0 {
+		b.buf = append(b.buf, 0xAB)
+	}
+	b.pageHeaderInfo(XLP_FIRST_IS_OVERWRITE_CONTRECORD, 0)
+}
+
+// xlogSwitch appends an XLOG_SWITCH record and pads the rest of the segment.
+func (b *walBuilder) xlogSwitch() XLogRecPtr {
+	lsn := b.record(RM_XLOG_ID, XLOG_SWITCH, 0, nil)
+	for b.pos()%XLogRecPtr(b.segmentSize) != 0 {
+		if b.pos()%XLogRecPtr(b.blockSize) == 0 {
+			b.pageHeader(0)
+			continue
+		}
+		b.buf = append(b.buf, 0)
+	}
+	b.pageHeader(0)
+	return lsn
+}
+
+// segments returns the content of every segment, keyed by file name. The
+// tail of the last segment is zero filled.
+func (b *walBuilder) segments() map[string][]byte {
+	data := b.buf
+	if rem := len(data) % int(b.segmentSize); rem != 0 {
+		data = append(data, make([]byte, int(b.segmentSize)-rem)...)
+	}
+	ret := make(map[string][]byte)
+	for off := 0; off < len(data); off += int(b.segmentSize) {
+		name, _ := WalName(b.tli, b.start+XLogRecPtr(off), b.segmentSize)
+		ret[name] = data[off : off+int(b.segmentSize)]
+	}
+	return ret
+}
+
+// dump writes the segments into a temporary directory.
+func (b *walBuilder) dump(t *testing.T) string {
+	dir := t.TempDir()
+	for name, data := range b.segments() {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), data, 0o600))
+	}
+	return dir
+}
+
+// mainData encodes data as the main data of a record without block references.
+func mainData(data []byte) []byte {
+	if len(data) < 256 {
+		return append([]byte{XLR_BLOCK_ID_DATA_SHORT, uint8(len(data))}, data...)
+	}
+	ret := []byte{XLR_BLOCK_ID_DATA_LONG, 0, 0, 0, 0}
+	binary.LittleEndian.PutUint32(ret[1:], uint32(len(data)))
+	return append(ret, data...)
+}
@@ -0,0 +1,84 @@
+package wal
+
+/* XLOG stuff */
+const (
+	CLOG_ZEROPAGE = 0x00
+	CLOG_TRUNCATE = 0x10
+)
+
+type XlClogTruncate struct {
+	Pageno       int64
+	OldestXact   TransactionId
+	OldestXactDb Oid
+}
+
+/* xl_clog_truncate up to PostgreSQL 16, with an int pageno */
+type xlClogTruncate16 struct {
+	Pageno       int32
+	OldestXact   TransactionId
+	OldestXactDb Oid
+}
+
+func SizeofXlClogTruncate(v PgVersion) int64 {
+	if v >= PG17 {
+		return 16
+	}
+	return 12
+}
+
+type ClogZeroPage struct {
+	Pageno int64
+}
+
+type ClogTruncate struct {
+	XlClogTruncate
+}
+
+// readSlruPageno reads the SLRU page number logged by the ZEROPAGE records,
+// an int64 from PostgreSQL 17 and an int before.
+func readSlruPageno(record *Record, data []byte) (int64, error) {
+	if record.Version >= PG17 {
+		pageno, err := readStruct[int64](record, data, 8)
+		if err != nil {
+			return 0, err
+		}
+		return *pageno, nil
+	}
+	pageno, err := readStruct[int32](record, data, 4)
+	if err != nil {
+		return 0, err
+	}
+	return int64(*pageno), nil
+}
+
+// DecodeClog decodes the records of RM_CLOG_ID into the struct of their info
+// code, e.g. *ClogTruncate.
+func DecodeClog(record *Record) (interface{}, error) {
+	data := record.MainData
+	switch record.Info() {
+	case CLOG_ZEROPAGE:
+		pageno, err := readSlruPageno(record, data)
+		if err != nil {
+			return nil, err
+		}
+		return &ClogZeroPage{Pageno: pageno}, nil
+	case CLOG_TRUNCATE:
+		if record.Version >= PG17 {
+			xlrec, err := readStruct[XlClogTruncate](record, data, SizeofXlClogTruncate(record.Version))
+			if err != nil {
+				return nil, err
+			}
+			return &ClogTruncate{XlClogTruncate: *xlrec}, nil
+		}
+		xlrec, err := readStruct[xlClogTruncate16](record, data, SizeofXlClogTruncate(record.Version))
+		if err != nil {
+			return nil, err
+		}
+		return &ClogTruncate{XlClogTruncate: XlClogTruncate{
+			Pageno:       int64(xlrec.Pageno),
+			OldestXact:   xlrec.OldestXact,
+			OldestXactDb: xlrec.OldestXactDb,
+		}}, nil
+	}
+	return nil, record.unknownInfo()
+}
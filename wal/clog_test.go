@@ -0,0 +1,26 @@
+package wal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeClog(t *testing.T) {
+	decoded, err := DecodeRmgrData(testRecord(t, RM_CLOG_ID, CLOG_ZEROPAGE, le(uint32(3))))
+	require.NoError(t, err)
+	assert.Equal(t, &ClogZeroPage{Pageno: 3}, decoded)
+
+	decoded, err = DecodeRmgrData(testRecord(t, RM_CLOG_ID, CLOG_TRUNCATE, le(uint32(3), uint32(100000), uint32(5))))
+	require.NoError(t, err)
+	assert.Equal(t, &ClogTruncate{XlClogTruncate{Pageno: 3, OldestXact: 100000, OldestXactDb: 5}}, decoded)
+
+	decoded, err = DecodeRmgrData(versioned(testRecord(t, RM_CLOG_ID, CLOG_TRUNCATE,
+		le(uint64(3), uint32(100000), uint32(5))), PG17))
+	require.NoError(t, err)
+	assert.Equal(t, &ClogTruncate{XlClogTruncate{Pageno: 3, OldestXact: 100000, OldestXactDb: 5}}, decoded)
+
+	_, err = DecodeRmgrData(versioned(testRecord(t, RM_CLOG_ID, CLOG_ZEROPAGE, le(uint32(3))), PG17))
+	assert.ErrorIs(t, err, ErrInvalidRecord)
+}
@@ -0,0 +1,95 @@
+package wal
+
+/* XLOG stuff */
+const (
+	COMMIT_TS_ZEROPAGE = 0x00
+	COMMIT_TS_TRUNCATE = 0x10
+	COMMIT_TS_SETTS    = 0x20 /* up to PostgreSQL 13 */
+)
+
+type XlCommitTsTruncate struct {
+	Pageno    int64
+	OldestXid TransactionId
+}
+
+/* xl_commit_ts_truncate up to PostgreSQL 16, with an int pageno */
+type xlCommitTsTruncate16 struct {
+	Pageno    int32
+	OldestXid TransactionId
+}
+
+func SizeofXlCommitTsTruncate(v PgVersion) int64 {
+	if v >= PG17 {
+		return 12
+	}
+	return 8
+}
+
+type XlCommitTsSet struct {
+	Timestamp TimestampTz
+	Nodeid    RepOriginId
+	Mainxid   TransactionId
+	/* subxact Xids follow */
+}
+
+func SizeofXlCommitTsSet() int64 {
+	return 16
+}
+
+type CommitTsZeroPage struct {
+	Pageno int64
+}
+
+type CommitTsTruncate struct {
+	XlCommitTsTruncate
+}
+
+type CommitTsSet struct {
+	XlCommitTsSet
+	Subxids []TransactionId
+}
+
+// DecodeCommitTs decodes the records of RM_COMMIT_TS_ID into the struct of
+// their info code, e.g. *CommitTsTruncate.
+func DecodeCommitTs(record *Record) (interface{}, error) {
+	data := record.MainData
+	switch record.Info() {
+	case COMMIT_TS_ZEROPAGE:
+		pageno, err := readSlruPageno(record, data)
+		if err != nil {
+			return nil, err
+		}
+		return &CommitTsZeroPage{Pageno: pageno}, nil
+	case COMMIT_TS_TRUNCATE:
+		if record.Version >= PG17 {
+			xlrec, err := readStruct[XlCommitTsTruncate](record, data, SizeofXlCommitTsTruncate(record.Version))
+			if err != nil {
+				return nil, err
+			}
+			return &CommitTsTruncate{XlCommitTsTruncate: *xlrec}, nil
+		}
+		xlrec, err := readStruct[xlCommitTsTruncate16](record, data, SizeofXlCommitTsTruncate(record.Version))
+		if err != nil {
+			return nil, err
+		}
+		return &CommitTsTruncate{XlCommitTsTruncate: XlCommitTsTruncate{
+			Pageno:    int64(xlrec.Pageno),
+			OldestXid: xlrec.OldestXid,
+		}}, nil
+	case COMMIT_TS_SETTS:
+		if record.Version >= PG14 {
+			break
+		}
+		xlrec, err := readStruct[XlCommitTsSet](record, data, SizeofXlCommitTsSet())
+		if err != nil {
+			return nil, err
+		}
+		rest := data[SizeofXlCommitTsSet():]
+		subxids, err := readArray[TransactionId](record, rest, len(rest)/4)
+		if err != nil {
+			return nil, err
+		}
+		return &CommitTsSet{XlCommitTsSet: *xlrec, Subxids: subxids}, nil
+	}
+	return nil, record.unknownInfo()
+}
@@ -0,0 +1,36 @@
+package wal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeCommitTs(t *testing.T) {
+	decoded, err := DecodeRmgrData(testRecord(t, RM_COMMIT_TS_ID, COMMIT_TS_ZEROPAGE, le(uint32(2))))
+	require.NoError(t, err)
+	assert.Equal(t, &CommitTsZeroPage{Pageno: 2}, decoded)
+
+	decoded, err = DecodeRmgrData(testRecord(t, RM_COMMIT_TS_ID, COMMIT_TS_TRUNCATE, le(uint32(2), uint32(4000))))
+	require.NoError(t, err)
+	assert.Equal(t, &CommitTsTruncate{XlCommitTsTruncate{Pageno: 2, OldestXid: 4000}}, decoded)
+
+	decoded, err = DecodeRmgrData(versioned(testRecord(t, RM_COMMIT_TS_ID, COMMIT_TS_TRUNCATE,
+		le(uint64(2), uint32(4000))), PG17))
+	require.NoError(t, err)
+	assert.Equal(t, &CommitTsTruncate{XlCommitTsTruncate{Pageno: 2, OldestXid: 4000}}, decoded)
+}
+
+func TestDecodeCommitTsSetTs(t *testing.T) {
+	record := testRecord(t, RM_COMMIT_TS_ID, COMMIT_TS_SETTS, le(uint64(42), uint16(1), uint16(0), uint32(900), uint32(901)))
+	decoded, err := DecodeRmgrData(versioned(record, PG13))
+	require.NoError(t, err)
+	assert.Equal(t, &CommitTsSet{
+		XlCommitTsSet: XlCommitTsSet{Timestamp: 42, Nodeid: 1, Mainxid: 900},
+		Subxids:       []TransactionId{901},
+	}, decoded)
+
+	_, err = DecodeRmgrData(versioned(record, PG14))
+	assert.ErrorIs(t, err, ErrInvalidRecord)
+}
@@ -0,0 +1,120 @@
+package wal
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"io/fs"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/pierrec/lz4/v4"
+)
+
+// PartialSuffix marks a segment which was not complete when it was written
+// out, by a standby being promoted or by pg_receivewal. The tail of such a
+// segment may hold pages of an earlier use of the file.
+const PartialSuffix = ".partial"
+
+// compressionSuffixes are the suffixes archive_command and pg_receivewal
+// append to compressed segment file names.
+var compressionSuffixes = []string{".gz", ".zst", ".lz4"}
+
+var (
+	gzipMagic = []byte{0x1F, 0x8B}
+	zstdMagic = []byte{0x28, 0xB5, 0x2F, 0xFD}
+	lz4Magic  = []byte{0x04, 0x22, 0x4D, 0x18}
+)
+
+// TrimSegmentSuffix strips the compression and PartialSuffix suffixes from
+// the name of a segment file, so that it can be given to PageLSN or
+// ParseWalName.
+func TrimSegmentSuffix(name string) string {
+	for {
+		trimmed := strings.TrimSuffix(name, PartialSuffix)
+		for _, suffix := range compressionSuffixes {
+			trimmed = strings.TrimSuffix(trimmed, suffix)
+		}
+		if trimmed == name {
+			return name
+		}
+		name = trimmed
+	}
+}
+
+// openSegmentFile opens the segment file name, or its compressed or partial
+// version, and returns a reader of its decompressed content. A complete
+// segment is preferred over a partial one.
+func openSegmentFile(open func(name string) (io.ReadCloser, error), name string) (io.ReadCloser, error) {
+	var first error
+	for _, partial := range []string{"", PartialSuffix} {
+		for _, suffix := range append([]string{""}, compressionSuffixes...) {
+			rc, err := open(name + suffix + partial)
+			if err == nil {
+				return decompress(rc)
+			}
+			if !errors.Is(err, fs.ErrNotExist) {
+				return nil, err
+			}
+			if first == nil {
+				first = err
+			}
+		}
+	}
+	return nil, first
+}
+
+// decompress recognizes a compressed segment by its magic bytes and returns
+// a reader of the decompressed content. Other content is returned as is.
+func decompress(rc io.ReadCloser) (io.ReadCloser, error) {
+	buffered := bufio.NewReader(rc)
+	magic, err := buffered.Peek(4)
+	if err != nil && err != io.EOF {
+		rc.Close()
+		return nil, err
+	}
+
+	switch {
+	case bytes.HasPrefix(magic, gzipMagic):
+		zr, err := gzip.NewReader(buffered)
+		if err != nil {
+			rc.Close()
+			return nil, err
+		}
+		return &decompressedFile{Reader: zr, closers: []io.Closer{zr, rc}}, nil
+	case bytes.HasPrefix(magic, zstdMagic):
+		zr, err := zstd.NewReader(buffered, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			rc.Close()
+			return nil, err
+		}
+		return &decompressedFile{Reader: zr, closers: []io.Closer{zr.IOReadCloser(), rc}}, nil
+	case bytes.HasPrefix(magic, lz4Magic):
+		return &decompressedFile{Reader: lz4.NewReader(buffered), closers: []io.Closer{rc}}, nil
+	}
+	if seeker, ok := rc.(io.ReadSeekCloser); ok {
+		// rewind rather than buffer, so that the file stays seekable.
+		_, err = seeker.Seek(0, io.SeekStart)
+		if err == nil {
+			return seeker, nil
+		}
+	}
+	return &decompressedFile{Reader: buffered, closers: []io.Closer{rc}}, nil
+}
+
+type decompressedFile struct {
+	io.Reader
+	closers []io.Closer
+}
+
+func (f *decompressedFile) Close() error {
+	var ret error
+	for _, closer := range f.closers {
+		if err := closer.Close(); err != nil && ret == nil {
+			ret = err
+		}
+	}
+	return ret
+}
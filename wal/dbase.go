@@ -0,0 +1,108 @@
+package wal
+
+/* record types */
+const (
+	XLOG_DBASE_CREATE_FILE_COPY = 0x00
+	/* Since PostgreSQL 15 */
+	XLOG_DBASE_CREATE_WAL_LOG = 0x10
+	XLOG_DBASE_DROP           = 0x20
+
+	/* Up to PostgreSQL 14 */
+	XLOG_DBASE_CREATE_PRE15 = 0x00
+	XLOG_DBASE_DROP_PRE15   = 0x10
+)
+
+/*
+ * Single WAL record for an entire CREATE DATABASE operation. This is used
+ * by the FILE_COPY strategy.
+ */
+type XlDbaseCreateFileCopyRec struct {
+	DbId            Oid
+	TablespaceId    Oid
+	SrcDbId         Oid
+	SrcTablespaceId Oid
+}
+
+func SizeofXlDbaseCreateFileCopyRec() int64 {
+	return 16
+}
+
+/*
+ * WAL record for the beginning of a CREATE DATABASE operation, when the
+ * WAL_LOG strategy is used. Each individual block will be logged separately
+ * afterward.
+ */
+type XlDbaseCreateWalLogRec struct {
+	DbId         Oid
+	TablespaceId Oid
+}
+
+func SizeofXlDbaseCreateWalLogRec() int64 {
+	return 8
+}
+
+type XlDbaseDropRec struct {
+	DbId         Oid
+	Ntablespaces int32 /* number of tablespace IDs */
+	/* tablespace_ids[FLEXIBLE_ARRAY_MEMBER] follow */
+}
+
+func SizeofXlDbaseDropRec() int64 {
+	return 8
+}
+
+type DbaseCreateFileCopy struct {
+	XlDbaseCreateFileCopyRec
+}
+
+type DbaseCreateWalLog struct {
+	XlDbaseCreateWalLogRec
+}
+
+// DbaseDrop decodes XLOG_DBASE_DROP. Up to PostgreSQL 12 a record drops the
+// database directory of a single tablespace.
+type DbaseDrop struct {
+	DbId          Oid
+	TablespaceIds []Oid
+}
+
+// DecodeDbase decodes the records of RM_DBASE_ID into the struct of their
+// info code, e.g. *DbaseDrop.
+func DecodeDbase(record *Record) (interface{}, error) {
+	data := record.MainData
+	info := record.Info()
+	switch {
+	case info == XLOG_DBASE_CREATE_FILE_COPY:
+		xlrec, err := readStruct[XlDbaseCreateFileCopyRec](record, data, SizeofXlDbaseCreateFileCopyRec())
+		if err != nil {
+			return nil, err
+		}
+		return &DbaseCreateFileCopy{XlDbaseCreateFileCopyRec: *xlrec}, nil
+	case record.Version >= PG15 && info == XLOG_DBASE_CREATE_WAL_LOG:
+		xlrec, err := readStruct[XlDbaseCreateWalLogRec](record, data, SizeofXlDbaseCreateWalLogRec())
+		if err != nil {
+			return nil, err
+		}
+		return &DbaseCreateWalLog{XlDbaseCreateWalLogRec: *xlrec}, nil
+	case record.Version >= PG15 && info == XLOG_DBASE_DROP,
+		record.Version < PG15 && info == XLOG_DBASE_DROP_PRE15:
+		if record.Version < PG13 {
+			// db_id and a single tablespace_id up to PostgreSQL 12
+			oids, err := readArray[Oid](record, data, 2)
+			if err != nil {
+				return nil, err
+			}
+			return &DbaseDrop{DbId: oids[0], TablespaceIds: oids[1:]}, nil
+		}
+		xlrec, err := readStruct[XlDbaseDropRec](record, data, SizeofXlDbaseDropRec())
+		if err != nil {
+			return nil, err
+		}
+		tablespaceIds, err := readArray[Oid](record, data[SizeofXlDbaseDropRec():], int(xlrec.Ntablespaces))
+		if err != nil {
+			return nil, err
+		}
+		return &DbaseDrop{DbId: xlrec.DbId, TablespaceIds: tablespaceIds}, nil
+	}
+	return nil, record.unknownInfo()
+}
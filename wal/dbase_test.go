@@ -0,0 +1,42 @@
+package wal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeDbase(t *testing.T) {
+	decoded, err := DecodeRmgrData(testRecord(t, RM_DBASE_ID, XLOG_DBASE_CREATE_FILE_COPY,
+		le(uint32(16400), uint32(1663), uint32(1), uint32(1663))))
+	require.NoError(t, err)
+	assert.Equal(t, &DbaseCreateFileCopy{XlDbaseCreateFileCopyRec{16400, 1663, 1, 1663}}, decoded)
+
+	decoded, err = DecodeRmgrData(testRecord(t, RM_DBASE_ID, XLOG_DBASE_CREATE_WAL_LOG,
+		le(uint32(16400), uint32(1663))))
+	require.NoError(t, err)
+	assert.Equal(t, &DbaseCreateWalLog{XlDbaseCreateWalLogRec{16400, 1663}}, decoded)
+
+	decoded, err = DecodeRmgrData(testRecord(t, RM_DBASE_ID, XLOG_DBASE_DROP,
+		le(uint32(16400), uint32(2), uint32(1663), uint32(16500))))
+	require.NoError(t, err)
+	assert.Equal(t, &DbaseDrop{DbId: 16400, TablespaceIds: []Oid{1663, 16500}}, decoded)
+
+	t.Run("pre 15", func(t *testing.T) {
+		record := versioned(testRecord(t, RM_DBASE_ID, XLOG_DBASE_DROP_PRE15,
+			le(uint32(16400), uint32(1), uint32(1663))), PG14)
+		decoded, err := DecodeRmgrData(record)
+		require.NoError(t, err)
+		assert.Equal(t, &DbaseDrop{DbId: 16400, TablespaceIds: []Oid{1663}}, decoded)
+
+		record = versioned(testRecord(t, RM_DBASE_ID, XLOG_DBASE_DROP_PRE15,
+			le(uint32(16400), uint32(1663))), PG12)
+		decoded, err = DecodeRmgrData(record)
+		require.NoError(t, err)
+		assert.Equal(t, &DbaseDrop{DbId: 16400, TablespaceIds: []Oid{1663}}, decoded)
+
+		_, err = DecodeRmgrData(versioned(testRecord(t, RM_DBASE_ID, XLOG_DBASE_DROP, le(uint32(1))), PG14))
+		assert.ErrorIs(t, err, ErrInvalidRecord)
+	})
+}
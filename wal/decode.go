@@ -2,28 +2,39 @@ package wal
 
 import (
 	"bytes"
+	"fmt"
 	"io"
 )
 
 type RawRecord struct {
-	LSN  XLogRecPtr
-	Hdr  *XLogRecord
-	data []byte
+	LSN     XLogRecPtr
+	Hdr     *XLogRecord
+	Version PgVersion
+	data    []byte
 }
 
+// Decode splits the record into its block references and main data, like
+// DecodeXLogRecord. A record whose headers do not add up to XlTotlen is
+// reported with ErrInvalidRecord.
 func (rr *RawRecord) Decode() (*Record, error) {
+	if int64(len(rr.data)) != int64(rr.Hdr.XlTotlen)-SizeofXLogRecord() {
+		return nil, rr.errorf("record length %d does not match xl_tot_len %d", int64(len(rr.data))+SizeofXLogRecord(), rr.Hdr.XlTotlen)
+	}
 	reader := bytes.NewReader(rr.data)
 	ret := &Record{
-		LSN: rr.LSN,
-		Hdr: rr.Hdr,
+		LSN:     rr.LSN,
+		Hdr:     rr.Hdr,
+		Version: rr.Version,
 	}
-	total := rr.Hdr.XlTotlen - uint32(SizeofXLogRecord())
-	queried := int64(0)
-LOOP:
-	for total > uint32(queried) {
+	var (
+		datatotal  int64
+		maxBlockId = -1
+		prevRel    *RelFileNode
+	)
+	for int64(reader.Len()) > datatotal {
 		bid, err := ReadReferenceId(reader)
 		if err != nil {
-			return nil, err
+			return nil, rr.shortData()
 		}
 
 		switch {
@@ -33,70 +44,92 @@ LOOP:
 				bheader *XLogRecordBlockHeader
 				iheader *XLogRecordBlockImageHeader
 			)
+			if int(bid) <= maxBlockId {
+				return nil, rr.errorf("out-of-order block_id %d", bid)
+			}
+			maxBlockId = int(bid)
 			bheader, err = ReadXLogRecordBlockHeader(reader, bid)
 			if err != nil {
-				return nil, err
+				return nil, rr.shortData()
+			}
+			if bheader.HasData() && bheader.DataLength == 0 {
+				return nil, rr.errorf("BKPBLOCK_HAS_DATA set, but no data included")
+			}
+			if !bheader.HasData() && bheader.DataLength != 0 {
+				return nil, rr.errorf("BKPBLOCK_HAS_DATA not set, but data length is %d", bheader.DataLength)
 			}
 			block.Bheader = bheader
-			queried += SizeofXLogRecordBlockHeader() + int64(bheader.DataLength)
+			block.version = rr.Version
+			datatotal += int64(bheader.DataLength)
 			if bheader.HasImage() {
 				iheader, err = ReadXLogRecordBlockImageHeader(reader)
 				if err != nil {
-					return nil, err
+					return nil, rr.shortData()
 				}
 				block.Iheader = iheader
-				queried += SizeofXLogRecordBlockImageHeader() + int64(iheader.Length)
-				if iheader.HasHole() && iheader.HasCompressed() {
+				datatotal += int64(iheader.Length)
+				if iheader.HasHole() && iheader.IsCompressed(rr.Version) {
 					cheader, err := ReadXLogRecordBlockCompressHeader(reader)
 					if err != nil {
-						return nil, err
+						return nil, rr.shortData()
 					}
 					block.Cheader = cheader
-					queried += SizeofXLogRecordBlockCompressHeader()
 				}
 			}
 			if bheader.HasFileNode() {
 				rfn, err := ReadRelFileNode(reader)
 				if err != nil {
-					return nil, err
+					return nil, rr.shortData()
 				}
-				block.RelFileNode = rfn
-				queried += SizeofRelFileNode()
+				prevRel = rfn
+			} else if prevRel == nil {
+				return nil, rr.errorf("BKPBLOCK_SAME_REL set but no previous rel")
 			}
+			block.RelFileNode = prevRel
+			block.ForkNum = bheader.ForkNum()
 			bn, err := ReadBlockNumber(reader)
 			if err != nil {
-				return nil, err
+				return nil, rr.shortData()
 			}
 			block.BlockNum = bn
-			queried += SizeofBlockNumber()
 			ret.Blocks = append(ret.Blocks, block)
 		case bid == XLR_BLOCK_ID_ORIGIN:
 			rod, err := ReadRepOriginDummy(reader, bid)
 			if err != nil {
-				return nil, err
+				return nil, rr.shortData()
 			}
 			ret.RepOriginId = rod.RepOriginId
-			queried += SizeofRepOriginDummy()
+		case bid == XLR_BLOCK_ID_TOPLEVEL_XID:
+			xid, err := ReadTransactionId(reader)
+			if err != nil {
+				return nil, rr.shortData()
+			}
+			ret.TopLevelXid = xid
 		case bid == XLR_BLOCK_ID_DATA_SHORT:
 			sheader, err := ReadXLogRecordDataHeaderShort(reader, bid)
 			if err != nil {
-				return nil, err
+				return nil, rr.shortData()
 			}
 			ret.MainData = make([]byte, sheader.DataLength)
-			break LOOP
+			datatotal += int64(sheader.DataLength)
 		case bid == XLR_BLOCK_ID_DATA_LONG:
 			_, err = ReadXLogRecordDataHeaderLong(reader, bid)
 			if err != nil {
-				return nil, err
+				return nil, rr.shortData()
 			}
 			length, err := ReadMainDataLength(reader)
 			if err != nil {
-				return nil, err
+				return nil, rr.shortData()
 			}
 			ret.MainData = make([]byte, length)
-			break LOOP
+			datatotal += int64(length)
+		default:
+			return nil, rr.errorf("invalid block_id %d", bid)
 		}
 	}
+	if int64(reader.Len()) != datatotal {
+		return nil, rr.shortData()
+	}
 
 	var err error
 	for i := range ret.Blocks {
@@ -130,11 +163,23 @@ LOOP:
 	return ret, nil
 }
 
+func (rr *RawRecord) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s at %s", ErrInvalidRecord, fmt.Sprintf(format, args...), rr.LSN)
+}
+
+// shortData reports that the lengths declared by the headers exceed the
+// record.
+func (rr *RawRecord) shortData() error {
+	return rr.errorf("record with invalid length")
+}
+
 type Record struct {
 	LSN         XLogRecPtr
 	Hdr         *XLogRecord
+	Version     PgVersion
 	Blocks      []Block
 	RepOriginId RepOriginId
+	TopLevelXid TransactionId // set on the first record of a subtransaction under wal_level=logical
 	MainData    []byte
 }
 
@@ -144,6 +189,9 @@ type Block struct {
 	Cheader     *XLogRecordBlockCompressHeader
 	PageData    []byte
 	TupleData   []byte
-	RelFileNode *RelFileNode
+	RelFileNode *RelFileNode // shared with the previous block under BKPBLOCK_SAME_REL
+	ForkNum     ForkNumber
 	BlockNum    BlockNumber
+
+	version PgVersion
 }
@@ -0,0 +1,113 @@
+package wal
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rawRecord wraps body, the payload following the XLogRecord header.
+func rawRecord(body []byte) *RawRecord {
+	return &RawRecord{
+		LSN:     0x300028,
+		Hdr:     &XLogRecord{XlTotlen: uint32(SizeofXLogRecord()) + uint32(len(body)), XlRmid: RM_HEAP_ID},
+		Version: PG16,
+		data:    body,
+	}
+}
+
+// blockHeader encodes the header of block reference id, without image.
+func blockHeader(id uint8, flags uint8, rfn *RelFileNode, blkno BlockNumber, dataLen uint16) []byte {
+	ret := []byte{id, flags, 0, 0}
+	binary.LittleEndian.PutUint16(ret[2:], dataLen)
+	if rfn != nil {
+		ret = binary.LittleEndian.AppendUint32(ret, uint32(rfn.SpcNode))
+		ret = binary.LittleEndian.AppendUint32(ret, uint32(rfn.DbNode))
+		ret = binary.LittleEndian.AppendUint32(ret, uint32(rfn.RelNode))
+	}
+	return binary.LittleEndian.AppendUint32(ret, uint32(blkno))
+}
+
+func concat(parts ...[]byte) []byte {
+	var ret []byte
+	for _, part := range parts {
+		ret = append(ret, part...)
+	}
+	return ret
+}
+
+func TestDecode(t *testing.T) {
+	rfn := &RelFileNode{1663, 5, 16384}
+	body := concat(
+		blockHeader(0, BKPBLOCK_HAS_DATA, rfn, 7, 3),
+		[]byte{XLR_BLOCK_ID_ORIGIN, 2, 0},
+		[]byte{XLR_BLOCK_ID_TOPLEVEL_XID, 0xE8, 0x03, 0, 0},
+		[]byte{XLR_BLOCK_ID_DATA_SHORT, 4},
+		[]byte("abc"),
+		[]byte("main"),
+	)
+	record, err := rawRecord(body).Decode()
+	require.NoError(t, err)
+	require.Len(t, record.Blocks, 1)
+	assert.Equal(t, rfn, record.Blocks[0].RelFileNode)
+	assert.Equal(t, BlockNumber(7), record.Blocks[0].BlockNum)
+	assert.Equal(t, []byte("abc"), record.Blocks[0].TupleData)
+	assert.Equal(t, RepOriginId(2), record.RepOriginId)
+	assert.Equal(t, TransactionId(1000), record.TopLevelXid)
+	assert.Equal(t, []byte("main"), record.MainData)
+	assert.Equal(t, PG16, record.Version)
+}
+
+func TestDecodeSameRel(t *testing.T) {
+	rfn := &RelFileNode{1663, 5, 16384}
+	body := concat(
+		blockHeader(0, uint8(MAIN_FORKNUM), rfn, 1, 0),
+		blockHeader(1, BKPBLOCK_SAME_REL|uint8(VISIBILITYMAP_FORKNUM), nil, 0, 0),
+		blockHeader(2, uint8(FSM_FORKNUM), &RelFileNode{1663, 5, 16390}, 3, 0),
+		blockHeader(3, BKPBLOCK_SAME_REL|uint8(INIT_FORKNUM), nil, 4, 0),
+	)
+	record, err := rawRecord(body).Decode()
+	require.NoError(t, err)
+	require.Len(t, record.Blocks, 4)
+	assert.Equal(t, rfn, record.Blocks[1].RelFileNode)
+	assert.Equal(t, VISIBILITYMAP_FORKNUM, record.Blocks[1].ForkNum)
+	assert.Equal(t, &RelFileNode{1663, 5, 16390}, record.Blocks[3].RelFileNode)
+	assert.Equal(t, INIT_FORKNUM, record.Blocks[3].ForkNum)
+	assert.Equal(t, []string{"main", "vm", "fsm", "init"}, []string{
+		record.Blocks[0].ForkNum.String(), record.Blocks[1].ForkNum.String(),
+		record.Blocks[2].ForkNum.String(), record.Blocks[3].ForkNum.String(),
+	})
+}
+
+func TestDecodeInvalid(t *testing.T) {
+	rfn := &RelFileNode{1663, 5, 16384}
+	cases := []struct {
+		name string
+		raw  *RawRecord
+	}{
+		{"unknown block id", rawRecord(concat([]byte{100, 0, 0, 0}, mainData([]byte("x"))))},
+		{"out-of-order block id", rawRecord(concat(
+			blockHeader(1, 0, rfn, 1, 0),
+			blockHeader(1, 0, rfn, 2, 0),
+		))},
+		{"same rel on first block", rawRecord(blockHeader(0, BKPBLOCK_SAME_REL, nil, 1, 0))},
+		{"data without flag", rawRecord(concat(blockHeader(0, 0, rfn, 1, 3), []byte("abc")))},
+		{"flag without data", rawRecord(blockHeader(0, BKPBLOCK_HAS_DATA, rfn, 1, 0))},
+		{"data longer than record", rawRecord(concat(blockHeader(0, BKPBLOCK_HAS_DATA, rfn, 1, 30), []byte("abc")))},
+		{"main data longer than record", rawRecord([]byte{XLR_BLOCK_ID_DATA_SHORT, 10, 'a'})},
+		{"truncated header", rawRecord([]byte{XLR_BLOCK_ID_TOPLEVEL_XID, 1})},
+		{"xl_tot_len", func() *RawRecord {
+			raw := rawRecord(mainData([]byte("abc")))
+			raw.Hdr.XlTotlen++
+			return raw
+		}()},
+	}
+	for _, entry := range cases {
+		t.Run(entry.name, func(t *testing.T) {
+			_, err := entry.raw.Decode()
+			assert.ErrorIs(t, err, ErrInvalidRecord)
+		})
+	}
+}
@@ -0,0 +1,1094 @@
+package wal
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	DEFAULTTABLESPACE_OID = 1663
+	GLOBALTABLESPACE_OID  = 1664
+)
+
+// rmgrDescribers append the rm_desc of the built-in resource managers, the
+// description pg_waldump shows after the rm_identify name, given the record
+// and what DecodeRmgrData decoded.
+var rmgrDescribers = map[RmgrId]func(buf *strings.Builder, record *Record, rec interface{}){
+	RM_XLOG_ID:       xlogDesc,
+	RM_XACT_ID:       xactDesc,
+	RM_SMGR_ID:       smgrDesc,
+	RM_CLOG_ID:       clogDesc,
+	RM_DBASE_ID:      dbaseDesc,
+	RM_TBLSPC_ID:     tblspcDesc,
+	RM_MULTIXACT_ID:  multixactDesc,
+	RM_RELMAP_ID:     relmapDesc,
+	RM_STANDBY_ID:    standbyDesc,
+	RM_HEAP2_ID:      heap2Desc,
+	RM_HEAP_ID:       heapDesc,
+	RM_BTREE_ID:      btreeDesc,
+	RM_HASH_ID:       hashDesc,
+	RM_GIN_ID:        ginDesc,
+	RM_GIST_ID:       gistDesc,
+	RM_SEQ_ID:        seqDesc,
+	RM_SPGIST_ID:     spgDesc,
+	RM_BRIN_ID:       brinDesc,
+	RM_COMMIT_TS_ID:  commitTsDesc,
+	RM_REPLORIGIN_ID: reploriginDesc,
+	RM_GENERIC_ID:    genericDesc,
+	RM_LOGICALMSG_ID: logicalmsgDesc,
+}
+
+// Describe formats record as the line pg_waldump prints for it, without the
+// trailing newline, e.g.
+//
+//	rmgr: Heap        len (rec/tot):     59/    59, tx:        735, lsn: 0/01529D30, prev 0/01529CF8, desc: INSERT off: 2, flags: 0x00, blkref #0: rel 1663/5/16384 blk 0
+//
+// The descriptions follow the pg_waldump of record.Version, timestamps are
+// shown in time.Local like pg_waldump shows them in the local time zone.
+func Describe(record *Record) (string, error) {
+	return describe(record, false)
+}
+
+// DescribeDetailed formats record like pg_waldump --bkp-details: the block
+// references follow the record line, one per line starting with a tab.
+func DescribeDetailed(record *Record) (string, error) {
+	return describe(record, true)
+}
+
+func describe(record *Record, detailed bool) (string, error) {
+	var buf strings.Builder
+	var fpiLen uint32
+	for i := range record.Blocks {
+		if record.Blocks[i].HasImage() {
+			fpiLen += uint32(record.Blocks[i].Iheader.Length)
+		}
+	}
+	fmt.Fprintf(&buf, "rmgr: %-11s len (rec/tot): %6d/%8d, tx: %10d, lsn: %s, prev %s, ",
+		RmgrIdName(record.Hdr.XlRmid), record.Hdr.XlTotlen-fpiLen, record.Hdr.XlTotlen,
+		record.Hdr.XlXid, record.LSN, record.Hdr.XlPrev)
+
+	info := record.Hdr.XlInfo &^ XLR_INFO_MASK
+	fmt.Fprintf(&buf, "desc: %s ", identify(record))
+	// rm_desc shows nothing for an info code it does not know
+	identifier, known := rmgrIdentifiers[record.Hdr.XlRmid]
+	if known && identifier(info, record.Version) != "" {
+		rec, err := DecodeRmgrData(record)
+		if err != nil {
+			return "", err
+		}
+		rmgrDescribers[record.Hdr.XlRmid](&buf, record, rec)
+	}
+
+	for i := range record.Blocks {
+		block := &record.Blocks[i]
+		rel := block.RelFileNode
+		if !detailed {
+			if block.ForkNum != MAIN_FORKNUM {
+				fmt.Fprintf(&buf, ", blkref #%d: rel %d/%d/%d fork %s blk %d", block.Bheader.Id, rel.SpcNode, rel.DbNode, rel.RelNode, block.ForkNum, block.BlockNum)
+			} else {
+				fmt.Fprintf(&buf, ", blkref #%d: rel %d/%d/%d blk %d", block.Bheader.Id, rel.SpcNode, rel.DbNode, rel.RelNode, block.BlockNum)
+			}
+			if block.HasImage() {
+				if block.Iheader.IsApply(record.Version) {
+					buf.WriteString(" FPW")
+				} else {
+					buf.WriteString(" FPW for WAL verification")
+				}
+			}
+			continue
+		}
+		fmt.Fprintf(&buf, "\n\tblkref #%d: rel %d/%d/%d fork %s blk %d", block.Bheader.Id, rel.SpcNode, rel.DbNode, rel.RelNode, block.ForkNum, block.BlockNum)
+		if !block.HasImage() {
+			continue
+		}
+		verification := ""
+		if !block.Iheader.IsApply(record.Version) {
+			verification = " for WAL verification"
+		}
+		fmt.Fprintf(&buf, " (FPW%s); hole: offset: %d, length: %d", verification, block.HoleOffset(), block.HoleLength())
+		if method := block.Compression(); method != "" {
+			fmt.Fprintf(&buf, ", compression saved: %d", BLCKSZ-int(block.HoleLength())-int(block.Iheader.Length))
+			if record.Version >= PG15 {
+				fmt.Fprintf(&buf, ", method: %s", method)
+			}
+		}
+	}
+	return buf.String(), nil
+}
+
+// timestamptzToStr formats ts like the timestamptz_to_str of the frontend
+// tools, in time.Local.
+func timestamptzToStr(ts TimestampTz) string {
+	t := ts.Time().Local()
+	return fmt.Sprintf("%s.%06d %s", t.Format("2006-01-02 15:04:05"), int64(ts)%1e6, t.Format("MST"))
+}
+
+// relpathperm returns the path of a fork of a relation relative to the data
+// directory, like relpathperm.
+func relpathperm(rel RelFileNode, fork ForkNumber, v PgVersion) string {
+	var path string
+	switch rel.SpcNode {
+	case GLOBALTABLESPACE_OID:
+		path = fmt.Sprintf("global/%d", rel.RelNode)
+	case DEFAULTTABLESPACE_OID:
+		path = fmt.Sprintf("base/%d/%d", rel.DbNode, rel.RelNode)
+	default:
+		path = fmt.Sprintf("pg_tblspc/%d/PG_%d_%d/%d/%d", rel.SpcNode, uint32(v), catalogVersions[v], rel.DbNode, rel.RelNode)
+	}
+	if fork != MAIN_FORKNUM {
+		path += "_" + fork.String()
+	}
+	return path
+}
+
+func walLevelString(level int32) string {
+	switch level {
+	case WAL_LEVEL_MINIMAL:
+		return "minimal"
+	case WAL_LEVEL_REPLICA:
+		return "replica"
+	case WAL_LEVEL_LOGICAL:
+		return "logical"
+	}
+	return "?"
+}
+
+func boolChar(b bool) byte {
+	if b {
+		return 'T'
+	}
+	return 'F'
+}
+
+// arrayDesc appends count elements like array_desc, elemDesc appending the
+// i-th one.
+func arrayDesc(buf *strings.Builder, count int, elemDesc func(i int)) {
+	if count == 0 {
+		buf.WriteString(" []")
+		return
+	}
+	buf.WriteString(" [")
+	for i := 0; i < count; i++ {
+		elemDesc(i)
+		if i < count-1 {
+			buf.WriteString(", ")
+		}
+	}
+	buf.WriteByte(']')
+}
+
+func offsetArrayDesc(buf *strings.Builder, offsets []OffsetNumber) {
+	arrayDesc(buf, len(offsets), func(i int) { fmt.Fprintf(buf, "%d", offsets[i]) })
+}
+
+// flagsDesc appends the names of the flags set in the brackets of
+// infobits_desc and truncate_flags_desc.
+func flagsDesc(buf *strings.Builder, key string, flags uint8, names []string) {
+	var set []string
+	for i, name := range names {
+		if flags&(1<<i) != 0 {
+			set = append(set, name)
+		}
+	}
+	fmt.Fprintf(buf, "%s: [%s]", key, strings.Join(set, ", "))
+}
+
+var infobitsNames = []string{"IS_MULTI", "LOCK_ONLY", "EXCL_LOCK", "KEYSHR_LOCK", "KEYS_UPDATED"}
+
+// outInfobits appends the infobits the way the rm_desc of heap did up to
+// PostgreSQL 15.
+func outInfobits(buf *strings.Builder, infobits uint8) {
+	for i, name := range infobitsNames {
+		if infobits&(1<<i) != 0 {
+			buf.WriteString(name + " ")
+		}
+	}
+}
+
+func xlogDesc(buf *strings.Builder, record *Record, rec interface{}) {
+	switch rec := rec.(type) {
+	case *XLogCheckPoint:
+		fmt.Fprintf(buf, "redo %s; tli %d; prev tli %d; fpw %t; ", lsnDesc(rec.Redo), rec.ThisTimeLineID, rec.PrevTimeLineID, rec.FullPageWrites)
+		if record.Version >= PG17 {
+			fmt.Fprintf(buf, "wal_level %s; ", walLevelString(rec.WalLevel))
+		}
+		fmt.Fprintf(buf, "xid %d:%d; oid %d; multi %d; offset %d; oldest xid %d in DB %d; oldest multi %d in DB %d; oldest/newest commit timestamp xid: %d/%d; oldest running xid %d; ",
+			rec.NextXidEpoch(), uint32(rec.NextXid), rec.NextOid, rec.NextMulti, rec.NextMultiOffset,
+			rec.OldestXid, rec.OldestXidDB, rec.OldestMulti, rec.OldestMultiDB,
+			rec.OldestCommitTsXid, rec.NewestCommitTsXid, rec.OldestActiveXid)
+		if rec.Shutdown {
+			buf.WriteString("shutdown")
+		} else {
+			buf.WriteString("online")
+		}
+	case *XLogNextOid:
+		fmt.Fprintf(buf, "%d", rec.NextOid)
+	case *XLogRestorePoint:
+		buf.WriteString(rec.Name())
+	case *XLogBackupEnd:
+		buf.WriteString(lsnDesc(rec.StartPoint))
+	case *XLogParameterChange:
+		fmt.Fprintf(buf, "max_connections=%d max_worker_processes=%d max_wal_senders=%d max_prepared_xacts=%d max_locks_per_xact=%d wal_level=%s wal_log_hints=%s track_commit_timestamp=%s",
+			rec.MaxConnections, rec.MaxWorkerProcesses, rec.MaxWalSenders, rec.MaxPreparedXacts,
+			rec.MaxLocksPerXact, walLevelString(rec.WalLevel), onOff(rec.WalLogHints), onOff(rec.TrackCommitTimestamp))
+	case *XLogFPWChange:
+		fmt.Fprintf(buf, "%t", rec.FullPageWrites)
+	case *XLogEndOfRecovery:
+		fmt.Fprintf(buf, "tli %d; prev tli %d; time %s", rec.ThisTimeLineID, rec.PrevTimeLineID, timestamptzToStr(rec.EndTime))
+		if record.Version >= PG17 {
+			fmt.Fprintf(buf, "; wal_level %s", walLevelString(rec.WalLevel))
+		}
+	case *XLogOverwriteContrecord:
+		fmt.Fprintf(buf, "lsn %s; time %s", lsnDesc(rec.OverwrittenLsn), timestamptzToStr(rec.OverwriteTime))
+	case *XLogCheckpointRedo:
+		fmt.Fprintf(buf, "wal_level %s", walLevelString(rec.WalLevel))
+	}
+}
+
+// lsnDesc formats lsn with LSN_FORMAT_ARGS and "%X/%X", the low half not
+// zero padded unlike XLogRecPtr.String.
+func lsnDesc(lsn XLogRecPtr) string {
+	return fmt.Sprintf("%X/%X", uint64(lsn)>>32, uint32(lsn))
+}
+
+func onOff(b bool) string {
+	if b {
+		return "on"
+	}
+	return "off"
+}
+
+func xactDesc(buf *strings.Builder, record *Record, rec interface{}) {
+	switch rec := rec.(type) {
+	case *XactCommit:
+		if rec.Twophase != InvalidTransactionId {
+			fmt.Fprintf(buf, "%d: ", rec.Twophase)
+		}
+		buf.WriteString(timestamptzToStr(rec.XactTime))
+		xactRelationsDesc(buf, "rels", rec.Xnodes, record.Version)
+		xactSubxactsDesc(buf, rec.Subxacts)
+		xactStatsDesc(buf, "", rec.Stats)
+		invalidationsDesc(buf, rec.Msgs, rec.DbId, rec.TsId, rec.Xinfo&XACT_COMPLETION_UPDATE_RELCACHE_FILE != 0)
+		if rec.Xinfo&XACT_COMPLETION_APPLY_FEEDBACK != 0 {
+			buf.WriteString("; apply_feedback")
+		}
+		if rec.Xinfo&XACT_COMPLETION_FORCE_SYNC_COMMIT != 0 {
+			buf.WriteString("; sync")
+		}
+		if rec.Xinfo&XACT_XINFO_HAS_ORIGIN != 0 {
+			xactOriginDesc(buf, record.RepOriginId, rec.OriginLsn, rec.OriginTimestamp)
+		}
+	case *XactAbort:
+		if rec.Twophase != InvalidTransactionId {
+			fmt.Fprintf(buf, "%d: ", rec.Twophase)
+		}
+		buf.WriteString(timestamptzToStr(rec.XactTime))
+		xactRelationsDesc(buf, "rels", rec.Xnodes, record.Version)
+		xactSubxactsDesc(buf, rec.Subxacts)
+		if rec.Xinfo&XACT_XINFO_HAS_ORIGIN != 0 {
+			xactOriginDesc(buf, record.RepOriginId, rec.OriginLsn, rec.OriginTimestamp)
+		}
+		xactStatsDesc(buf, "", rec.Stats)
+	case *XactPrepare:
+		if record.Version < PG13 {
+			return
+		}
+		fmt.Fprintf(buf, "gid %s: %s", rec.Gid, timestamptzToStr(rec.PreparedAt))
+		xactRelationsDesc(buf, "rels(commit)", rec.CommitRels, record.Version)
+		xactRelationsDesc(buf, "rels(abort)", rec.AbortRels, record.Version)
+		xactStatsDesc(buf, "commit ", rec.CommitStats)
+		xactStatsDesc(buf, "abort ", rec.AbortStats)
+		xactSubxactsDesc(buf, rec.Subxacts)
+		invalidationsDesc(buf, rec.Msgs, rec.Database, 0, rec.Initfileinval)
+		// the origin is set in the record the way PrepareRedoAdd checks it
+		if record.Version >= PG14 && record.RepOriginId != 0 {
+			xactOriginDesc(buf, record.RepOriginId, rec.OriginLsn, rec.OriginTimestamp)
+		}
+	case *XactAssignment:
+		fmt.Fprintf(buf, "xtop %d: subxacts:", rec.Xtop)
+		for _, xid := range rec.Xsub {
+			fmt.Fprintf(buf, " %d", xid)
+		}
+	case *XactInvalidations:
+		invalidationsDesc(buf, rec.Msgs, 0, 0, false)
+	}
+}
+
+func xactRelationsDesc(buf *strings.Builder, label string, rels []RelFileNode, v PgVersion) {
+	if len(rels) == 0 {
+		return
+	}
+	fmt.Fprintf(buf, "; %s:", label)
+	for _, rel := range rels {
+		buf.WriteString(" " + relpathperm(rel, MAIN_FORKNUM, v))
+	}
+}
+
+func xactSubxactsDesc(buf *strings.Builder, subxacts []TransactionId) {
+	if len(subxacts) == 0 {
+		return
+	}
+	buf.WriteString("; subxacts:")
+	for _, xid := range subxacts {
+		fmt.Fprintf(buf, " %d", xid)
+	}
+}
+
+func xactStatsDesc(buf *strings.Builder, label string, stats []XlXactStatsItem) {
+	if len(stats) == 0 {
+		return
+	}
+	fmt.Fprintf(buf, "; %sdropped stats:", label)
+	for _, item := range stats {
+		fmt.Fprintf(buf, " %d/%d/%d", item.Kind, item.Dboid, item.Objoid)
+	}
+}
+
+func xactOriginDesc(buf *strings.Builder, originId RepOriginId, lsn XLogRecPtr, ts TimestampTz) {
+	fmt.Fprintf(buf, "; origin: node %d, lsn %s, at %s", originId, lsnDesc(lsn), timestamptzToStr(ts))
+}
+
+// invalidationsDesc appends the invalidation messages like
+// standby_desc_invalidations.
+func invalidationsDesc(buf *strings.Builder, msgs []SharedInvalidationMessage, dbId, tsId Oid, relcacheInitFileInval bool) {
+	if len(msgs) == 0 {
+		return
+	}
+	if relcacheInitFileInval {
+		fmt.Fprintf(buf, "; relcache init file inval dbid %d tsid %d", dbId, tsId)
+	}
+	buf.WriteString("; inval msgs:")
+	for _, msg := range msgs {
+		switch {
+		case msg.Id >= 0:
+			fmt.Fprintf(buf, " catcache %d", msg.Id)
+		case msg.Id == SHAREDINVALCATALOG_ID:
+			fmt.Fprintf(buf, " catalog %d", msg.Words[1])
+		case msg.Id == SHAREDINVALRELCACHE_ID:
+			fmt.Fprintf(buf, " relcache %d", msg.Words[1])
+		case msg.Id == SHAREDINVALSMGR_ID:
+			buf.WriteString(" smgr")
+		case msg.Id == SHAREDINVALRELMAP_ID:
+			fmt.Fprintf(buf, " relmap db %d", msg.Words[0])
+		case msg.Id == SHAREDINVALSNAPSHOT_ID:
+			fmt.Fprintf(buf, " snapshot %d", msg.Words[1])
+		default:
+			fmt.Fprintf(buf, " unrecognized id %d", msg.Id)
+		}
+	}
+}
+
+func smgrDesc(buf *strings.Builder, record *Record, rec interface{}) {
+	switch rec := rec.(type) {
+	case *SmgrCreate:
+		buf.WriteString(relpathperm(rec.Rnode, rec.ForkNum, record.Version))
+	case *SmgrTruncate:
+		fmt.Fprintf(buf, "%s to %d blocks flags %d", relpathperm(rec.Rnode, MAIN_FORKNUM, record.Version), rec.Blkno, rec.Flags)
+	}
+}
+
+func clogDesc(buf *strings.Builder, record *Record, rec interface{}) {
+	switch rec := rec.(type) {
+	case *ClogZeroPage:
+		fmt.Fprintf(buf, "page %d", rec.Pageno)
+	case *ClogTruncate:
+		fmt.Fprintf(buf, "page %d; oldestXact %d", rec.Pageno, rec.OldestXact)
+	}
+}
+
+func dbaseDesc(buf *strings.Builder, record *Record, rec interface{}) {
+	switch rec := rec.(type) {
+	case *DbaseCreateFileCopy:
+		fmt.Fprintf(buf, "copy dir %d/%d to %d/%d", rec.SrcTablespaceId, rec.SrcDbId, rec.TablespaceId, rec.DbId)
+	case *DbaseCreateWalLog:
+		fmt.Fprintf(buf, "create dir %d/%d", rec.TablespaceId, rec.DbId)
+	case *DbaseDrop:
+		buf.WriteString("dir")
+		for _, tsId := range rec.TablespaceIds {
+			fmt.Fprintf(buf, " %d/%d", tsId, rec.DbId)
+		}
+	}
+}
+
+func tblspcDesc(buf *strings.Builder, record *Record, rec interface{}) {
+	switch rec := rec.(type) {
+	case *TblspcCreate:
+		fmt.Fprintf(buf, "%d \"%s\"", rec.TsId, rec.TsPath)
+	case *TblspcDrop:
+		fmt.Fprintf(buf, "%d", rec.TsId)
+	}
+}
+
+func multixactDesc(buf *strings.Builder, record *Record, rec interface{}) {
+	switch rec := rec.(type) {
+	case *MultiXactZeroOffPage:
+		fmt.Fprintf(buf, "%d", rec.Pageno)
+	case *MultiXactZeroMemPage:
+		fmt.Fprintf(buf, "%d", rec.Pageno)
+	case *MultiXactCreate:
+		fmt.Fprintf(buf, "%d offset %d nmembers %d: ", rec.Mid, rec.Moff, rec.Nmembers)
+		for _, member := range rec.Members {
+			fmt.Fprintf(buf, "%s ", member)
+		}
+	case *MultiXactTruncate:
+		fmt.Fprintf(buf, "offsets [%d, %d), members [%d, %d)", rec.StartTruncOff, rec.EndTruncOff, rec.StartTruncMemb, rec.EndTruncMemb)
+	}
+}
+
+func relmapDesc(buf *strings.Builder, record *Record, rec interface{}) {
+	if rec, ok := rec.(*RelmapUpdate); ok {
+		fmt.Fprintf(buf, "database %d tablespace %d size %d", rec.Dbid, rec.Tsid, rec.Nbytes)
+	}
+}
+
+func standbyDesc(buf *strings.Builder, record *Record, rec interface{}) {
+	switch rec := rec.(type) {
+	case *StandbyLock:
+		for _, lock := range rec.Locks {
+			fmt.Fprintf(buf, "xid %d db %d rel %d ", lock.Xid, lock.DbOid, lock.RelOid)
+		}
+	case *StandbyRunningXacts:
+		fmt.Fprintf(buf, "nextXid %d latestCompletedXid %d oldestRunningXid %d", rec.NextXid, rec.LatestCompletedXid, rec.OldestRunningXid)
+		if len(rec.Xids) > 0 {
+			fmt.Fprintf(buf, "; %d xacts:", len(rec.Xids))
+			for _, xid := range rec.Xids {
+				fmt.Fprintf(buf, " %d", xid)
+			}
+		}
+		if record.Version < PG13 {
+			if rec.SubxidOverflow {
+				buf.WriteString("; subxid ovf")
+			}
+			return
+		}
+		if rec.SubxidOverflow {
+			buf.WriteString("; subxid overflowed")
+		}
+		if len(rec.Subxids) > 0 {
+			fmt.Fprintf(buf, "; %d subxacts:", len(rec.Subxids))
+			for _, xid := range rec.Subxids {
+				fmt.Fprintf(buf, " %d", xid)
+			}
+		}
+	case *StandbyInvalidations:
+		invalidationsDesc(buf, rec.Msgs, rec.DbId, rec.TsId, rec.RelcacheInitFileInval)
+	}
+}
+
+func heapDesc(buf *strings.Builder, record *Record, rec interface{}) {
+	if record.Version < PG16 {
+		heapDesc15(buf, rec)
+		return
+	}
+	switch rec := rec.(type) {
+	case *HeapInsert:
+		fmt.Fprintf(buf, "off: %d, flags: 0x%02X", rec.Offnum, rec.Flags)
+	case *HeapDelete:
+		fmt.Fprintf(buf, "xmax: %d, off: %d, ", rec.Xmax, rec.Offnum)
+		flagsDesc(buf, "infobits", rec.InfobitsSet, infobitsNames)
+		fmt.Fprintf(buf, ", flags: 0x%02X", rec.Flags)
+	case *HeapUpdate:
+		fmt.Fprintf(buf, "old_xmax: %d, old_off: %d, ", rec.OldXmax, rec.OldOffnum)
+		flagsDesc(buf, "old_infobits", rec.OldInfobitsSet, infobitsNames)
+		fmt.Fprintf(buf, ", flags: 0x%02X, new_xmax: %d, new_off: %d", rec.Flags, rec.NewXmax, rec.NewOffnum)
+	case *HeapTruncate:
+		flagsDesc(buf, "flags", rec.Flags, []string{"CASCADE", "RESTART_SEQS"})
+		fmt.Fprintf(buf, ", nrelids: %d, relids:", rec.Nrelids)
+		arrayDesc(buf, len(rec.Relids), func(i int) { fmt.Fprintf(buf, "%d", rec.Relids[i]) })
+	case *HeapConfirm:
+		fmt.Fprintf(buf, "off: %d", rec.Offnum)
+	case *HeapLock:
+		fmt.Fprintf(buf, "xmax: %d, off: %d, ", rec.Xmax, rec.Offnum)
+		flagsDesc(buf, "infobits", rec.InfobitsSet, infobitsNames)
+		fmt.Fprintf(buf, ", flags: 0x%02X", rec.Flags)
+	case *HeapInplace:
+		fmt.Fprintf(buf, "off: %d", rec.Offnum)
+	}
+}
+
+// heapDesc15 is the rm_desc of heap up to PostgreSQL 15.
+func heapDesc15(buf *strings.Builder, rec interface{}) {
+	switch rec := rec.(type) {
+	case *HeapInsert:
+		fmt.Fprintf(buf, "off %d flags 0x%02X", rec.Offnum, rec.Flags)
+	case *HeapDelete:
+		fmt.Fprintf(buf, "off %d flags 0x%02X ", rec.Offnum, rec.Flags)
+		outInfobits(buf, rec.InfobitsSet)
+	case *HeapUpdate:
+		fmt.Fprintf(buf, "off %d xmax %d flags 0x%02X ", rec.OldOffnum, rec.OldXmax, rec.Flags)
+		outInfobits(buf, rec.OldInfobitsSet)
+		fmt.Fprintf(buf, "; new off %d xmax %d", rec.NewOffnum, rec.NewXmax)
+	case *HeapTruncate:
+		if rec.Flags&XLH_TRUNCATE_CASCADE != 0 {
+			buf.WriteString("cascade ")
+		}
+		if rec.Flags&XLH_TRUNCATE_RESTART_SEQS != 0 {
+			buf.WriteString("restart_seqs ")
+		}
+		fmt.Fprintf(buf, "nrelids %d relids", rec.Nrelids)
+		for _, relid := range rec.Relids {
+			fmt.Fprintf(buf, " %d", relid)
+		}
+	case *HeapConfirm:
+		fmt.Fprintf(buf, "off %d", rec.Offnum)
+	case *HeapLock:
+		fmt.Fprintf(buf, "off %d: xid %d: flags 0x%02X ", rec.Offnum, rec.Xmax, rec.Flags)
+		outInfobits(buf, rec.InfobitsSet)
+	case *HeapInplace:
+		fmt.Fprintf(buf, "off %d", rec.Offnum)
+	}
+}
+
+func heap2Desc(buf *strings.Builder, record *Record, rec interface{}) {
+	if record.Version < PG16 {
+		heap2Desc15(buf, record, rec)
+		return
+	}
+	hasBlockData := record.BlockData(0) != nil
+	switch rec := rec.(type) {
+	case *HeapPrune:
+		if record.Version >= PG17 {
+			heapPruneDesc17(buf, rec, hasBlockData)
+			return
+		}
+		fmt.Fprintf(buf, "snapshotConflictHorizon: %d, nredirected: %d, ndead: %d, isCatalogRel: %c",
+			rec.SnapshotConflictHorizon, rec.Nredirected, rec.Ndead, boolChar(rec.IsCatalogRel))
+		if hasBlockData {
+			fmt.Fprintf(buf, ", nunused: %d, redirected:", len(rec.Nowunused))
+			redirectArrayDesc(buf, rec.Redirected)
+			buf.WriteString(", dead:")
+			offsetArrayDesc(buf, rec.Nowdead)
+			buf.WriteString(", unused:")
+			offsetArrayDesc(buf, rec.Nowunused)
+		}
+	case *HeapVacuum:
+		fmt.Fprintf(buf, "nunused: %d", rec.Nunused)
+		if hasBlockData {
+			buf.WriteString(", unused:")
+			offsetArrayDesc(buf, rec.Nowunused)
+		}
+	case *HeapFreezePage:
+		fmt.Fprintf(buf, "snapshotConflictHorizon: %d, nplans: %d, isCatalogRel: %c", rec.SnapshotConflictHorizon, rec.Nplans, boolChar(rec.IsCatalogRel))
+		if hasBlockData {
+			buf.WriteString(", plans:")
+			freezePlansDesc(buf, rec.Plans, rec.Offsets)
+		}
+	case *HeapVisible:
+		fmt.Fprintf(buf, "snapshotConflictHorizon: %d, flags: 0x%02X", rec.SnapshotConflictHorizon, rec.Flags)
+	case *HeapMultiInsert:
+		fmt.Fprintf(buf, "ntuples: %d, flags: 0x%02X", rec.Ntuples, rec.Flags)
+		if hasBlockData && !rec.InitPage {
+			buf.WriteString(", offsets:")
+			offsetArrayDesc(buf, rec.Offsets)
+		}
+	case *HeapLockUpdated:
+		fmt.Fprintf(buf, "xmax: %d, off: %d, ", rec.Xmax, rec.Offnum)
+		flagsDesc(buf, "infobits", rec.InfobitsSet, infobitsNames)
+		fmt.Fprintf(buf, ", flags: 0x%02X", rec.Flags)
+	case *HeapNewCid:
+		fmt.Fprintf(buf, "rel: %d/%d/%d, tid: %d/%d, cmin: %d, cmax: %d, combo: %d",
+			rec.TargetNode.SpcNode, rec.TargetNode.DbNode, rec.TargetNode.RelNode,
+			rec.TargetTid.BlockNumber(), rec.TargetTid.Posid, rec.Cmin, rec.Cmax, rec.Combocid)
+	}
+}
+
+// heapPruneDesc17 describes the pruning records of PostgreSQL 17, which only
+// log the arrays that are not empty.
+func heapPruneDesc17(buf *strings.Builder, rec *HeapPrune, hasBlockData bool) {
+	if rec.Flags&XLHP_HAS_CONFLICT_HORIZON != 0 {
+		fmt.Fprintf(buf, "snapshotConflictHorizon: %d", rec.SnapshotConflictHorizon)
+	}
+	fmt.Fprintf(buf, ", isCatalogRel: %c", boolChar(rec.IsCatalogRel))
+	if !hasBlockData {
+		return
+	}
+	fmt.Fprintf(buf, ", nplans: %d, nredirected: %d, ndead: %d, nunused: %d",
+		len(rec.Plans), len(rec.Redirected)/2, len(rec.Nowdead), len(rec.Nowunused))
+	if len(rec.Plans) > 0 {
+		buf.WriteString(", plans:")
+		freezePlansDesc(buf, rec.Plans, rec.Frozen)
+	}
+	if len(rec.Redirected) > 0 {
+		buf.WriteString(", redirected:")
+		redirectArrayDesc(buf, rec.Redirected)
+	}
+	if len(rec.Nowdead) > 0 {
+		buf.WriteString(", dead:")
+		offsetArrayDesc(buf, rec.Nowdead)
+	}
+	if len(rec.Nowunused) > 0 {
+		buf.WriteString(", unused:")
+		offsetArrayDesc(buf, rec.Nowunused)
+	}
+}
+
+func redirectArrayDesc(buf *strings.Builder, redirected []OffsetNumber) {
+	arrayDesc(buf, len(redirected)/2, func(i int) { fmt.Fprintf(buf, "%d->%d", redirected[2*i], redirected[2*i+1]) })
+}
+
+// freezePlansDesc appends the plans like plan_elem_desc, each with the
+// offsets it applies to.
+func freezePlansDesc(buf *strings.Builder, plans []XlHeapFreezePlan, offsets []OffsetNumber) {
+	arrayDesc(buf, len(plans), func(i int) {
+		plan := plans[i]
+		fmt.Fprintf(buf, "{ xmax: %d, infomask: %d, infomask2: %d, ntuples: %d, offsets:", plan.Xmax, plan.TInfomask, plan.TInfomask2, plan.Ntuples)
+		n := int(plan.Ntuples)
+		if n > len(offsets) {
+			n = len(offsets)
+		}
+		offsetArrayDesc(buf, offsets[:n])
+		offsets = offsets[n:]
+		buf.WriteString(" }")
+	})
+}
+
+// heap2Desc15 is the rm_desc of heap2 up to PostgreSQL 15.
+func heap2Desc15(buf *strings.Builder, record *Record, rec interface{}) {
+	switch rec := rec.(type) {
+	case *HeapPrune:
+		if record.Version < PG14 {
+			fmt.Fprintf(buf, "remxid %d", rec.SnapshotConflictHorizon)
+		} else {
+			fmt.Fprintf(buf, "latestRemovedXid %d nredirected %d ndead %d", rec.SnapshotConflictHorizon, rec.Nredirected, rec.Ndead)
+		}
+	case *HeapVacuum:
+		fmt.Fprintf(buf, "nunused %d", rec.Nunused)
+	case *HeapCleanupInfo:
+		fmt.Fprintf(buf, "remxid %d", rec.LatestRemovedXid)
+	case *HeapFreezePage:
+		fmt.Fprintf(buf, "cutoff xid %d ntuples %d", rec.SnapshotConflictHorizon, rec.Nplans)
+	case *HeapVisible:
+		fmt.Fprintf(buf, "cutoff xid %d flags 0x%02X", rec.SnapshotConflictHorizon, rec.Flags)
+	case *HeapMultiInsert:
+		fmt.Fprintf(buf, "%d tuples flags 0x%02X", rec.Ntuples, rec.Flags)
+	case *HeapLockUpdated:
+		fmt.Fprintf(buf, "off %d: xmax %d: flags 0x%02X ", rec.Offnum, rec.Xmax, rec.Flags)
+		outInfobits(buf, rec.InfobitsSet)
+	case *HeapNewCid:
+		fmt.Fprintf(buf, "rel %d/%d/%d; tid %d/%d; cmin: %d, cmax: %d, combo: %d",
+			rec.TargetNode.SpcNode, rec.TargetNode.DbNode, rec.TargetNode.RelNode,
+			rec.TargetTid.BlockNumber(), rec.TargetTid.Posid, rec.Cmin, rec.Cmax, rec.Combocid)
+	}
+}
+
+func btreeDesc(buf *strings.Builder, record *Record, rec interface{}) {
+	if record.Version < PG16 {
+		btreeDesc15(buf, record, rec)
+		return
+	}
+	switch rec := rec.(type) {
+	case *BtreeInsert:
+		fmt.Fprintf(buf, "off: %d", rec.Offnum)
+	case *BtreeSplit:
+		fmt.Fprintf(buf, "level: %d, firstrightoff: %d, newitemoff: %d, postingoff: %d", rec.Level, rec.Firstrightoff, rec.Newitemoff, rec.Postingoff)
+	case *BtreeDedup:
+		fmt.Fprintf(buf, "nintervals: %d", rec.Nintervals)
+	case *BtreeVacuum:
+		fmt.Fprintf(buf, "ndeleted: %d, nupdated: %d", rec.Ndeleted, rec.Nupdated)
+		if record.BlockData(0) != nil {
+			btreeDelvacuumDesc(buf, rec.Deleted, rec.Updated, rec.Updates)
+		}
+	case *BtreeDelete:
+		fmt.Fprintf(buf, "snapshotConflictHorizon: %d, ndeleted: %d, nupdated: %d, isCatalogRel: %c",
+			rec.SnapshotConflictHorizon, rec.Ndeleted, rec.Nupdated, boolChar(rec.IsCatalogRel))
+		if record.BlockData(0) != nil {
+			btreeDelvacuumDesc(buf, rec.Deleted, rec.Updated, rec.Updates)
+		}
+	case *BtreeMarkPageHalfdead:
+		fmt.Fprintf(buf, "topparent: %d, leaf: %d, left: %d, right: %d", rec.Topparent, rec.Leafblk, rec.Leftblk, rec.Rightblk)
+	case *BtreeUnlinkPage:
+		fmt.Fprintf(buf, "left: %d, right: %d, level: %d, safexid: %d:%d, leafleft: %d, leafright: %d, leaftopparent: %d",
+			rec.Leftsib, rec.Rightsib, rec.Level, rec.Safexid>>32, uint32(rec.Safexid),
+			rec.Leafleftsib, rec.Leafrightsib, rec.Leaftopparent)
+	case *BtreeNewroot:
+		fmt.Fprintf(buf, "level: %d", rec.Level)
+	case *BtreeReusePage:
+		fmt.Fprintf(buf, "rel: %d/%d/%d, snapshotConflictHorizon: %d:%d, isCatalogRel: %c",
+			rec.Node.SpcNode, rec.Node.DbNode, rec.Node.RelNode,
+			rec.SnapshotConflictHorizon>>32, uint32(rec.SnapshotConflictHorizon), boolChar(rec.IsCatalogRel))
+	case *BtreeMetaCleanup:
+		if rec.Metadata != nil {
+			fmt.Fprintf(buf, "last_cleanup_num_delpages: %d", rec.Metadata.LastCleanupNumDelpages)
+		}
+	}
+}
+
+// btreeDelvacuumDesc appends the deleted and updated items like
+// delvacuum_desc.
+func btreeDelvacuumDesc(buf *strings.Builder, deleted, updated []OffsetNumber, updates []BtreeUpdate) {
+	buf.WriteString(", deleted:")
+	offsetArrayDesc(buf, deleted)
+	buf.WriteString(", updated: [")
+	for i, off := range updated {
+		tids := updates[i].DeletedTids
+		fmt.Fprintf(buf, "{ off: %d, nptids: %d, ptids: [", off, len(tids))
+		for p, tid := range tids {
+			fmt.Fprintf(buf, "%d", tid)
+			if p < len(tids)-1 {
+				buf.WriteString(", ")
+			}
+		}
+		buf.WriteString("] }")
+		if i < len(updated)-1 {
+			buf.WriteString(", ")
+		}
+	}
+	buf.WriteByte(']')
+}
+
+// btreeDesc15 is the rm_desc of btree up to PostgreSQL 15.
+func btreeDesc15(buf *strings.Builder, record *Record, rec interface{}) {
+	v := record.Version
+	switch rec := rec.(type) {
+	case *BtreeInsert:
+		fmt.Fprintf(buf, "off %d", rec.Offnum)
+	case *BtreeSplit:
+		switch {
+		case v >= PG14:
+			fmt.Fprintf(buf, "level %d, firstrightoff %d, newitemoff %d, postingoff %d", rec.Level, rec.Firstrightoff, rec.Newitemoff, rec.Postingoff)
+		case v >= PG13:
+			fmt.Fprintf(buf, "level %d, firstright %d, newitemoff %d, postingoff %d", rec.Level, rec.Firstrightoff, rec.Newitemoff, rec.Postingoff)
+		default:
+			fmt.Fprintf(buf, "level %d, firstright %d, newitemoff %d", rec.Level, rec.Firstrightoff, rec.Newitemoff)
+		}
+	case *BtreeDedup:
+		fmt.Fprintf(buf, "nintervals %d", rec.Nintervals)
+	case *BtreeVacuum:
+		if v < PG13 {
+			fmt.Fprintf(buf, "lastBlockVacuumed %d", rec.LastBlockVacuumed)
+		} else {
+			fmt.Fprintf(buf, "ndeleted %d; nupdated %d", rec.Ndeleted, rec.Nupdated)
+		}
+	case *BtreeDelete:
+		switch {
+		case v >= PG14:
+			fmt.Fprintf(buf, "latestRemovedXid %d; ndeleted %d; nupdated %d", rec.SnapshotConflictHorizon, rec.Ndeleted, rec.Nupdated)
+		case v >= PG13:
+			fmt.Fprintf(buf, "latestRemovedXid %d; ndeleted %d", rec.SnapshotConflictHorizon, rec.Ndeleted)
+		default:
+			fmt.Fprintf(buf, "%d items, latest removed xid %d", rec.Ndeleted, rec.SnapshotConflictHorizon)
+		}
+	case *BtreeMarkPageHalfdead:
+		fmt.Fprintf(buf, "topparent %d; leaf %d; left %d; right %d", rec.Topparent, rec.Leafblk, rec.Leftblk, rec.Rightblk)
+	case *BtreeUnlinkPage:
+		if v >= PG14 {
+			fmt.Fprintf(buf, "left %d; right %d; level %d; safexid %d:%d; leafleft %d; leafright %d; leaftopparent %d",
+				rec.Leftsib, rec.Rightsib, rec.Level, rec.Safexid>>32, uint32(rec.Safexid),
+				rec.Leafleftsib, rec.Leafrightsib, rec.Leaftopparent)
+		} else {
+			fmt.Fprintf(buf, "left %d; right %d; btpo_xact %d; leafleft %d; leafright %d; topparent %d",
+				rec.Leftsib, rec.Rightsib, uint32(rec.Safexid), rec.Leafleftsib, rec.Leafrightsib, rec.Leaftopparent)
+		}
+	case *BtreeNewroot:
+		fmt.Fprintf(buf, "lev %d", rec.Level)
+	case *BtreeReusePage:
+		if v >= PG14 {
+			fmt.Fprintf(buf, "rel %d/%d/%d; latestRemovedXid %d:%d", rec.Node.SpcNode, rec.Node.DbNode, rec.Node.RelNode,
+				rec.SnapshotConflictHorizon>>32, uint32(rec.SnapshotConflictHorizon))
+		} else {
+			fmt.Fprintf(buf, "rel %d/%d/%d; latestRemovedXid %d", rec.Node.SpcNode, rec.Node.DbNode, rec.Node.RelNode,
+				uint32(rec.SnapshotConflictHorizon))
+		}
+	case *BtreeMetaCleanup:
+		switch {
+		case rec.Metadata == nil:
+		case v >= PG14:
+			fmt.Fprintf(buf, "last_cleanup_num_delpages %d", rec.Metadata.LastCleanupNumDelpages)
+		default:
+			// LastCleanupNumDelpages holds oldest_btpo_xact
+			fmt.Fprintf(buf, "oldest_btpo_xact %d; last_cleanup_num_heap_tuples: %f",
+				rec.Metadata.LastCleanupNumDelpages, rec.Metadata.LastCleanupNumHeapTuples)
+		}
+	}
+}
+
+func hashDesc(buf *strings.Builder, record *Record, rec interface{}) {
+	switch rec := rec.(type) {
+	case *HashInitMetaPage:
+		fmt.Fprintf(buf, "num_tuples %.6g, fillfactor %d", rec.NumTuples, rec.Ffactor)
+	case *HashInitBitmapPage:
+		fmt.Fprintf(buf, "bmsize %d", rec.Bmsize)
+	case *HashInsert:
+		fmt.Fprintf(buf, "off %d", rec.Offnum)
+	case *HashAddOvflPage:
+		fmt.Fprintf(buf, "bmsize %d, bmpage_found %c", rec.Bmsize, boolChar(rec.BmpageFound))
+	case *HashSplitAllocatePage:
+		fmt.Fprintf(buf, "new_bucket %d, meta_page_masks_updated %c, issplitpoint_changed %c", rec.NewBucket,
+			boolChar(rec.Flags&XLH_SPLIT_META_UPDATE_MASKS != 0), boolChar(rec.Flags&XLH_SPLIT_META_UPDATE_SPLITPOINT != 0))
+	case *HashSplitComplete:
+		fmt.Fprintf(buf, "old_bucket_flag %d, new_bucket_flag %d", rec.OldBucketFlag, rec.NewBucketFlag)
+	case *HashMovePageContents:
+		fmt.Fprintf(buf, "ntups %d, is_primary %c", rec.Ntups, boolChar(rec.IsPrimBucketSameWrt))
+	case *HashSqueezePage:
+		fmt.Fprintf(buf, "prevblkno %d, nextblkno %d, ntups %d, is_primary %c", rec.Prevblkno, rec.Nextblkno, rec.Ntups, boolChar(rec.IsPrimBucketSameWrt))
+	case *HashDelete:
+		fmt.Fprintf(buf, "clear_dead_marking %c, is_primary %c", boolChar(rec.ClearDeadMarking), boolChar(rec.IsPrimaryBucketPage))
+	case *HashUpdateMetaPage:
+		fmt.Fprintf(buf, "ntuples %.6g", rec.Ntuples)
+	case *HashVacuumOnePage:
+		if record.Version >= PG16 {
+			fmt.Fprintf(buf, "ntuples %d, snapshotConflictHorizon %d, isCatalogRel %c", rec.Ntuples, rec.SnapshotConflictHorizon, boolChar(rec.IsCatalogRel))
+		} else {
+			fmt.Fprintf(buf, "ntuples %d, latestRemovedXid %d", rec.Ntuples, rec.SnapshotConflictHorizon)
+		}
+	}
+}
+
+func ginDesc(buf *strings.Builder, record *Record, rec interface{}) {
+	switch rec := rec.(type) {
+	case *GinInsert:
+		fmt.Fprintf(buf, "isdata: %c isleaf: %c", boolChar(rec.Flags&GIN_INSERT_ISDATA != 0), boolChar(rec.Flags&GIN_INSERT_ISLEAF != 0))
+		if rec.Flags&GIN_INSERT_ISLEAF == 0 {
+			fmt.Fprintf(buf, " children: %d/%d", rec.LeftChildBlkno, rec.RightChildBlkno)
+		}
+		switch {
+		case ginImageDesc(buf, record):
+		case rec.Entry != nil:
+			fmt.Fprintf(buf, " isdelete: %c", boolChar(rec.Entry.IsDelete))
+		case rec.Internal != nil:
+			item := rec.Internal.Newitem
+			fmt.Fprintf(buf, " pitem: %d-%d/%d", item.ChildBlkno.BlockNumber(), item.Key.BlockNumber(), item.Key.Posid)
+		case rec.Flags&GIN_INSERT_ISDATA != 0 && rec.Flags&GIN_INSERT_ISLEAF != 0:
+			ginRecompressLeafDesc(buf, rec.Actions)
+		}
+	case *GinSplit:
+		fmt.Fprintf(buf, "isrootsplit: %c isdata: %c isleaf: %c", boolChar(rec.Flags&GIN_SPLIT_ROOT != 0),
+			boolChar(rec.Flags&GIN_INSERT_ISDATA != 0), boolChar(rec.Flags&GIN_INSERT_ISLEAF != 0))
+	case *GinVacuumDataLeafPage:
+		if !ginImageDesc(buf, record) {
+			ginRecompressLeafDesc(buf, rec.Actions)
+		}
+	case *GinDeleteListPages:
+		fmt.Fprintf(buf, "ndeleted: %d", rec.Ndeleted)
+	}
+}
+
+// ginImageDesc appends what gin_desc shows in place of the block data when
+// block 0 has a full-page image, and tells whether it has one.
+func ginImageDesc(buf *strings.Builder, record *Record) bool {
+	block, ok := record.Block(0)
+	if !ok || !block.HasImage() {
+		return false
+	}
+	if block.Iheader.IsApply(record.Version) {
+		buf.WriteString(" (full page image)")
+	} else {
+		buf.WriteString(" (full page image, for WAL verification)")
+	}
+	return true
+}
+
+// ginRecompressLeafDesc appends the segment actions like
+// desc_recompress_leaf.
+func ginRecompressLeafDesc(buf *strings.Builder, actions []GinSegmentAction) {
+	fmt.Fprintf(buf, " %d segments:", len(actions))
+	for _, action := range actions {
+		switch action.Type {
+		case GIN_SEGMENT_ADDITEMS:
+			fmt.Fprintf(buf, " %d (add %d items)", action.Segno, len(action.Items))
+		case GIN_SEGMENT_DELETE:
+			fmt.Fprintf(buf, " %d (delete)", action.Segno)
+		case GIN_SEGMENT_INSERT:
+			fmt.Fprintf(buf, " %d (insert)", action.Segno)
+		case GIN_SEGMENT_REPLACE:
+			fmt.Fprintf(buf, " %d (replace)", action.Segno)
+		default:
+			fmt.Fprintf(buf, " %d unknown action %d ???", action.Segno, action.Type)
+			return
+		}
+	}
+}
+
+func gistDesc(buf *strings.Builder, record *Record, rec interface{}) {
+	switch rec := rec.(type) {
+	case *GistPageReuse:
+		node := rec.Node
+		horizon := rec.SnapshotConflictHorizon
+		if record.Version >= PG16 {
+			fmt.Fprintf(buf, "rel %d/%d/%d; blk %d; snapshotConflictHorizon %d:%d, isCatalogRel %c",
+				node.SpcNode, node.DbNode, node.RelNode, rec.Block, horizon>>32, uint32(horizon), boolChar(rec.IsCatalogRel))
+		} else {
+			fmt.Fprintf(buf, "rel %d/%d/%d; blk %d; latestRemovedXid %d:%d",
+				node.SpcNode, node.DbNode, node.RelNode, rec.Block, horizon>>32, uint32(horizon))
+		}
+	case *GistDelete:
+		switch {
+		case record.Version >= PG16:
+			fmt.Fprintf(buf, "delete: snapshotConflictHorizon %d, nitems: %d, isCatalogRel %c", rec.SnapshotConflictHorizon, rec.Ntodelete, boolChar(rec.IsCatalogRel))
+		case record.Version >= PG13:
+			fmt.Fprintf(buf, "delete: latestRemovedXid %d, nitems: %d", rec.SnapshotConflictHorizon, rec.Ntodelete)
+		}
+	case *GistPageSplit:
+		fmt.Fprintf(buf, "page_split: splits to %d pages", rec.Npage)
+	case *GistPageDelete:
+		fmt.Fprintf(buf, "deleteXid %d:%d; downlink %d", rec.DeleteXid>>32, uint32(rec.DeleteXid), rec.DownlinkOffset)
+	}
+}
+
+func seqDesc(buf *strings.Builder, record *Record, rec interface{}) {
+	if rec, ok := rec.(*SeqLog); ok {
+		fmt.Fprintf(buf, "rel %d/%d/%d", rec.Node.SpcNode, rec.Node.DbNode, rec.Node.RelNode)
+	}
+}
+
+func spgDesc(buf *strings.Builder, record *Record, rec interface{}) {
+	if record.Version < PG16 {
+		spgDesc15(buf, rec)
+		return
+	}
+	flag := func(set bool, name string) {
+		if set {
+			buf.WriteString(" (" + name + ")")
+		}
+	}
+	switch rec := rec.(type) {
+	case *SpgAddLeaf:
+		fmt.Fprintf(buf, "off: %d, headoff: %d, parentoff: %d, nodeI: %d", rec.OffnumLeaf, rec.OffnumHeadLeaf, rec.OffnumParent, rec.NodeI)
+		flag(rec.NewPage, "newpage")
+		flag(rec.StoresNulls, "nulls")
+	case *SpgMoveLeafs:
+		fmt.Fprintf(buf, "nmoves: %d, parentoff: %d, nodeI: %d", rec.NMoves, rec.OffnumParent, rec.NodeI)
+		flag(rec.NewPage, "newpage")
+		flag(rec.ReplaceDead, "replacedead")
+		flag(rec.StoresNulls, "nulls")
+	case *SpgAddNode:
+		fmt.Fprintf(buf, "off: %d, newoff: %d, parentBlk: %d, parentoff: %d, nodeI: %d", rec.Offnum, rec.OffnumNew, rec.ParentBlk, rec.OffnumParent, rec.NodeI)
+		flag(rec.NewPage, "newpage")
+	case *SpgSplitTuple:
+		fmt.Fprintf(buf, "prefixoff: %d, postfixoff: %d", rec.OffnumPrefix, rec.OffnumPostfix)
+		flag(rec.NewPage, "newpage")
+		flag(rec.PostfixBlkSame, "same")
+	case *SpgPickSplit:
+		fmt.Fprintf(buf, "ndelete: %d, ninsert: %d, inneroff: %d, parentoff: %d, nodeI: %d", rec.NDelete, rec.NInsert, rec.OffnumInner, rec.OffnumParent, rec.NodeI)
+		flag(rec.InnerIsParent, "innerIsParent")
+		flag(rec.StoresNulls, "nulls")
+		flag(rec.IsRootSplit, "isRootSplit")
+	case *SpgVacuumLeaf:
+		fmt.Fprintf(buf, "ndead: %d, nplaceholder: %d, nmove: %d, nchain: %d", rec.NDead, rec.NPlaceholder, rec.NMove, rec.NChain)
+	case *SpgVacuumRoot:
+		fmt.Fprintf(buf, "ndelete: %d", rec.NDelete)
+	case *SpgVacuumRedirect:
+		fmt.Fprintf(buf, "ntoplaceholder: %d, firstplaceholder: %d, snapshotConflictHorizon: %d, isCatalogRel: %c",
+			rec.NToPlaceholder, rec.FirstPlaceholder, rec.SnapshotConflictHorizon, boolChar(rec.IsCatalogRel))
+	}
+}
+
+// spgDesc15 is the rm_desc of SP-GiST up to PostgreSQL 15.
+func spgDesc15(buf *strings.Builder, rec interface{}) {
+	switch rec := rec.(type) {
+	case *SpgAddLeaf:
+		fmt.Fprintf(buf, "add leaf to page; off %d; headoff %d; parentoff %d", rec.OffnumLeaf, rec.OffnumHeadLeaf, rec.OffnumParent)
+		if rec.NewPage {
+			buf.WriteString(" (newpage)")
+		}
+		if rec.StoresNulls {
+			buf.WriteString(" (nulls)")
+		}
+	case *SpgMoveLeafs:
+		fmt.Fprintf(buf, "%d leafs", rec.NMoves)
+	case *SpgAddNode:
+		fmt.Fprintf(buf, "off %d", rec.Offnum)
+	case *SpgSplitTuple:
+		fmt.Fprintf(buf, "prefix off: %d, postfix off: %d (same %d, new %d)", rec.OffnumPrefix, rec.OffnumPostfix, boolInt(rec.PostfixBlkSame), boolInt(rec.NewPage))
+	case *SpgPickSplit:
+		fmt.Fprintf(buf, "ndel %d; nins %d", rec.NDelete, rec.NInsert)
+		if rec.InnerIsParent {
+			buf.WriteString(" (innerIsParent)")
+		}
+		if rec.IsRootSplit {
+			buf.WriteString(" (isRootSplit)")
+		}
+	case *SpgVacuumRedirect:
+		fmt.Fprintf(buf, "newest XID %d", rec.SnapshotConflictHorizon)
+	}
+}
+
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func brinDesc(buf *strings.Builder, record *Record, rec interface{}) {
+	switch rec := rec.(type) {
+	case *BrinCreateIdx:
+		fmt.Fprintf(buf, "v%d pagesPerRange %d", rec.Version, rec.PagesPerRange)
+	case *BrinInsert:
+		fmt.Fprintf(buf, "heapBlk %d pagesPerRange %d offnum %d", rec.HeapBlk, rec.PagesPerRange, rec.Offnum)
+	case *BrinUpdate:
+		fmt.Fprintf(buf, "heapBlk %d pagesPerRange %d old offnum %d, new offnum %d", rec.Insert.HeapBlk, rec.Insert.PagesPerRange, rec.OldOffnum, rec.Insert.Offnum)
+	case *BrinSamepageUpdate:
+		fmt.Fprintf(buf, "offnum %d", rec.Offnum)
+	case *BrinRevmapExtend:
+		fmt.Fprintf(buf, "targetBlk %d", rec.TargetBlk)
+	case *BrinDesummarize:
+		fmt.Fprintf(buf, "pagesPerRange %d, heapBlk %d, page offset %d", rec.PagesPerRange, rec.HeapBlk, rec.RegOffset)
+	}
+}
+
+func commitTsDesc(buf *strings.Builder, record *Record, rec interface{}) {
+	switch rec := rec.(type) {
+	case *CommitTsZeroPage:
+		fmt.Fprintf(buf, "%d", rec.Pageno)
+	case *CommitTsTruncate:
+		fmt.Fprintf(buf, "pageno %d, oldestXid %d", rec.Pageno, rec.OldestXid)
+	case *CommitTsSet:
+		fmt.Fprintf(buf, "set %s/%d for: %d", timestamptzToStr(rec.Timestamp), rec.Nodeid, rec.Mainxid)
+		for _, xid := range rec.Subxids {
+			fmt.Fprintf(buf, ", %d", xid)
+		}
+	}
+}
+
+func reploriginDesc(buf *strings.Builder, record *Record, rec interface{}) {
+	switch rec := rec.(type) {
+	case *ReploriginSet:
+		fmt.Fprintf(buf, "set %d; lsn %s; force: %d", rec.NodeId, lsnDesc(rec.RemoteLsn), boolInt(rec.Force))
+	case *ReploriginDrop:
+		fmt.Fprintf(buf, "drop %d", rec.NodeId)
+	}
+}
+
+// genericDesc walks the main data as generic_desc does, which finds no
+// fragments there as they are logged with the blocks.
+func genericDesc(buf *strings.Builder, record *Record, rec interface{}) {
+	data := record.MainData
+	for len(data) >= 4 {
+		offset := uint16(data[0]) | uint16(data[1])<<8
+		length := uint16(data[2]) | uint16(data[3])<<8
+		data = data[4:]
+		if int(length) >= len(data) {
+			fmt.Fprintf(buf, "offset %d, length %d", offset, length)
+			return
+		}
+		data = data[length:]
+		fmt.Fprintf(buf, "offset %d, length %d; ", offset, length)
+	}
+}
+
+func logicalmsgDesc(buf *strings.Builder, record *Record, rec interface{}) {
+	msg, ok := rec.(*LogicalMessage)
+	if !ok {
+		return
+	}
+	if record.Version < PG14 {
+		kind := "nontransactional"
+		if msg.Transactional {
+			kind = "transactional"
+		}
+		fmt.Fprintf(buf, "%s message size %d bytes", kind, msg.MessageSize)
+		return
+	}
+	kind := "non-transactional"
+	if msg.Transactional {
+		kind = "transactional"
+	}
+	fmt.Fprintf(buf, "%s, prefix \"%s\"; payload (%d bytes): ", kind, msg.Prefix, msg.MessageSize)
+	for i, b := range msg.Message {
+		if i > 0 {
+			buf.WriteByte(' ')
+		}
+		fmt.Fprintf(buf, "%02X", b)
+	}
+}
@@ -0,0 +1,131 @@
+package wal
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func utcLocal(t *testing.T) {
+	local := time.Local
+	time.Local = time.UTC
+	t.Cleanup(func() { time.Local = local })
+}
+
+// describeDesc returns what Describe shows after "desc: ".
+func describeDesc(t *testing.T, record *Record) string {
+	line, err := Describe(record)
+	require.NoError(t, err)
+	_, desc, found := strings.Cut(line, "desc: ")
+	require.True(t, found, line)
+	return desc
+}
+
+func TestDescribe(t *testing.T) {
+	record := testRecord(t, RM_HEAP_ID, XLOG_HEAP_INSERT, le(uint16(2), uint8(0)),
+		le(uint16(1), uint16(2), uint8(24), []byte{1, 2, 3}))
+	record.LSN = 0x1529D30
+	record.Hdr.XlTotlen = 59
+	record.Hdr.XlXid = 735
+	record.Hdr.XlPrev = 0x1529CF8
+
+	line, err := Describe(record)
+	require.NoError(t, err)
+	assert.Equal(t, "rmgr: Heap        len (rec/tot):     59/      59, tx:        735, lsn: 0/01529D30, prev 0/01529CF8, desc: INSERT off: 2, flags: 0x00, blkref #0: rel 1663/5/16384 blk 0", line)
+	line, err = DescribeDetailed(record)
+	require.NoError(t, err)
+	assert.Equal(t, "rmgr: Heap        len (rec/tot):     59/      59, tx:        735, lsn: 0/01529D30, prev 0/01529CF8, desc: INSERT off: 2, flags: 0x00\n\tblkref #0: rel 1663/5/16384 fork main blk 0", line)
+
+	// the length of the images is not part of the record length
+	block := &record.Blocks[0]
+	block.Iheader = &XLogRecordBlockImageHeader{Length: 100, HoleOffset: 40, BimgInfo: BKPIMAGE_HAS_HOLE | BKPIMAGE_APPLY}
+	record.Hdr.XlTotlen = 159
+	line, err = Describe(record)
+	require.NoError(t, err)
+	assert.Equal(t, "rmgr: Heap        len (rec/tot):     59/     159, tx:        735, lsn: 0/01529D30, prev 0/01529CF8, desc: INSERT off: 2, flags: 0x00, blkref #0: rel 1663/5/16384 blk 0 FPW", line)
+	line, err = DescribeDetailed(record)
+	require.NoError(t, err)
+	assert.True(t, strings.HasSuffix(line, "\n\tblkref #0: rel 1663/5/16384 fork main blk 0 (FPW); hole: offset: 40, length: 8092"), line)
+
+	block.Iheader.BimgInfo = BKPIMAGE_HAS_HOLE | BKPIMAGE_COMPRESS_LZ4
+	block.Cheader = &XLogRecordBlockCompressHeader{HoleLength: 40}
+	block.ForkNum = FSM_FORKNUM
+	line, err = Describe(record)
+	require.NoError(t, err)
+	assert.True(t, strings.HasSuffix(line, ", blkref #0: rel 1663/5/16384 fork fsm blk 0 FPW for WAL verification"), line)
+	line, err = DescribeDetailed(record)
+	require.NoError(t, err)
+	assert.True(t, strings.HasSuffix(line, "\n\tblkref #0: rel 1663/5/16384 fork fsm blk 0 (FPW for WAL verification); hole: offset: 40, length: 40, compression saved: 8052, method: lz4"), line)
+
+	// an info code the resource manager does not know has no description
+	record = testRecord(t, RM_XLOG_ID, XLOG_CHECKPOINT_REDO, nil)
+	assert.Equal(t, "UNKNOWN (e0) ", describeDesc(t, record))
+	line, err = Describe(testRecord(t, RM_MIN_CUSTOM_ID, 0x10, []byte{1}))
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(line, "rmgr: custom128   len (rec/tot): "), line)
+	assert.True(t, strings.HasSuffix(line, "desc: UNKNOWN (10) "), line)
+
+	_, err = Describe(testRecord(t, RM_HEAP_ID, XLOG_HEAP_INSERT, []byte{1}))
+	assert.ErrorIs(t, err, ErrInvalidRecord)
+}
+
+func TestDescribeRmgrs(t *testing.T) {
+	utcLocal(t)
+	heapDelete := le(uint32(800), uint16(3), uint8(XLHL_XMAX_IS_MULTI|XLHL_XMAX_LOCK_ONLY), uint8(0))
+	pruneMain := le(uint32(700), uint16(1), uint16(2), uint8(1))
+	pruneData := le(uint16(1), uint16(3), uint16(4), uint16(5), uint16(6), uint16(7))
+	for _, c := range []struct {
+		name    string
+		record  *Record
+		version PgVersion
+		want    string
+	}{
+		{"heap delete", testRecord(t, RM_HEAP_ID, XLOG_HEAP_DELETE, heapDelete), PG16,
+			"DELETE xmax: 800, off: 3, infobits: [IS_MULTI, LOCK_ONLY], flags: 0x00"},
+		{"heap delete 15", testRecord(t, RM_HEAP_ID, XLOG_HEAP_DELETE, heapDelete), PG15,
+			"DELETE off 3 flags 0x00 IS_MULTI LOCK_ONLY "},
+		{"prune", testRecord(t, RM_HEAP2_ID, XLOG_HEAP2_PRUNE, pruneMain, pruneData), PG16,
+			"PRUNE snapshotConflictHorizon: 700, nredirected: 1, ndead: 2, isCatalogRel: T, nunused: 2, redirected: [1->3], dead: [4, 5], unused: [6, 7], blkref #0: rel 1663/5/16384 blk 0"},
+		{"prune without data", testRecord(t, RM_HEAP2_ID, XLOG_HEAP2_PRUNE, pruneMain, nil), PG16,
+			"PRUNE snapshotConflictHorizon: 700, nredirected: 1, ndead: 2, isCatalogRel: T, blkref #0: rel 1663/5/16384 blk 0"},
+		{"nextoid", testRecord(t, RM_XLOG_ID, XLOG_NEXTOID, le(uint32(16400))), PG16,
+			"NEXTOID 16400"},
+		{"abort", testRecord(t, RM_XACT_ID, XLOG_XACT_ABORT|XLOG_XACT_HAS_INFO, le(
+			uint64(42), uint32(XACT_XINFO_HAS_SUBXACTS|XACT_XINFO_HAS_RELFILENODES),
+			uint32(1), uint32(801),
+			uint32(1), uint32(1663), uint32(5), uint32(16384))), PG16,
+			"ABORT 2000-01-01 00:00:00.000042 UTC; rels: base/5/16384; subxacts: 801"},
+		{"assignment", testRecord(t, RM_XACT_ID, XLOG_XACT_ASSIGNMENT, le(uint32(800), uint32(2), uint32(801), uint32(802))), PG16,
+			"ASSIGNMENT xtop 800: subxacts: 801 802"},
+		{"running xacts", testRecord(t, RM_STANDBY_ID, XLOG_RUNNING_XACTS, le(
+			uint32(2), uint32(1), uint32(0), uint32(905), uint32(900), uint32(904),
+			uint32(900), uint32(903), uint32(902))), PG16,
+			"RUNNING_XACTS nextXid 905 latestCompletedXid 904 oldestRunningXid 900; 2 xacts: 900 903; 1 subxacts: 902"},
+		{"invalidations", testRecord(t, RM_STANDBY_ID, XLOG_INVALIDATIONS, le(
+			uint32(5), uint32(1663), uint32(1), uint32(1),
+			uint8(0xFE), uint8(0), uint16(0), uint32(5), uint32(16384), uint32(0))), PG16,
+			"INVALIDATIONS ; relcache init file inval dbid 5 tsid 1663; inval msgs: relcache 16384"},
+		{"smgr create", testRecord(t, RM_SMGR_ID, XLOG_SMGR_CREATE,
+			le(uint32(16500), uint32(5), uint32(16384), uint32(VISIBILITYMAP_FORKNUM))), PG16,
+			"CREATE pg_tblspc/16500/PG_16_202307071/5/16384_vm"},
+		{"logical message", testRecord(t, RM_LOGICALMSG_ID, XLOG_LOGICAL_MESSAGE, le(
+			uint32(5), uint8(1), []byte{0, 0, 0}, uint64(4), uint64(5), []byte("app\x00hello"))), PG16,
+			"MESSAGE transactional, prefix \"app\"; payload (5 bytes): 68 65 6C 6C 6F"},
+		{"logical message 13", testRecord(t, RM_LOGICALMSG_ID, XLOG_LOGICAL_MESSAGE, le(
+			uint32(5), uint8(0), []byte{0, 0, 0}, uint64(4), uint64(5), []byte("app\x00hello"))), PG13,
+			"MESSAGE nontransactional message size 5 bytes"},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			assert.Equal(t, c.want, describeDesc(t, versioned(c.record, c.version)))
+		})
+	}
+}
+
+func TestRelpathperm(t *testing.T) {
+	assert.Equal(t, "global/1262", relpathperm(RelFileNode{1664, 0, 1262}, MAIN_FORKNUM, PG16))
+	assert.Equal(t, "base/5/16384_fsm", relpathperm(RelFileNode{1663, 5, 16384}, FSM_FORKNUM, PG16))
+	assert.Equal(t, "pg_tblspc/16500/PG_12_201909212/5/16384_init", relpathperm(RelFileNode{16500, 5, 16384}, INIT_FORKNUM, PG12))
+}
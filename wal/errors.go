@@ -0,0 +1,42 @@
+package wal
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrEndOfWAL reports the clean end of the available WAL: a zeroed
+	// tail, a page left over from a recycled segment, a record of zero
+	// length or a missing segment file.
+	ErrEndOfWAL = errors.New("end of WAL")
+	// ErrInvalidRecord reports a record header which does not make sense.
+	ErrInvalidRecord = errors.New("invalid record")
+	// ErrInvalidPageHeader reports a page header which does not make sense.
+	ErrInvalidPageHeader = errors.New("invalid page header")
+	// ErrCRCMismatch reports a record whose xl_crc does not match its content.
+	ErrCRCMismatch = errors.New("incorrect resource manager data checksum")
+)
+
+// ReadError is returned by XLogReader when the WAL at LSN cannot be used.
+// Err is one of the Err* values of this package, or the I/O error which
+// stopped the reader.
+type ReadError struct {
+	LSN     XLogRecPtr
+	Segment string // name of the segment file LSN belongs to
+	Offset  uint32 // offset of LSN in the segment file
+	Err     error
+	Reason  string
+}
+
+func (e *ReadError) Error() string {
+	msg := e.Err.Error()
+	if e.Reason != "" {
+		msg += ": " + e.Reason
+	}
+	return fmt.Sprintf("%s at %s (segment %s, offset %d)", msg, e.LSN, e.Segment, e.Offset)
+}
+
+func (e *ReadError) Unwrap() error {
+	return e.Err
+}
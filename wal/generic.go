@@ -0,0 +1,56 @@
+package wal
+
+const MAX_GENERIC_XLOG_PAGES = 4 /* XLR_NORMAL_MAX_BLOCK_ID */
+
+/*
+ * A fragment of the delta generic WAL logs for a page, the Length bytes of
+ * Data to copy at Offset.
+ */
+type GenericFragment struct {
+	Offset OffsetNumber
+	Length OffsetNumber
+	Data   []byte
+}
+
+// GenericPage is the change of a page registered as block Id, either a full
+// page image or the fragments of the delta.
+type GenericPage struct {
+	Id        uint8
+	FullImage bool
+	Fragments []GenericFragment
+}
+
+type GenericRecord struct {
+	Pages []GenericPage
+}
+
+// DecodeGeneric decodes the records of RM_GENERIC_ID, which have no info
+// code, into a *GenericRecord.
+func DecodeGeneric(record *Record) (interface{}, error) {
+	ret := &GenericRecord{}
+	for i := range record.Blocks {
+		block := &record.Blocks[i]
+		page := GenericPage{Id: block.Bheader.Id}
+		data := block.TupleData
+		if len(data) == 0 {
+			/* the page was logged as a full image, GENERIC_XLOG_FULL_IMAGE */
+			page.FullImage = true
+		}
+		for len(data) > 0 {
+			header, err := readArray[OffsetNumber](record, data, 2)
+			if err != nil {
+				return nil, err
+			}
+			fragment := GenericFragment{Offset: header[0], Length: header[1]}
+			data = data[4:]
+			if int(fragment.Length) > len(data) {
+				return nil, record.tooShort(data, "a generic fragment")
+			}
+			fragment.Data = data[:fragment.Length]
+			data = data[fragment.Length:]
+			page.Fragments = append(page.Fragments, fragment)
+		}
+		ret.Pages = append(ret.Pages, page)
+	}
+	return ret, nil
+}
@@ -0,0 +1,22 @@
+package wal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeGeneric(t *testing.T) {
+	decoded, err := DecodeRmgrData(testRecord(t, RM_GENERIC_ID, 0, nil,
+		le(uint16(24), uint16(2), []byte{1, 2}, uint16(8000), uint16(3), []byte{3, 4, 5}),
+		nil))
+	require.NoError(t, err)
+	assert.Equal(t, &GenericRecord{Pages: []GenericPage{
+		{Id: 0, Fragments: []GenericFragment{{24, 2, []byte{1, 2}}, {8000, 3, []byte{3, 4, 5}}}},
+		{Id: 1, FullImage: true},
+	}}, decoded)
+
+	_, err = DecodeRmgrData(testRecord(t, RM_GENERIC_ID, 0, nil, le(uint16(24), uint16(3), []byte{1, 2})))
+	assert.ErrorIs(t, err, ErrInvalidRecord)
+}
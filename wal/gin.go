@@ -0,0 +1,429 @@
+package wal
+
+import "unsafe"
+
+const (
+	/* 0x00 is free, was XLOG_GIN_CREATE_INDEX */
+	XLOG_GIN_CREATE_PTREE          = 0x10
+	XLOG_GIN_INSERT                = 0x20
+	XLOG_GIN_SPLIT                 = 0x30
+	XLOG_GIN_VACUUM_PAGE           = 0x40
+	XLOG_GIN_DELETE_PAGE           = 0x50
+	XLOG_GIN_UPDATE_META_PAGE      = 0x60
+	XLOG_GIN_INSERT_LISTPAGE       = 0x70
+	XLOG_GIN_DELETE_LISTPAGE       = 0x80
+	XLOG_GIN_VACUUM_DATA_LEAF_PAGE = 0x90
+)
+
+const (
+	GIN_INSERT_ISDATA = 0x01 /* for both insertion and split records */
+	GIN_INSERT_ISLEAF = 0x02 /* ditto */
+	GIN_SPLIT_ROOT    = 0x04 /* only for split records */
+)
+
+/* Action types */
+const (
+	GIN_SEGMENT_UNMODIFIED = 0 /* no action (not used in WAL records) */
+	GIN_SEGMENT_DELETE     = 1 /* a whole segment is removed */
+	GIN_SEGMENT_INSERT     = 2 /* a whole segment is added */
+	GIN_SEGMENT_REPLACE    = 3 /* a segment is replaced */
+	GIN_SEGMENT_ADDITEMS   = 4 /* items are added to existing segment */
+)
+
+type BlockIdData struct {
+	BiHi uint16
+	BiLo uint16
+}
+
+// BlockNumber returns the block number, like BlockIdGetBlockNumber.
+func (b BlockIdData) BlockNumber() BlockNumber {
+	return BlockNumber(b.BiHi)<<16 | BlockNumber(b.BiLo)
+}
+
+type GinxlogCreatePostingTree struct {
+	Size uint32
+	/* A compressed posting list follows */
+}
+
+func SizeofGinxlogCreatePostingTree() int64 {
+	return 4
+}
+
+/*
+ * The format of the insertion record varies depending on the page type.
+ * ginxlogInsert is the common part between all variants.
+ *
+ * Backup Blk 0: target page
+ * Backup Blk 1: left child, if this insertion finishes an incomplete split
+ */
+type GinxlogInsert struct {
+	Flags uint16 /* GIN_INSERT_ISLEAF and/or GIN_INSERT_ISDATA */
+
+	/*
+	 * FOLLOWS:
+	 *
+	 * 1. if not leaf page, block numbers of the left and right child pages
+	 * whose split this insertion finishes, as BlockIdData[2] (beware of
+	 * adding fields in this struct that would make them not 16-bit aligned)
+	 *
+	 * 2. a ginxlogInsertEntry or ginxlogRecompressDataLeaf struct, depending
+	 * on tree type.
+	 */
+}
+
+func SizeofGinxlogInsert() int64 {
+	return 2
+}
+
+type GinxlogInsertEntry struct {
+	Offset   OffsetNumber
+	IsDelete bool
+	/* IndexTupleData tuple follows, 16-bit aligned */
+}
+
+func SizeofGinxlogInsertEntry() int64 {
+	return 4
+}
+
+/* PostingItem of a non-leaf posting tree page */
+type PostingItem struct {
+	ChildBlkno BlockIdData
+	Key        ItemPointerData
+}
+
+type GinxlogInsertDataInternal struct {
+	Offset  OffsetNumber
+	Newitem PostingItem
+}
+
+func SizeofGinxlogInsertDataInternal() int64 {
+	return 12
+}
+
+/*
+ * Backup Blk 0: new left page (= original page, if not root split)
+ * Backup Blk 1: new right page
+ * Backup Blk 2: original page / new root page, if root split
+ * Backup Blk 3: left child, if this insertion completes an earlier split
+ */
+type GinxlogSplit struct {
+	Node   RelFileNode
+	Rrlink BlockNumber /* right link, or root's blocknumber if root
+	 * split */
+	LeftChildBlkno  BlockNumber /* valid on a non-leaf split */
+	RightChildBlkno BlockNumber
+	Flags           uint16 /* see below */
+}
+
+func SizeofGinxlogSplit() int64 {
+	return 26
+}
+
+/*
+ * Backup Blk 0: deleted page
+ * Backup Blk 1: parent page
+ * Backup Blk 2: left sibling
+ */
+type GinxlogDeletePage struct {
+	ParentOffset OffsetNumber
+	RightLink    BlockNumber
+	DeleteXid    TransactionId /* last Xid which could see this page in scan */
+}
+
+func SizeofGinxlogDeletePage() int64 {
+	return 12
+}
+
+/*
+ * Contents of the metapage of a GIN index
+ */
+type GinMetaPageData struct {
+	/*
+	 * Pointers to head and tail of pending list, which consists of GIN_LIST
+	 * pages.  These store fast-inserted entries that haven't yet been moved
+	 * into the regular GIN structure.
+	 */
+	Head BlockNumber
+	Tail BlockNumber
+
+	/*
+	 * Free space in bytes in the pending list's tail page.
+	 */
+	TailFreeSize uint32
+
+	/*
+	 * We store both number of pages and number of heap tuples that are in
+	 * the pending list.
+	 */
+	NPendingPages      BlockNumber
+	NPendingHeapTuples int64
+
+	/*
+	 * Statistics for planner use (accurate as of last VACUUM)
+	 */
+	NTotalPages BlockNumber
+	NEntryPages BlockNumber
+	NDataPages  BlockNumber
+	NEntries    int64
+
+	/*
+	 * GIN version number (ideally this should have been at the front, but too
+	 * late now.  Don't move it!)
+	 */
+	GinVersion int32
+}
+
+/*
+ * Backup Blk 0: metapage
+ * Backup Blk 1: tail page
+ */
+type GinxlogUpdateMeta struct {
+	Node         RelFileNode
+	Metadata     GinMetaPageData
+	PrevTail     BlockNumber
+	NewRightlink BlockNumber
+	Ntuples      int32 /* if ntuples > 0 then metadata.tail was
+	 * updated with that many tuples; else new sub
+	 * list was appended */
+}
+
+func SizeofGinxlogUpdateMeta() int64 {
+	return 84
+}
+
+/*
+ * Backup Blk 0: page
+ */
+type GinxlogInsertListPage struct {
+	Rightlink BlockNumber
+	Ntuples   int32
+	/* array of inserted tuples follows */
+}
+
+func SizeofGinxlogInsertListPage() int64 {
+	return 8
+}
+
+/*
+ * Backup Blk 0: metapage
+ * Backup Blk 1 to (ndeleted + 1): deleted pages
+ */
+type GinxlogDeleteListPages struct {
+	Metadata GinMetaPageData /* new metapage contents */
+	Ndeleted int32
+}
+
+func SizeofGinxlogDeleteListPages() int64 {
+	return 60
+}
+
+// GinSegmentAction is a ginxlogSegmentAction of a recompressed posting tree
+// leaf page.
+type GinSegmentAction struct {
+	Segno uint8 /* segment this action applies to */
+	Type  uint8 /* action type */
+	// the GinPostingList of GIN_SEGMENT_INSERT and GIN_SEGMENT_REPLACE
+	PostingList []byte
+	// the items of GIN_SEGMENT_ADDITEMS
+	Items []ItemPointerData
+}
+
+type GinCreatePtree struct {
+	GinxlogCreatePostingTree
+	PostingList []byte
+}
+
+// GinInsert decodes XLOG_GIN_INSERT. Depending on Flags, one of Entry,
+// Actions and Internal is set, unless only a full-page image was logged.
+type GinInsert struct {
+	GinxlogInsert
+	LeftChildBlkno  BlockNumber // if not a leaf page
+	RightChildBlkno BlockNumber // if not a leaf page
+	Entry           *GinxlogInsertEntry
+	Tuple           []byte // the index tuple of Entry
+	Actions         []GinSegmentAction
+	Internal        *GinxlogInsertDataInternal
+}
+
+type GinSplit struct {
+	GinxlogSplit
+}
+
+// GinVacuumPage is a full-page image of an entry tree leaf page.
+type GinVacuumPage struct{}
+
+type GinVacuumDataLeafPage struct {
+	Actions []GinSegmentAction
+}
+
+type GinDeletePage struct {
+	GinxlogDeletePage
+}
+
+type GinUpdateMetaPage struct {
+	GinxlogUpdateMeta
+	Tuples []byte // the tuples appended to the tail page
+}
+
+type GinInsertListPage struct {
+	GinxlogInsertListPage
+	Tuples []byte
+}
+
+type GinDeleteListPages struct {
+	GinxlogDeleteListPages
+}
+
+// DecodeGin decodes the records of RM_GIN_ID into the struct of their info
+// code, e.g. *GinInsert.
+func DecodeGin(record *Record) (interface{}, error) {
+	data := record.MainData
+	switch record.Info() {
+	case XLOG_GIN_CREATE_PTREE:
+		xlrec, err := readStruct[GinxlogCreatePostingTree](record, data, SizeofGinxlogCreatePostingTree())
+		if err != nil {
+			return nil, err
+		}
+		data = data[SizeofGinxlogCreatePostingTree():]
+		if uint32(len(data)) < xlrec.Size {
+			return nil, record.tooShort(data, "posting list")
+		}
+		return &GinCreatePtree{GinxlogCreatePostingTree: *xlrec, PostingList: data[:xlrec.Size]}, nil
+	case XLOG_GIN_INSERT:
+		return decodeGinInsert(record)
+	case XLOG_GIN_SPLIT:
+		xlrec, err := readStruct[GinxlogSplit](record, data, SizeofGinxlogSplit())
+		if err != nil {
+			return nil, err
+		}
+		return &GinSplit{GinxlogSplit: *xlrec}, nil
+	case XLOG_GIN_VACUUM_PAGE:
+		return &GinVacuumPage{}, nil
+	case XLOG_GIN_VACUUM_DATA_LEAF_PAGE:
+		ret := &GinVacuumDataLeafPage{}
+		if blockData := record.BlockData(0); blockData != nil {
+			var err error
+			ret.Actions, err = readGinSegmentActions(record, blockData)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return ret, nil
+	case XLOG_GIN_DELETE_PAGE:
+		xlrec, err := readStruct[GinxlogDeletePage](record, data, SizeofGinxlogDeletePage())
+		if err != nil {
+			return nil, err
+		}
+		return &GinDeletePage{GinxlogDeletePage: *xlrec}, nil
+	case XLOG_GIN_UPDATE_META_PAGE:
+		xlrec, err := readStruct[GinxlogUpdateMeta](record, data, SizeofGinxlogUpdateMeta())
+		if err != nil {
+			return nil, err
+		}
+		return &GinUpdateMetaPage{GinxlogUpdateMeta: *xlrec, Tuples: record.BlockData(1)}, nil
+	case XLOG_GIN_INSERT_LISTPAGE:
+		xlrec, err := readStruct[GinxlogInsertListPage](record, data, SizeofGinxlogInsertListPage())
+		if err != nil {
+			return nil, err
+		}
+		return &GinInsertListPage{GinxlogInsertListPage: *xlrec, Tuples: record.BlockData(0)}, nil
+	case XLOG_GIN_DELETE_LISTPAGE:
+		xlrec, err := readStruct[GinxlogDeleteListPages](record, data, SizeofGinxlogDeleteListPages())
+		if err != nil {
+			return nil, err
+		}
+		return &GinDeleteListPages{GinxlogDeleteListPages: *xlrec}, nil
+	}
+	return nil, record.unknownInfo()
+}
+
+func decodeGinInsert(record *Record) (*GinInsert, error) {
+	data := record.MainData
+	xlrec, err := readStruct[GinxlogInsert](record, data, SizeofGinxlogInsert())
+	if err != nil {
+		return nil, err
+	}
+	ret := &GinInsert{GinxlogInsert: *xlrec}
+	isLeaf := xlrec.Flags&GIN_INSERT_ISLEAF != 0
+	if !isLeaf {
+		children, err := readArray[BlockIdData](record, data[SizeofGinxlogInsert():], 2)
+		if err != nil {
+			return nil, err
+		}
+		ret.LeftChildBlkno = children[0].BlockNumber()
+		ret.RightChildBlkno = children[1].BlockNumber()
+	}
+	payload := record.BlockData(0)
+	if payload == nil {
+		return ret, nil
+	}
+	switch {
+	case xlrec.Flags&GIN_INSERT_ISDATA == 0:
+		ret.Entry, err = readStruct[GinxlogInsertEntry](record, payload, SizeofGinxlogInsertEntry())
+		if err != nil {
+			return nil, err
+		}
+		ret.Tuple, _, err = readIndexTuple(record, payload[SizeofGinxlogInsertEntry():], false)
+		if err != nil {
+			return nil, err
+		}
+	case isLeaf:
+		ret.Actions, err = readGinSegmentActions(record, payload)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		ret.Internal, err = readStruct[GinxlogInsertDataInternal](record, payload, SizeofGinxlogInsertDataInternal())
+		if err != nil {
+			return nil, err
+		}
+	}
+	return ret, nil
+}
+
+// readGinSegmentActions decodes a ginxlogRecompressDataLeaf, see
+// ginRedoRecompress.
+func readGinSegmentActions(record *Record, data []byte) ([]GinSegmentAction, error) {
+	nactions, err := readStruct[uint16](record, data, 2)
+	if err != nil {
+		return nil, err
+	}
+	walbuf := data[2:]
+	actions := make([]GinSegmentAction, 0, *nactions)
+	for i := 0; i < int(*nactions); i++ {
+		if len(walbuf) < 2 {
+			return nil, record.tooShort(walbuf, "segment action")
+		}
+		action := GinSegmentAction{Segno: walbuf[0], Type: walbuf[1]}
+		walbuf = walbuf[2:]
+		switch action.Type {
+		case GIN_SEGMENT_INSERT, GIN_SEGMENT_REPLACE:
+			// GinPostingList: ItemPointerData first, uint16 nbytes and the
+			// varbyte-encoded items
+			if len(walbuf) < 8 {
+				return nil, record.tooShort(walbuf, "posting list")
+			}
+			size := 8 + (int(*(*uint16)(unsafe.Pointer(&walbuf[6])))+1)&^1
+			if len(walbuf) < size {
+				return nil, record.tooShort(walbuf, "posting list")
+			}
+			action.PostingList = walbuf[:size]
+			walbuf = walbuf[size:]
+		case GIN_SEGMENT_ADDITEMS:
+			nitems, err := readStruct[uint16](record, walbuf, 2)
+			if err != nil {
+				return nil, err
+			}
+			action.Items, err = readArray[ItemPointerData](record, walbuf[2:], int(*nitems))
+			if err != nil {
+				return nil, err
+			}
+			walbuf = walbuf[2+6*int(*nitems):]
+		case GIN_SEGMENT_DELETE:
+		default:
+			return nil, record.errorf("unexpected GIN leaf action: %d", action.Type)
+		}
+		actions = append(actions, action)
+	}
+	return actions, nil
+}
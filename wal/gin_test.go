@@ -0,0 +1,83 @@
+package wal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeGin(t *testing.T) {
+	t.Run("insert entry", func(t *testing.T) {
+		record := testRecord(t, RM_GIN_ID, XLOG_GIN_INSERT, le(uint16(GIN_INSERT_ISLEAF)),
+			le(uint16(3), uint8(1), uint8(0), indexTuple(1)))
+		decoded, err := DecodeRmgrData(record)
+		require.NoError(t, err)
+		insert := decoded.(*GinInsert)
+		require.NotNil(t, insert.Entry)
+		assert.EqualValues(t, 3, insert.Entry.Offset)
+		assert.True(t, insert.Entry.IsDelete)
+		assert.Equal(t, indexTuple(1), insert.Tuple)
+	})
+	t.Run("insert internal", func(t *testing.T) {
+		record := testRecord(t, RM_GIN_ID, XLOG_GIN_INSERT,
+			le(uint16(GIN_INSERT_ISDATA), uint16(0), uint16(4), uint16(0), uint16(5)),
+			le(uint16(2), uint16(0), uint16(9), uint16(0), uint16(7), uint16(3)))
+		decoded, err := DecodeRmgrData(record)
+		require.NoError(t, err)
+		insert := decoded.(*GinInsert)
+		assert.Equal(t, BlockNumber(4), insert.LeftChildBlkno)
+		assert.Equal(t, BlockNumber(5), insert.RightChildBlkno)
+		require.NotNil(t, insert.Internal)
+		assert.Equal(t, BlockNumber(9), insert.Internal.Newitem.ChildBlkno.BlockNumber())
+		assert.Equal(t, BlockNumber(7), insert.Internal.Newitem.Key.BlockNumber())
+	})
+	t.Run("vacuum data leaf page", func(t *testing.T) {
+		record := testRecord(t, RM_GIN_ID, XLOG_GIN_VACUUM_DATA_LEAF_PAGE, nil,
+			le(uint16(3),
+				uint8(0), uint8(GIN_SEGMENT_DELETE),
+				uint8(1), uint8(GIN_SEGMENT_REPLACE), uint16(0), uint16(1), uint16(1), uint16(3), []byte{1, 2, 3, 0},
+				uint8(2), uint8(GIN_SEGMENT_ADDITEMS), uint16(1), uint16(0), uint16(8), uint16(2)))
+		decoded, err := DecodeRmgrData(record)
+		require.NoError(t, err)
+		actions := decoded.(*GinVacuumDataLeafPage).Actions
+		require.Len(t, actions, 3)
+		assert.EqualValues(t, GIN_SEGMENT_DELETE, actions[0].Type)
+		assert.Len(t, actions[1].PostingList, 12)
+		assert.Equal(t, []ItemPointerData{{0, 8, 2}}, actions[2].Items)
+	})
+	t.Run("create ptree", func(t *testing.T) {
+		record := testRecord(t, RM_GIN_ID, XLOG_GIN_CREATE_PTREE, le(uint32(2), []byte{7, 8}))
+		decoded, err := DecodeRmgrData(record)
+		require.NoError(t, err)
+		assert.Equal(t, []byte{7, 8}, decoded.(*GinCreatePtree).PostingList)
+
+		record = testRecord(t, RM_GIN_ID, XLOG_GIN_CREATE_PTREE, le(uint32(3), []byte{7, 8}))
+		_, err = DecodeRmgrData(record)
+		assert.ErrorIs(t, err, ErrInvalidRecord)
+	})
+	t.Run("delete page", func(t *testing.T) {
+		record := testRecord(t, RM_GIN_ID, XLOG_GIN_DELETE_PAGE,
+			le(uint16(2), uint16(0), uint32(12), uint32(900)), nil, nil, nil)
+		decoded, err := DecodeRmgrData(record)
+		require.NoError(t, err)
+		del := decoded.(*GinDeletePage)
+		assert.Equal(t, BlockNumber(12), del.RightLink)
+		assert.Equal(t, TransactionId(900), del.DeleteXid)
+	})
+	t.Run("update meta page", func(t *testing.T) {
+		metadata := le(uint32(1), uint32(2), uint32(100), uint32(2), uint64(5),
+			uint32(10), uint32(3), uint32(4), uint32(0), uint64(50), uint32(2), uint32(0))
+		record := testRecord(t, RM_GIN_ID, XLOG_GIN_UPDATE_META_PAGE,
+			le(uint32(1663), uint32(5), uint32(16384), uint32(0), metadata, uint32(2), uint32(0xFFFFFFFF), uint32(1)),
+			nil, indexTuple(1))
+		decoded, err := DecodeRmgrData(record)
+		require.NoError(t, err)
+		meta := decoded.(*GinUpdateMetaPage)
+		assert.Equal(t, BlockNumber(2), meta.Metadata.Tail)
+		assert.EqualValues(t, 50, meta.Metadata.NEntries)
+		assert.EqualValues(t, 2, meta.Metadata.GinVersion)
+		assert.EqualValues(t, 1, meta.Ntuples)
+		assert.Equal(t, indexTuple(1), meta.Tuples)
+	})
+}
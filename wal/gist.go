@@ -0,0 +1,233 @@
+package wal
+
+const (
+	XLOG_GIST_PAGE_UPDATE = 0x00
+	XLOG_GIST_DELETE      = 0x10 /* delete leaf index tuples for a
+	 * page */
+	XLOG_GIST_PAGE_REUSE = 0x20 /* old page is about to be reused
+	 * from FSM */
+	XLOG_GIST_PAGE_SPLIT = 0x30
+	/* 0x40 was XLOG_GIST_INSERT_COMPLETE, 0x50 XLOG_GIST_CREATE_INDEX */
+	XLOG_GIST_PAGE_DELETE = 0x60
+	/* Since PostgreSQL 13 */
+	XLOG_GIST_ASSIGN_LSN = 0x70 /* nop, assign new LSN */
+)
+
+/*
+ * Backup Blk 0: updated page.
+ * Backup Blk 1: If this operation completes a page split, by inserting a
+ *				 downlink for the split page, the left half of the split
+ */
+type GistxlogPageUpdate struct {
+	/* number of deleted offsets */
+	Ntodelete uint16
+	Ntoinsert uint16
+
+	/*
+	 * In payload of blk 0 : 1. todelete OffsetNumbers 2. tuples to insert
+	 */
+}
+
+func SizeofGistxlogPageUpdate() int64 {
+	return 4
+}
+
+/*
+ * Backup Blk 0: Leaf page, whose index tuples are deleted.
+ */
+type GistxlogDelete struct {
+	SnapshotConflictHorizon TransactionId /* latestRemovedXid before 16 */
+	Ntodelete               uint16        /* number of deleted offsets */
+	IsCatalogRel            bool          /* Since PostgreSQL 16 */
+
+	/* TODELETE OFFSET NUMBERS FOLLOW */
+}
+
+func SizeofGistxlogDelete(v PgVersion) int64 {
+	if v >= PG16 {
+		return 8
+	}
+	return 6
+}
+
+/*
+ * Backup Blk 0: If this operation completes a page split, by inserting a
+ *				 downlink for the split page, the left half of the split
+ * Backup Blk 1 - npage: split pages (1 is the original page)
+ */
+type GistxlogPageSplit struct {
+	Origrlink BlockNumber /* rightlink of the page before split */
+	Orignsn   XLogRecPtr  /* NSN of the page before split */
+	Origleaf  bool        /* was splitted page a leaf page? */
+
+	Npage           uint16 /* # of pages in the split */
+	Markfollowright bool   /* set F_FOLLOW_RIGHT flags */
+
+	/*
+	 * follow: 1. gistxlogPage and array of IndexTupleData per page
+	 */
+}
+
+func SizeofGistxlogPageSplit() int64 {
+	return 21
+}
+
+/*
+ * Backup Blk 0: page that was deleted.
+ * Backup Blk 1: parent page, containing the downlink to the deleted page.
+ */
+type GistxlogPageDelete struct {
+	DeleteXid      uint64       /* last Xid which could see page in scan */
+	DownlinkOffset OffsetNumber /* Offset of downlink referencing this page */
+}
+
+func SizeofGistxlogPageDelete() int64 {
+	return 10
+}
+
+/*
+ * This is what we need to know about page reuse, for hot standby.
+ */
+type GistxlogPageReuse struct {
+	Node                    RelFileNode
+	Block                   BlockNumber
+	SnapshotConflictHorizon uint64 /* latestRemovedFullXid before 16 */
+	IsCatalogRel            bool   /* Since PostgreSQL 16 */
+}
+
+func SizeofGistxlogPageReuse(v PgVersion) int64 {
+	if v >= PG16 {
+		return 25
+	}
+	return 24
+}
+
+type GistPageUpdate struct {
+	GistxlogPageUpdate
+	Todelete []OffsetNumber
+	Tuples   [][]byte
+}
+
+type GistDelete struct {
+	GistxlogDelete
+	Todelete []OffsetNumber
+}
+
+// GistSplitPage is one of the pages of a split, block reference Id.
+type GistSplitPage struct {
+	Id     uint8
+	Tuples [][]byte
+}
+
+type GistPageSplit struct {
+	GistxlogPageSplit
+	Pages []GistSplitPage
+}
+
+type GistPageDelete struct {
+	GistxlogPageDelete
+}
+
+type GistPageReuse struct {
+	GistxlogPageReuse
+}
+
+// GistAssignLsn only assigns a new LSN, since PostgreSQL 13.
+type GistAssignLsn struct{}
+
+// DecodeGist decodes the records of RM_GIST_ID into the struct of their info
+// code, e.g. *GistPageSplit.
+func DecodeGist(record *Record) (interface{}, error) {
+	data := record.MainData
+	switch record.Info() {
+	case XLOG_GIST_PAGE_UPDATE:
+		xlrec, err := readStruct[GistxlogPageUpdate](record, data, SizeofGistxlogPageUpdate())
+		if err != nil {
+			return nil, err
+		}
+		ret := &GistPageUpdate{GistxlogPageUpdate: *xlrec}
+		blockData := record.BlockData(0)
+		if blockData == nil {
+			return ret, nil
+		}
+		ret.Todelete, err = readArray[OffsetNumber](record, blockData, int(xlrec.Ntodelete))
+		if err != nil {
+			return nil, err
+		}
+		ret.Tuples, err = readIndexTuples(record, blockData[2*int(xlrec.Ntodelete):])
+		if err != nil {
+			return nil, err
+		}
+		return ret, nil
+	case XLOG_GIST_DELETE:
+		size := SizeofGistxlogDelete(record.Version)
+		xlrec, err := readStruct[GistxlogDelete](record, data, size)
+		if err != nil {
+			return nil, err
+		}
+		if record.Version < PG16 {
+			xlrec.IsCatalogRel = false
+		}
+		todelete, err := readArray[OffsetNumber](record, data[size:], int(xlrec.Ntodelete))
+		if err != nil {
+			return nil, err
+		}
+		return &GistDelete{GistxlogDelete: *xlrec, Todelete: todelete}, nil
+	case XLOG_GIST_PAGE_REUSE:
+		xlrec, err := readStruct[GistxlogPageReuse](record, data, SizeofGistxlogPageReuse(record.Version))
+		if err != nil {
+			return nil, err
+		}
+		return &GistPageReuse{GistxlogPageReuse: *xlrec}, nil
+	case XLOG_GIST_PAGE_SPLIT:
+		xlrec, err := readStruct[GistxlogPageSplit](record, data, SizeofGistxlogPageSplit())
+		if err != nil {
+			return nil, err
+		}
+		ret := &GistPageSplit{GistxlogPageSplit: *xlrec}
+		for i := 0; i < int(xlrec.Npage); i++ {
+			page := GistSplitPage{Id: uint8(i + 1)}
+			if blockData := record.BlockData(page.Id); blockData != nil {
+				// the number of tuples, an int, precedes them
+				if _, err := readStruct[int32](record, blockData, 4); err != nil {
+					return nil, err
+				}
+				page.Tuples, err = readIndexTuples(record, blockData[4:])
+				if err != nil {
+					return nil, err
+				}
+			}
+			ret.Pages = append(ret.Pages, page)
+		}
+		return ret, nil
+	case XLOG_GIST_PAGE_DELETE:
+		xlrec, err := readStruct[GistxlogPageDelete](record, data, SizeofGistxlogPageDelete())
+		if err != nil {
+			return nil, err
+		}
+		return &GistPageDelete{GistxlogPageDelete: *xlrec}, nil
+	case XLOG_GIST_ASSIGN_LSN:
+		if record.Version < PG13 {
+			break
+		}
+		return &GistAssignLsn{}, nil
+	}
+	return nil, record.unknownInfo()
+}
+
+// readIndexTuples splits data into the IndexTuples it holds back to back.
+func readIndexTuples(record *Record, data []byte) ([][]byte, error) {
+	var tuples [][]byte
+	for len(data) > 0 {
+		var (
+			tuple []byte
+			err   error
+		)
+		tuple, data, err = readIndexTuple(record, data, false)
+		if err != nil {
+			return nil, err
+		}
+		tuples = append(tuples, tuple)
+	}
+	return tuples, nil
+}
@@ -0,0 +1,70 @@
+package wal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeGist(t *testing.T) {
+	t.Run("page update", func(t *testing.T) {
+		record := testRecord(t, RM_GIST_ID, XLOG_GIST_PAGE_UPDATE, le(uint16(1), uint16(2)),
+			le(uint16(4), indexTuple(1), indexTuple(2)))
+		decoded, err := DecodeRmgrData(record)
+		require.NoError(t, err)
+		update := decoded.(*GistPageUpdate)
+		assert.Equal(t, []OffsetNumber{4}, update.Todelete)
+		assert.Equal(t, [][]byte{indexTuple(1), indexTuple(2)}, update.Tuples)
+	})
+	t.Run("delete", func(t *testing.T) {
+		record := testRecord(t, RM_GIST_ID, XLOG_GIST_DELETE,
+			le(uint32(700), uint16(2), uint8(1), uint8(0), uint16(3), uint16(5)), nil)
+		decoded, err := DecodeRmgrData(record)
+		require.NoError(t, err)
+		del := decoded.(*GistDelete)
+		assert.True(t, del.IsCatalogRel)
+		assert.Equal(t, []OffsetNumber{3, 5}, del.Todelete)
+
+		record = versioned(testRecord(t, RM_GIST_ID, XLOG_GIST_DELETE,
+			le(uint32(700), uint16(2), uint16(3), uint16(5)), nil), PG15)
+		decoded, err = DecodeRmgrData(record)
+		require.NoError(t, err)
+		del = decoded.(*GistDelete)
+		assert.Equal(t, TransactionId(700), del.SnapshotConflictHorizon)
+		assert.False(t, del.IsCatalogRel)
+		assert.Equal(t, []OffsetNumber{3, 5}, del.Todelete)
+	})
+	t.Run("page split", func(t *testing.T) {
+		record := testRecord(t, RM_GIST_ID, XLOG_GIST_PAGE_SPLIT,
+			le(uint32(8), uint32(0), uint64(0x300000), uint8(1), uint8(0), uint16(2), uint8(1), []byte{0, 0, 0}),
+			nil, le(uint32(1), indexTuple(1)), le(uint32(2), indexTuple(2), indexTuple(3)))
+		decoded, err := DecodeRmgrData(record)
+		require.NoError(t, err)
+		split := decoded.(*GistPageSplit)
+		assert.Equal(t, XLogRecPtr(0x300000), split.Orignsn)
+		assert.True(t, split.Origleaf)
+		assert.True(t, split.Markfollowright)
+		require.Len(t, split.Pages, 2)
+		assert.Equal(t, [][]byte{indexTuple(1)}, split.Pages[0].Tuples)
+		assert.EqualValues(t, 2, split.Pages[1].Id)
+		assert.Len(t, split.Pages[1].Tuples, 2)
+	})
+	t.Run("page delete", func(t *testing.T) {
+		record := testRecord(t, RM_GIST_ID, XLOG_GIST_PAGE_DELETE, le(uint64(1<<32|900), uint16(3)), nil, nil)
+		decoded, err := DecodeRmgrData(record)
+		require.NoError(t, err)
+		del := decoded.(*GistPageDelete)
+		assert.Equal(t, uint64(1<<32|900), del.DeleteXid)
+		assert.EqualValues(t, 3, del.DownlinkOffset)
+	})
+	t.Run("assign lsn", func(t *testing.T) {
+		record := testRecord(t, RM_GIST_ID, XLOG_GIST_ASSIGN_LSN, nil)
+		decoded, err := DecodeRmgrData(record)
+		require.NoError(t, err)
+		assert.IsType(t, &GistAssignLsn{}, decoded)
+
+		_, err = DecodeRmgrData(versioned(record, PG12))
+		assert.ErrorIs(t, err, ErrInvalidRecord)
+	})
+}
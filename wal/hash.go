@@ -0,0 +1,497 @@
+package wal
+
+const (
+	/*
+	 * XLOG records for hash operations
+	 */
+	XLOG_HASH_INIT_META_PAGE      = 0x00 /* initialize the meta page */
+	XLOG_HASH_INIT_BITMAP_PAGE    = 0x10 /* initialize the bitmap page */
+	XLOG_HASH_INSERT              = 0x20 /* add index tuple without split */
+	XLOG_HASH_ADD_OVFL_PAGE       = 0x30 /* add overflow page */
+	XLOG_HASH_SPLIT_ALLOCATE_PAGE = 0x40 /* allocate new page for split */
+	XLOG_HASH_SPLIT_PAGE          = 0x50 /* split page */
+	XLOG_HASH_SPLIT_COMPLETE      = 0x60 /* completion of split operation */
+	XLOG_HASH_MOVE_PAGE_CONTENTS  = 0x70 /* remove tuples from one page
+	 * and add to another page */
+	XLOG_HASH_SQUEEZE_PAGE = 0x80 /* add tuples to one of the previous
+	 * pages in chain and free the ovfl
+	 * page */
+	XLOG_HASH_DELETE        = 0x90 /* delete index tuples from a page */
+	XLOG_HASH_SPLIT_CLEANUP = 0xA0 /* clear split-cleanup flag in primary
+	 * bucket page after deleting tuples
+	 * that are moved due to split	*/
+	XLOG_HASH_UPDATE_META_PAGE = 0xB0 /* update meta page after vacuum */
+	XLOG_HASH_VACUUM_ONE_PAGE  = 0xC0 /* remove dead tuples from index
+	 * page */
+)
+
+/*
+ * xl_hash_split_allocate_page flag values, 8 bits are available.
+ */
+const (
+	XLH_SPLIT_META_UPDATE_MASKS      = 1 << 0
+	XLH_SPLIT_META_UPDATE_SPLITPOINT = 1 << 1
+)
+
+/*
+ * This is what we need to know about simple (without split) insert.
+ *
+ * This data record is used for XLOG_HASH_INSERT
+ *
+ * Backup Blk 0: original page (data contains the inserted tuple)
+ * Backup Blk 1: metapage (HashMetaPageData)
+ */
+type XlHashInsert struct {
+	Offnum OffsetNumber
+}
+
+func SizeofXlHashInsert() int64 {
+	return 2
+}
+
+/*
+ * This is what we need to know about addition of overflow page.
+ *
+ * This data record is used for XLOG_HASH_ADD_OVFL_PAGE
+ *
+ * Backup Blk 0: newly allocated overflow page
+ * Backup Blk 1: page before new overflow page in the bucket chain
+ * Backup Blk 2: bitmap page
+ * Backup Blk 3: new bitmap page
+ * Backup Blk 4: metapage
+ */
+type XlHashAddOvflPage struct {
+	Bmsize      uint16
+	BmpageFound bool
+}
+
+func SizeofXlHashAddOvflPage() int64 {
+	return 3
+}
+
+/*
+ * This is what we need to know about allocating a page for split.
+ *
+ * This data record is used for XLOG_HASH_SPLIT_ALLOCATE_PAGE
+ *
+ * Backup Blk 0: page for old bucket
+ * Backup Blk 1: page for new bucket
+ * Backup Blk 2: metapage
+ */
+type XlHashSplitAllocatePage struct {
+	NewBucket     uint32
+	OldBucketFlag uint16
+	NewBucketFlag uint16
+	Flags         uint8
+}
+
+func SizeofXlHashSplitAllocatePage() int64 {
+	return 9
+}
+
+/*
+ * This is what we need to know about completing the split operation.
+ *
+ * This data record is used for XLOG_HASH_SPLIT_COMPLETE
+ *
+ * Backup Blk 0: page for old bucket
+ * Backup Blk 1: page for new bucket
+ */
+type XlHashSplitComplete struct {
+	OldBucketFlag uint16
+	NewBucketFlag uint16
+}
+
+func SizeofXlHashSplitComplete() int64 {
+	return 4
+}
+
+/*
+ * This is what we need to know about move page contents required during
+ * squeeze operation.
+ *
+ * This data record is used for XLOG_HASH_MOVE_PAGE_CONTENTS
+ *
+ * Backup Blk 0: bucket page
+ * Backup Blk 1: page containing moved tuples
+ * Backup Blk 2: page from which tuples will be removed
+ */
+type XlHashMovePageContents struct {
+	Ntups               uint16
+	IsPrimBucketSameWrt bool /* true if the page to which
+	 * tuples are moved is same as
+	 * primary bucket page */
+}
+
+func SizeofXlHashMovePageContents() int64 {
+	return 3
+}
+
+/*
+ * This is what we need to know about the squeeze page operation.
+ *
+ * This data record is used for XLOG_HASH_SQUEEZE_PAGE
+ *
+ * Backup Blk 0: primary bucket page
+ * Backup Blk 1: page containing tuples moved from freed overflow page
+ * Backup Blk 2: freed overflow page
+ * Backup Blk 3: page previous to the freed overflow page
+ * Backup Blk 4: page next to the freed overflow page
+ * Backup Blk 5: bitmap page containing info of freed overflow page
+ * Backup Blk 6: meta page
+ */
+type XlHashSqueezePage struct {
+	Prevblkno           BlockNumber
+	Nextblkno           BlockNumber
+	Ntups               uint16
+	IsPrimBucketSameWrt bool /* true if the page to which
+	 * tuples are moved is same as
+	 * primary bucket page */
+	IsPrevBucketSameWrt bool /* true if the page to which
+	 * tuples are moved is the page
+	 * previous to the freed overflow
+	 * page */
+}
+
+func SizeofXlHashSqueezePage() int64 {
+	return 12
+}
+
+/*
+ * This is what we need to know about the deletion of index tuples from a page.
+ *
+ * This data record is used for XLOG_HASH_DELETE
+ *
+ * Backup Blk 0: primary bucket page
+ * Backup Blk 1: page from which tuples are deleted
+ */
+type XlHashDelete struct {
+	ClearDeadMarking bool /* true if this operation clears
+	 * LH_PAGE_HAS_DEAD_TUPLES flag */
+	IsPrimaryBucketPage bool /* true if the operation is for
+	 * primary bucket page */
+}
+
+func SizeofXlHashDelete() int64 {
+	return 2
+}
+
+/*
+ * This is what we need for metapage update operation.
+ *
+ * This data record is used for XLOG_HASH_UPDATE_META_PAGE
+ *
+ * Backup Blk 0: meta page
+ */
+type XlHashUpdateMetaPage struct {
+	Ntuples float64
+}
+
+func SizeofXlHashUpdateMetaPage() int64 {
+	return 8
+}
+
+/*
+ * This is what we need to initialize metapage.
+ *
+ * This data record is used for XLOG_HASH_INIT_META_PAGE
+ *
+ * Backup Blk 0: meta page
+ */
+type XlHashInitMetaPage struct {
+	NumTuples float64
+	Procid    Oid
+	Ffactor   uint16
+}
+
+func SizeofXlHashInitMetaPage() int64 {
+	return 14
+}
+
+/*
+ * This is what we need to initialize bitmap page.
+ *
+ * This data record is used for XLOG_HASH_INIT_BITMAP_PAGE
+ *
+ * Backup Blk 0: bitmap page
+ * Backup Blk 1: meta page
+ */
+type XlHashInitBitmapPage struct {
+	Bmsize uint16
+}
+
+func SizeofXlHashInitBitmapPage() int64 {
+	return 2
+}
+
+/*
+ * This is what we need for index tuple deletion and to
+ * update the meta page.
+ *
+ * This data record is used for XLOG_HASH_VACUUM_ONE_PAGE
+ *
+ * Backup Blk 0: bucket page
+ * Backup Blk 1: meta page
+ */
+type XlHashVacuumOnePage struct {
+	SnapshotConflictHorizon TransactionId /* latestRemovedXid before 16 */
+	Ntuples                 uint16        /* an int up to PostgreSQL 15 */
+	IsCatalogRel            bool          /* Since PostgreSQL 16 */
+
+	/* TARGET OFFSET NUMBERS FOLLOW */
+}
+
+func SizeofXlHashVacuumOnePage() int64 {
+	return 8
+}
+
+type HashInitMetaPage struct {
+	XlHashInitMetaPage
+}
+
+type HashInitBitmapPage struct {
+	XlHashInitBitmapPage
+}
+
+type HashInsert struct {
+	XlHashInsert
+	Tuple []byte
+}
+
+type HashAddOvflPage struct {
+	XlHashAddOvflPage
+	Bucket        *uint32 // the bucket of the new overflow page
+	BitmapPageBit *uint32 // the bit set in the bitmap page, if BmpageFound
+	Firstfree     *uint32 // the new hashm_firstfree of the metapage
+}
+
+// HashSplitAllocatePage decodes XLOG_HASH_SPLIT_ALLOCATE_PAGE, with the
+// metapage fields Flags tells to update.
+type HashSplitAllocatePage struct {
+	XlHashSplitAllocatePage
+	Lowmask   uint32 // XLH_SPLIT_META_UPDATE_MASKS
+	Highmask  uint32
+	Ovflpoint uint32 // XLH_SPLIT_META_UPDATE_SPLITPOINT
+	Ovflpages uint32
+}
+
+// HashSplitPage is a full-page image of a page being split.
+type HashSplitPage struct{}
+
+type HashSplitComplete struct {
+	XlHashSplitComplete
+}
+
+type HashMovePageContents struct {
+	XlHashMovePageContents
+	Offsets []OffsetNumber // where the tuples are added
+	Tuples  [][]byte
+	Deleted []OffsetNumber // the tuples removed from the overflow page
+}
+
+type HashSqueezePage struct {
+	XlHashSqueezePage
+	Offsets       []OffsetNumber
+	Tuples        [][]byte
+	BitmapPageBit *uint32
+	Firstfree     *uint32
+}
+
+type HashDelete struct {
+	XlHashDelete
+	Deleted []OffsetNumber
+}
+
+// HashSplitCleanup clears the split-cleanup flag of a primary bucket page.
+type HashSplitCleanup struct{}
+
+type HashUpdateMetaPage struct {
+	XlHashUpdateMetaPage
+}
+
+type HashVacuumOnePage struct {
+	XlHashVacuumOnePage
+	Offsets []OffsetNumber
+}
+
+// DecodeHash decodes the records of RM_HASH_ID into the struct of their info
+// code, e.g. *HashInsert.
+func DecodeHash(record *Record) (interface{}, error) {
+	data := record.MainData
+	switch record.Info() {
+	case XLOG_HASH_INIT_META_PAGE:
+		xlrec, err := readStruct[XlHashInitMetaPage](record, data, SizeofXlHashInitMetaPage())
+		if err != nil {
+			return nil, err
+		}
+		return &HashInitMetaPage{XlHashInitMetaPage: *xlrec}, nil
+	case XLOG_HASH_INIT_BITMAP_PAGE:
+		xlrec, err := readStruct[XlHashInitBitmapPage](record, data, SizeofXlHashInitBitmapPage())
+		if err != nil {
+			return nil, err
+		}
+		return &HashInitBitmapPage{XlHashInitBitmapPage: *xlrec}, nil
+	case XLOG_HASH_INSERT:
+		xlrec, err := readStruct[XlHashInsert](record, data, SizeofXlHashInsert())
+		if err != nil {
+			return nil, err
+		}
+		return &HashInsert{XlHashInsert: *xlrec, Tuple: record.BlockData(0)}, nil
+	case XLOG_HASH_ADD_OVFL_PAGE:
+		xlrec, err := readStruct[XlHashAddOvflPage](record, data, SizeofXlHashAddOvflPage())
+		if err != nil {
+			return nil, err
+		}
+		ret := &HashAddOvflPage{XlHashAddOvflPage: *xlrec}
+		if ret.Bucket, err = readBlockUint32(record, 0); err != nil {
+			return nil, err
+		}
+		if ret.BitmapPageBit, err = readBlockUint32(record, 2); err != nil {
+			return nil, err
+		}
+		if ret.Firstfree, err = readBlockUint32(record, 4); err != nil {
+			return nil, err
+		}
+		return ret, nil
+	case XLOG_HASH_SPLIT_ALLOCATE_PAGE:
+		xlrec, err := readStruct[XlHashSplitAllocatePage](record, data, SizeofXlHashSplitAllocatePage())
+		if err != nil {
+			return nil, err
+		}
+		ret := &HashSplitAllocatePage{XlHashSplitAllocatePage: *xlrec}
+		blockData := record.BlockData(2)
+		if blockData == nil {
+			return ret, nil
+		}
+		if xlrec.Flags&XLH_SPLIT_META_UPDATE_MASKS != 0 {
+			masks, err := readArray[uint32](record, blockData, 2)
+			if err != nil {
+				return nil, err
+			}
+			ret.Lowmask, ret.Highmask = masks[0], masks[1]
+			blockData = blockData[8:]
+		}
+		if xlrec.Flags&XLH_SPLIT_META_UPDATE_SPLITPOINT != 0 {
+			splitpoint, err := readArray[uint32](record, blockData, 2)
+			if err != nil {
+				return nil, err
+			}
+			ret.Ovflpoint, ret.Ovflpages = splitpoint[0], splitpoint[1]
+		}
+		return ret, nil
+	case XLOG_HASH_SPLIT_PAGE:
+		return &HashSplitPage{}, nil
+	case XLOG_HASH_SPLIT_COMPLETE:
+		xlrec, err := readStruct[XlHashSplitComplete](record, data, SizeofXlHashSplitComplete())
+		if err != nil {
+			return nil, err
+		}
+		return &HashSplitComplete{XlHashSplitComplete: *xlrec}, nil
+	case XLOG_HASH_MOVE_PAGE_CONTENTS:
+		xlrec, err := readStruct[XlHashMovePageContents](record, data, SizeofXlHashMovePageContents())
+		if err != nil {
+			return nil, err
+		}
+		ret := &HashMovePageContents{XlHashMovePageContents: *xlrec}
+		if ret.Offsets, ret.Tuples, err = readHashMovedTuples(record, 1, int(xlrec.Ntups)); err != nil {
+			return nil, err
+		}
+		if blockData := record.BlockData(2); blockData != nil {
+			if ret.Deleted, err = readArray[OffsetNumber](record, blockData, len(blockData)/2); err != nil {
+				return nil, err
+			}
+		}
+		return ret, nil
+	case XLOG_HASH_SQUEEZE_PAGE:
+		xlrec, err := readStruct[XlHashSqueezePage](record, data, SizeofXlHashSqueezePage())
+		if err != nil {
+			return nil, err
+		}
+		ret := &HashSqueezePage{XlHashSqueezePage: *xlrec}
+		if ret.Offsets, ret.Tuples, err = readHashMovedTuples(record, 1, int(xlrec.Ntups)); err != nil {
+			return nil, err
+		}
+		if ret.BitmapPageBit, err = readBlockUint32(record, 5); err != nil {
+			return nil, err
+		}
+		if ret.Firstfree, err = readBlockUint32(record, 6); err != nil {
+			return nil, err
+		}
+		return ret, nil
+	case XLOG_HASH_DELETE:
+		xlrec, err := readStruct[XlHashDelete](record, data, SizeofXlHashDelete())
+		if err != nil {
+			return nil, err
+		}
+		ret := &HashDelete{XlHashDelete: *xlrec}
+		if blockData := record.BlockData(1); blockData != nil {
+			if ret.Deleted, err = readArray[OffsetNumber](record, blockData, len(blockData)/2); err != nil {
+				return nil, err
+			}
+		}
+		return ret, nil
+	case XLOG_HASH_SPLIT_CLEANUP:
+		return &HashSplitCleanup{}, nil
+	case XLOG_HASH_UPDATE_META_PAGE:
+		xlrec, err := readStruct[XlHashUpdateMetaPage](record, data, SizeofXlHashUpdateMetaPage())
+		if err != nil {
+			return nil, err
+		}
+		return &HashUpdateMetaPage{XlHashUpdateMetaPage: *xlrec}, nil
+	case XLOG_HASH_VACUUM_ONE_PAGE:
+		xlrec, err := readStruct[XlHashVacuumOnePage](record, data, SizeofXlHashVacuumOnePage())
+		if err != nil {
+			return nil, err
+		}
+		if record.Version < PG16 {
+			// an int ntuples up to PostgreSQL 15
+			ntuples, err := readStruct[int32](record, data[4:], 4)
+			if err != nil {
+				return nil, err
+			}
+			if *ntuples < 0 || *ntuples > 0xFFFF {
+				return nil, record.errorf("invalid ntuples %d", *ntuples)
+			}
+			xlrec.Ntuples = uint16(*ntuples)
+			xlrec.IsCatalogRel = false
+		}
+		offsets, err := readArray[OffsetNumber](record, data[SizeofXlHashVacuumOnePage():], int(xlrec.Ntuples))
+		if err != nil {
+			return nil, err
+		}
+		return &HashVacuumOnePage{XlHashVacuumOnePage: *xlrec, Offsets: offsets}, nil
+	}
+	return nil, record.unknownInfo()
+}
+
+// readBlockUint32 decodes the uint32 registered with block reference id, nil
+// when there is none.
+func readBlockUint32(record *Record, id uint8) (*uint32, error) {
+	data := record.BlockData(id)
+	if data == nil {
+		return nil, nil
+	}
+	return readStruct[uint32](record, data, 4)
+}
+
+// readHashMovedTuples decodes the offsets and the ntups tuples following them
+// in the data of block reference id.
+func readHashMovedTuples(record *Record, id uint8, ntups int) ([]OffsetNumber, [][]byte, error) {
+	data := record.BlockData(id)
+	if data == nil {
+		return nil, nil, nil
+	}
+	offsets, data, err := readOffsets(record, data, ntups)
+	if err != nil {
+		return nil, nil, err
+	}
+	tuples := make([][]byte, 0, ntups)
+	for i := 0; i < ntups; i++ {
+		var tuple []byte
+		if tuple, data, err = readIndexTuple(record, data, true); err != nil {
+			return nil, nil, err
+		}
+		tuples = append(tuples, tuple)
+	}
+	return offsets, tuples, nil
+}
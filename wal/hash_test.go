@@ -0,0 +1,81 @@
+package wal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeHash(t *testing.T) {
+	t.Run("add ovfl page", func(t *testing.T) {
+		record := testRecord(t, RM_HASH_ID, XLOG_HASH_ADD_OVFL_PAGE, le(uint16(4096), uint8(1)),
+			le(uint32(3)), nil, le(uint32(17)), nil, le(uint32(18)))
+		decoded, err := DecodeRmgrData(record)
+		require.NoError(t, err)
+		add := decoded.(*HashAddOvflPage)
+		assert.True(t, add.BmpageFound)
+		assert.Equal(t, uint32(3), *add.Bucket)
+		assert.Equal(t, uint32(17), *add.BitmapPageBit)
+		assert.Equal(t, uint32(18), *add.Firstfree)
+	})
+	t.Run("split allocate page", func(t *testing.T) {
+		flags := uint8(XLH_SPLIT_META_UPDATE_MASKS | XLH_SPLIT_META_UPDATE_SPLITPOINT)
+		record := testRecord(t, RM_HASH_ID, XLOG_HASH_SPLIT_ALLOCATE_PAGE,
+			le(uint32(5), uint16(1), uint16(2), flags),
+			nil, nil, le(uint32(3), uint32(7), uint32(9), uint32(2)))
+		decoded, err := DecodeRmgrData(record)
+		require.NoError(t, err)
+		split := decoded.(*HashSplitAllocatePage)
+		assert.Equal(t, uint32(5), split.NewBucket)
+		assert.Equal(t, uint32(7), split.Highmask)
+		assert.Equal(t, uint32(9), split.Ovflpoint)
+		assert.Equal(t, uint32(2), split.Ovflpages)
+	})
+	t.Run("squeeze page", func(t *testing.T) {
+		record := testRecord(t, RM_HASH_ID, XLOG_HASH_SQUEEZE_PAGE,
+			le(uint32(1), uint32(3), uint16(2), uint8(0), uint8(0)),
+			nil, le(uint16(4), uint16(5), indexTuple(1), indexTuple(2)), nil, nil, nil, le(uint32(6)))
+		decoded, err := DecodeRmgrData(record)
+		require.NoError(t, err)
+		squeeze := decoded.(*HashSqueezePage)
+		assert.Equal(t, []OffsetNumber{4, 5}, squeeze.Offsets)
+		assert.Equal(t, [][]byte{indexTuple(1), indexTuple(2)}, squeeze.Tuples)
+		assert.Equal(t, uint32(6), *squeeze.BitmapPageBit)
+		assert.Nil(t, squeeze.Firstfree)
+	})
+	t.Run("delete", func(t *testing.T) {
+		record := testRecord(t, RM_HASH_ID, XLOG_HASH_DELETE, le(uint8(1), uint8(0)),
+			nil, le(uint16(2), uint16(3)))
+		decoded, err := DecodeRmgrData(record)
+		require.NoError(t, err)
+		assert.Equal(t, []OffsetNumber{2, 3}, decoded.(*HashDelete).Deleted)
+	})
+	t.Run("vacuum one page", func(t *testing.T) {
+		record := testRecord(t, RM_HASH_ID, XLOG_HASH_VACUUM_ONE_PAGE,
+			le(uint32(700), uint16(1), uint8(1), uint8(0), uint16(4)), nil, nil)
+		decoded, err := DecodeRmgrData(record)
+		require.NoError(t, err)
+		vacuum := decoded.(*HashVacuumOnePage)
+		assert.True(t, vacuum.IsCatalogRel)
+		assert.Equal(t, []OffsetNumber{4}, vacuum.Offsets)
+
+		record = versioned(testRecord(t, RM_HASH_ID, XLOG_HASH_VACUUM_ONE_PAGE,
+			le(uint32(700), uint32(2), uint16(4), uint16(6)), nil, nil), PG15)
+		decoded, err = DecodeRmgrData(record)
+		require.NoError(t, err)
+		vacuum = decoded.(*HashVacuumOnePage)
+		assert.False(t, vacuum.IsCatalogRel)
+		assert.Equal(t, []OffsetNumber{4, 6}, vacuum.Offsets)
+	})
+	t.Run("init meta page", func(t *testing.T) {
+		record := testRecord(t, RM_HASH_ID, XLOG_HASH_INIT_META_PAGE,
+			le(uint64(0x4059000000000000), uint32(450), uint16(307)), nil)
+		decoded, err := DecodeRmgrData(record)
+		require.NoError(t, err)
+		meta := decoded.(*HashInitMetaPage)
+		assert.Equal(t, 100.0, meta.NumTuples)
+		assert.Equal(t, Oid(450), meta.Procid)
+		assert.EqualValues(t, 307, meta.Ffactor)
+	})
+}
@@ -0,0 +1,381 @@
+package wal
+
+const (
+	/*
+	 * WAL record definitions for heapam.c's WAL operations
+	 *
+	 * XLOG allows to store some information in high 4 bits of log
+	 * record xl_info field.  We use 3 for opcode and one for init bit.
+	 */
+	XLOG_HEAP_INSERT     = 0x00
+	XLOG_HEAP_DELETE     = 0x10
+	XLOG_HEAP_UPDATE     = 0x20
+	XLOG_HEAP_TRUNCATE   = 0x30
+	XLOG_HEAP_HOT_UPDATE = 0x40
+	XLOG_HEAP_CONFIRM    = 0x50
+	XLOG_HEAP_LOCK       = 0x60
+	XLOG_HEAP_INPLACE    = 0x70
+
+	XLOG_HEAP_OPMASK = 0x70
+	/*
+	 * When we insert 1st item on new page in INSERT, UPDATE, HOT_UPDATE,
+	 * or MULTI_INSERT, we can (and we do) restore entire page in redo
+	 */
+	XLOG_HEAP_INIT_PAGE = 0x80
+)
+
+const (
+	/*
+	 * xl_heap_insert/xl_heap_multi_insert flag values, 8 bits are available.
+	 */
+	/* PD_ALL_VISIBLE was cleared */
+	XLH_INSERT_ALL_VISIBLE_CLEARED = 1 << 0
+	XLH_INSERT_LAST_IN_MULTI       = 1 << 1
+	XLH_INSERT_IS_SPECULATIVE      = 1 << 2
+	XLH_INSERT_CONTAINS_NEW_TUPLE  = 1 << 3
+	/* Since PostgreSQL 13 */
+	XLH_INSERT_ON_TOAST_RELATION = 1 << 4
+	/* all_frozen_set always implies all_visible_set, since PostgreSQL 14 */
+	XLH_INSERT_ALL_FROZEN_SET = 1 << 5
+
+	/*
+	 * xl_heap_update flag values, 8 bits are available.
+	 */
+	/* PD_ALL_VISIBLE was cleared */
+	XLH_UPDATE_OLD_ALL_VISIBLE_CLEARED = 1 << 0
+	/* PD_ALL_VISIBLE was cleared in the 2nd page */
+	XLH_UPDATE_NEW_ALL_VISIBLE_CLEARED = 1 << 1
+	XLH_UPDATE_CONTAINS_OLD_TUPLE      = 1 << 2
+	XLH_UPDATE_CONTAINS_OLD_KEY        = 1 << 3
+	XLH_UPDATE_CONTAINS_NEW_TUPLE      = 1 << 4
+	XLH_UPDATE_PREFIX_FROM_OLD         = 1 << 5
+	XLH_UPDATE_SUFFIX_FROM_OLD         = 1 << 6
+
+	/* convenience macro for checking whether any form of old tuple was logged */
+	XLH_UPDATE_CONTAINS_OLD = XLH_UPDATE_CONTAINS_OLD_TUPLE | XLH_UPDATE_CONTAINS_OLD_KEY
+
+	/*
+	 * xl_heap_delete flag values, 8 bits are available.
+	 */
+	/* PD_ALL_VISIBLE was cleared */
+	XLH_DELETE_ALL_VISIBLE_CLEARED = 1 << 0
+	XLH_DELETE_CONTAINS_OLD_TUPLE  = 1 << 1
+	XLH_DELETE_CONTAINS_OLD_KEY    = 1 << 2
+	XLH_DELETE_IS_SUPER            = 1 << 3
+	XLH_DELETE_IS_PARTITION_MOVE   = 1 << 4
+
+	/* convenience macro for checking whether any form of old tuple was logged */
+	XLH_DELETE_CONTAINS_OLD = XLH_DELETE_CONTAINS_OLD_TUPLE | XLH_DELETE_CONTAINS_OLD_KEY
+
+	/*
+	 * xl_heap_truncate flag values, 8 bits are available.
+	 */
+	XLH_TRUNCATE_CASCADE      = 1 << 0
+	XLH_TRUNCATE_RESTART_SEQS = 1 << 1
+
+	/* flags for infobits_set */
+	XLHL_XMAX_IS_MULTI    = 0x01
+	XLHL_XMAX_LOCK_ONLY   = 0x02
+	XLHL_XMAX_EXCL_LOCK   = 0x04
+	XLHL_XMAX_KEYSHR_LOCK = 0x08
+	XLHL_KEYS_UPDATED     = 0x10
+
+	/* flag bits for xl_heap_lock / xl_heap_lock_updated's flag field */
+	XLH_LOCK_ALL_FROZEN_CLEARED = 0x01
+)
+
+/*
+ * We don't store the whole fixed part (HeapTupleHeaderData) of an inserted
+ * or updated tuple in WAL; we can save a few bytes by reconstructing the
+ * fields that are available elsewhere in the WAL record, or perhaps just
+ * plain needn't be reconstructed.  These are the fields we must store.
+ */
+type XlHeapHeader struct {
+	TInfomask2 uint16
+	TInfomask  uint16
+	THoff      uint8
+}
+
+func SizeofXlHeapHeader() int64 {
+	return 5
+}
+
+/* This is what we need to know about insert */
+type XlHeapInsert struct {
+	Offnum OffsetNumber /* inserted tuple's offset */
+	Flags  uint8
+
+	/* xl_heap_header & TUPLE DATA in backup block 0 */
+}
+
+func SizeofXlHeapInsert() int64 {
+	return 3
+}
+
+/* This is what we need to know about delete */
+type XlHeapDelete struct {
+	Xmax        TransactionId /* xmax of the deleted tuple */
+	Offnum      OffsetNumber  /* deleted tuple's offset */
+	InfobitsSet uint8         /* infomask bits */
+	Flags       uint8
+}
+
+func SizeofXlHeapDelete() int64 {
+	return 8
+}
+
+/*
+ * This is what we need to know about update|hot_update
+ *
+ * Backup blk 0: new page
+ *
+ * If XLH_UPDATE_PREFIX_FROM_OLD or XLH_UPDATE_SUFFIX_FROM_OLD flags are set,
+ * the prefix and/or suffix come first, as one or two uint16s.
+ *
+ * After that, xl_heap_header and new tuple data follow.  The new tuple
+ * data doesn't include the prefix and suffix, which are copied from the
+ * old tuple on replay.
+ *
+ * If XLH_UPDATE_CONTAINS_NEW_TUPLE flag is given, the tuple data is
+ * included even if a full-page image was taken.
+ *
+ * Backup blk 1: old page, if different. (no data, just a reference to the blk)
+ */
+type XlHeapUpdate struct {
+	OldXmax        TransactionId /* xmax of the old tuple */
+	OldOffnum      OffsetNumber  /* old tuple's offset */
+	OldInfobitsSet uint8         /* infomask bits to set on old tuple */
+	Flags          uint8
+	NewXmax        TransactionId /* xmax of the new tuple */
+	NewOffnum      OffsetNumber  /* new tuple's offset */
+
+	/*
+	 * If XLH_UPDATE_CONTAINS_OLD_TUPLE or XLH_UPDATE_CONTAINS_OLD_KEY flags
+	 * are set, xl_heap_header and tuple data for the old tuple follow.
+	 */
+}
+
+func SizeofXlHeapUpdate() int64 {
+	return 14
+}
+
+/*
+ * For truncate we list all truncated relids in an array, followed by all
+ * sequence relids that need to be restarted, if any.
+ * All rels are always within the same database, so we just list dbid once.
+ */
+type XlHeapTruncate struct {
+	DbId    Oid
+	Nrelids uint32
+	Flags   uint8
+}
+
+func SizeofXlHeapTruncate() int64 {
+	return 12
+}
+
+/*
+ * This is what we need to know about confirmation of speculative insertion
+ *
+ * Backup blk 0: page containing tuple
+ */
+type XlHeapConfirm struct {
+	Offnum OffsetNumber /* confirmed tuple's offset on page */
+}
+
+func SizeofXlHeapConfirm() int64 {
+	return 2
+}
+
+/* This is what we need to know about lock */
+type XlHeapLock struct {
+	Xmax        TransactionId /* might be a MultiXactId */
+	Offnum      OffsetNumber  /* locked tuple's offset on page */
+	InfobitsSet uint8         /* infomask and infomask2 bits to set */
+	Flags       uint8         /* XLH_LOCK_* flag bits */
+}
+
+func SizeofXlHeapLock() int64 {
+	return 8
+}
+
+/* This is what we need to know about in-place update */
+type XlHeapInplace struct {
+	Offnum OffsetNumber /* updated tuple's offset on page */
+	/* TUPLE DATA FOLLOWS AT END OF STRUCT */
+}
+
+func SizeofXlHeapInplace() int64 {
+	return 2
+}
+
+// HeapTuple is a tuple logged without the fixed part of its header.
+type HeapTuple struct {
+	Header XlHeapHeader
+	Data   []byte // the tuple from offsetof(HeapTupleHeaderData, t_bits)
+}
+
+type HeapInsert struct {
+	XlHeapInsert
+	InitPage bool
+	Tuple    *HeapTuple // nil when only a full-page image was logged
+}
+
+type HeapDelete struct {
+	XlHeapDelete
+	OldTuple *HeapTuple // the old tuple or key under wal_level=logical
+}
+
+// HeapUpdate decodes XLOG_HEAP_UPDATE and XLOG_HEAP_HOT_UPDATE.
+type HeapUpdate struct {
+	XlHeapUpdate
+	Hot       bool
+	InitPage  bool
+	PrefixLen uint16     // bytes of the old tuple preceding NewTuple.Data
+	SuffixLen uint16     // bytes of the old tuple following NewTuple.Data
+	NewTuple  *HeapTuple // nil when only a full-page image was logged
+	OldTuple  *HeapTuple // the old tuple or key under wal_level=logical
+}
+
+type HeapTruncate struct {
+	XlHeapTruncate
+	Relids []Oid
+}
+
+type HeapConfirm struct {
+	XlHeapConfirm
+}
+
+type HeapLock struct {
+	XlHeapLock
+}
+
+type HeapInplace struct {
+	XlHeapInplace
+	Tuple []byte // nil when only a full-page image was logged
+}
+
+// DecodeHeap decodes the records of RM_HEAP_ID into the struct of their info
+// code, e.g. *HeapInsert.
+func DecodeHeap(record *Record) (interface{}, error) {
+	data := record.MainData
+	switch record.Info() & XLOG_HEAP_OPMASK {
+	case XLOG_HEAP_INSERT:
+		xlrec, err := readStruct[XlHeapInsert](record, data, SizeofXlHeapInsert())
+		if err != nil {
+			return nil, err
+		}
+		tuple, err := readHeapTuple(record, record.BlockData(0))
+		if err != nil {
+			return nil, err
+		}
+		return &HeapInsert{
+			XlHeapInsert: *xlrec,
+			InitPage:     record.Info()&XLOG_HEAP_INIT_PAGE != 0,
+			Tuple:        tuple,
+		}, nil
+	case XLOG_HEAP_DELETE:
+		xlrec, err := readStruct[XlHeapDelete](record, data, SizeofXlHeapDelete())
+		if err != nil {
+			return nil, err
+		}
+		ret := &HeapDelete{XlHeapDelete: *xlrec}
+		if xlrec.Flags&XLH_DELETE_CONTAINS_OLD != 0 {
+			ret.OldTuple, err = readHeapTuple(record, data[SizeofXlHeapDelete():])
+			if err != nil {
+				return nil, err
+			}
+		}
+		return ret, nil
+	case XLOG_HEAP_UPDATE, XLOG_HEAP_HOT_UPDATE:
+		return decodeHeapUpdate(record)
+	case XLOG_HEAP_TRUNCATE:
+		xlrec, err := readStruct[XlHeapTruncate](record, data, SizeofXlHeapTruncate())
+		if err != nil {
+			return nil, err
+		}
+		relids, err := readArray[Oid](record, data[SizeofXlHeapTruncate():], int(xlrec.Nrelids))
+		if err != nil {
+			return nil, err
+		}
+		return &HeapTruncate{XlHeapTruncate: *xlrec, Relids: relids}, nil
+	case XLOG_HEAP_CONFIRM:
+		xlrec, err := readStruct[XlHeapConfirm](record, data, SizeofXlHeapConfirm())
+		if err != nil {
+			return nil, err
+		}
+		return &HeapConfirm{XlHeapConfirm: *xlrec}, nil
+	case XLOG_HEAP_LOCK:
+		xlrec, err := readStruct[XlHeapLock](record, data, SizeofXlHeapLock())
+		if err != nil {
+			return nil, err
+		}
+		return &HeapLock{XlHeapLock: *xlrec}, nil
+	case XLOG_HEAP_INPLACE:
+		xlrec, err := readStruct[XlHeapInplace](record, data, SizeofXlHeapInplace())
+		if err != nil {
+			return nil, err
+		}
+		return &HeapInplace{XlHeapInplace: *xlrec, Tuple: record.BlockData(0)}, nil
+	}
+	return nil, record.unknownInfo()
+}
+
+func decodeHeapUpdate(record *Record) (*HeapUpdate, error) {
+	data := record.MainData
+	xlrec, err := readStruct[XlHeapUpdate](record, data, SizeofXlHeapUpdate())
+	if err != nil {
+		return nil, err
+	}
+	ret := &HeapUpdate{
+		XlHeapUpdate: *xlrec,
+		Hot:          record.Info()&XLOG_HEAP_OPMASK == XLOG_HEAP_HOT_UPDATE,
+		InitPage:     record.Info()&XLOG_HEAP_INIT_PAGE != 0,
+	}
+	if xlrec.Flags&XLH_UPDATE_CONTAINS_OLD != 0 {
+		ret.OldTuple, err = readHeapTuple(record, data[SizeofXlHeapUpdate():])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	blockData := record.BlockData(0)
+	if blockData == nil {
+		return ret, nil
+	}
+	for _, item := range []struct {
+		flag uint8
+		len  *uint16
+	}{
+		{XLH_UPDATE_PREFIX_FROM_OLD, &ret.PrefixLen},
+		{XLH_UPDATE_SUFFIX_FROM_OLD, &ret.SuffixLen},
+	} {
+		if xlrec.Flags&item.flag == 0 {
+			continue
+		}
+		length, err := readStruct[uint16](record, blockData, 2)
+		if err != nil {
+			return nil, err
+		}
+		*item.len = *length
+		blockData = blockData[2:]
+	}
+	ret.NewTuple, err = readHeapTuple(record, blockData)
+	if err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// readHeapTuple decodes an xl_heap_header followed by the tuple data, nil
+// data being a tuple which was not logged.
+func readHeapTuple(record *Record, data []byte) (*HeapTuple, error) {
+	if data == nil {
+		return nil, nil
+	}
+	hdr, err := readStruct[XlHeapHeader](record, data, SizeofXlHeapHeader())
+	if err != nil {
+		return nil, err
+	}
+	return &HeapTuple{Header: *hdr, Data: data[SizeofXlHeapHeader():]}, nil
+}
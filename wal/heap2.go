@@ -0,0 +1,567 @@
+package wal
+
+const (
+	/*
+	 * We ran out of opcodes, so heapam.c now has a second RmgrId.  These opcodes
+	 * are associated with RM_HEAP2_ID, but are not logically different from
+	 * the ones above associated with RM_HEAP_ID.  XLOG_HEAP_OPMASK applies to
+	 * these, too.
+	 */
+	XLOG_HEAP2_REWRITE      = 0x00
+	XLOG_HEAP2_PRUNE        = 0x10
+	XLOG_HEAP2_VACUUM       = 0x20
+	XLOG_HEAP2_FREEZE_PAGE  = 0x30
+	XLOG_HEAP2_VISIBLE      = 0x40
+	XLOG_HEAP2_MULTI_INSERT = 0x50
+	XLOG_HEAP2_LOCK_UPDATED = 0x60
+	XLOG_HEAP2_NEW_CID      = 0x70
+
+	/* Up to PostgreSQL 13 */
+	XLOG_HEAP2_CLEAN             = 0x10
+	XLOG_HEAP2_FREEZE_PAGE_PRE14 = 0x20
+	XLOG_HEAP2_CLEANUP_INFO      = 0x30
+
+	/* Since PostgreSQL 17, replacing PRUNE, VACUUM and FREEZE_PAGE */
+	XLOG_HEAP2_PRUNE_ON_ACCESS      = 0x10
+	XLOG_HEAP2_PRUNE_VACUUM_SCAN    = 0x20
+	XLOG_HEAP2_PRUNE_VACUUM_CLEANUP = 0x30
+)
+
+const (
+	/* flags for xl_heap_prune, since PostgreSQL 17 */
+	XLHP_IS_CATALOG_REL       = 1 << 1
+	XLHP_CLEANUP_LOCK         = 1 << 2
+	XLHP_HAS_CONFLICT_HORIZON = 1 << 3
+	XLHP_HAS_FREEZE_PLANS     = 1 << 4
+	XLHP_HAS_REDIRECTIONS     = 1 << 5
+	XLHP_HAS_DEAD_ITEMS       = 1 << 6
+	XLHP_HAS_NOW_UNUSED_ITEMS = 1 << 7
+
+	/* 0x01 was XLH_FREEZE_XMIN */
+	XLH_FREEZE_XVAC  = 0x02
+	XLH_INVALID_XVAC = 0x04
+
+	/* flags of xl_heap_visible */
+	VISIBILITYMAP_ALL_VISIBLE = 0x01
+	VISIBILITYMAP_ALL_FROZEN  = 0x02
+	/* Since PostgreSQL 16 */
+	VISIBILITYMAP_XLOG_CATALOG_REL = 0x04
+)
+
+/*
+ * xl_heap_prune up to PostgreSQL 16, xl_heap_clean up to PostgreSQL 13.
+ *
+ * Note that the payload of the record, the OffsetNumber arrays, is in the
+ * block data of block 0: redirected pairs, then dead, then unused items.
+ */
+type XlHeapPrune struct {
+	SnapshotConflictHorizon TransactionId /* latestRemovedXid before 16 */
+	Nredirected             uint16
+	Ndead                   uint16
+	IsCatalogRel            bool /* Since PostgreSQL 16 */
+}
+
+func SizeofXlHeapPrune(v PgVersion) int64 {
+	if v >= PG16 {
+		return 9
+	}
+	return 8
+}
+
+/*
+ * xl_heap_prune since PostgreSQL 17, the snapshotConflictHorizon follows if
+ * XLHP_HAS_CONFLICT_HORIZON is set.
+ */
+type XlHeapPrune17 struct {
+	Reason uint8
+	Flags  uint8
+}
+
+func SizeofXlHeapPrune17() int64 {
+	return 2
+}
+
+/*
+ * The vacuum page record is similar to the prune record, but can only mark
+ * already LP_DEAD items LP_UNUSED (during VACUUM's second heap pass), from
+ * PostgreSQL 14 to 16.
+ */
+type XlHeapVacuum struct {
+	Nunused uint16
+	/* OFFSET NUMBERS are in the block reference 0 */
+}
+
+func SizeofXlHeapVacuum() int64 {
+	return 2
+}
+
+/*
+ * Cleanup_info is required in some cases during a lazy VACUUM, up to
+ * PostgreSQL 13.
+ */
+type XlHeapCleanupInfo struct {
+	Node             RelFileNode
+	LatestRemovedXid TransactionId
+}
+
+func SizeofXlHeapCleanupInfo() int64 {
+	return 16
+}
+
+/*
+ * This is what we need to know about a block being frozen during vacuum
+ *
+ * The freeze plans, or the frozen tuples before PostgreSQL 16, are in the
+ * block data of block 0.
+ */
+type XlHeapFreezePage struct {
+	SnapshotConflictHorizon TransactionId /* cutoff_xid before 16 */
+	Nplans                  uint16        /* ntuples before 16 */
+	IsCatalogRel            bool          /* Since PostgreSQL 16 */
+}
+
+func SizeofXlHeapFreezePage(v PgVersion) int64 {
+	if v >= PG16 {
+		return 7
+	}
+	return 6
+}
+
+/*
+ * This struct represents a 'freeze plan', which describes how to freeze a
+ * group of one or more heap tuples, since PostgreSQL 16.
+ */
+type XlHeapFreezePlan struct {
+	Xmax       TransactionId
+	TInfomask2 uint16
+	TInfomask  uint16
+	Frzflags   uint8
+
+	/* Length of individual page offset numbers array for this plan */
+	Ntuples uint16
+}
+
+/*
+ * This struct represents a frozen tuple before PostgreSQL 16.
+ */
+type XlHeapFreezeTuple struct {
+	Xmax       TransactionId
+	Offset     OffsetNumber
+	TInfomask2 uint16
+	TInfomask  uint16
+	Frzflags   uint8
+}
+
+/*
+ * This is what we need to know about setting a visibility map bit
+ *
+ * Backup blk 0: visibility map buffer
+ * Backup blk 1: heap buffer
+ */
+type XlHeapVisible struct {
+	SnapshotConflictHorizon TransactionId /* cutoff_xid before 16 */
+	Flags                   uint8
+}
+
+func SizeofXlHeapVisible() int64 {
+	return 5
+}
+
+/*
+ * This is what we need to know about a multi-insert.
+ *
+ * The main data of the record consists of this xl_heap_multi_insert header.
+ * 'offsets' array is omitted if the whole page is reinitialized
+ * (XLOG_HEAP_INIT_PAGE).
+ *
+ * In block 0's data portion, there is an xl_multi_insert_tuple struct,
+ * followed by the tuple data for each tuple. There is padding to align
+ * each xl_multi_insert_tuple struct.
+ */
+type XlHeapMultiInsert struct {
+	Flags   uint8
+	Ntuples uint16
+	/* TUPLE DATA FOLLOW AT END OF STRUCT */
+}
+
+func SizeofXlHeapMultiInsert() int64 {
+	return 4
+}
+
+type XlMultiInsertTuple struct {
+	Datalen    uint16 /* size of tuple data that follows */
+	TInfomask2 uint16
+	TInfomask  uint16
+	THoff      uint8
+	/* TUPLE DATA FOLLOWS AT END OF STRUCT */
+}
+
+func SizeofXlMultiInsertTuple() int64 {
+	return 7
+}
+
+/* This is what we need to know about lock_updated */
+type XlHeapLockUpdated struct {
+	Xmax        TransactionId
+	Offnum      OffsetNumber
+	InfobitsSet uint8
+	Flags       uint8
+}
+
+func SizeofXlHeapLockUpdated() int64 {
+	return 8
+}
+
+type CommandId uint32
+
+type ItemPointerData struct {
+	BiHi  uint16
+	BiLo  uint16
+	Posid OffsetNumber
+}
+
+// BlockNumber returns the block the item pointer points into.
+func (p ItemPointerData) BlockNumber() BlockNumber {
+	return BlockNumber(p.BiHi)<<16 | BlockNumber(p.BiLo)
+}
+
+/* This is what we need to know about a newly created cid */
+type XlHeapNewCid struct {
+	/*
+	 * store toplevel xid so we don't have to merge cids from different
+	 * transactions
+	 */
+	TopXid   TransactionId
+	Cmin     CommandId
+	Cmax     CommandId
+	Combocid CommandId /* just for debugging */
+
+	/*
+	 * Store the relfilenode/ctid pair to facilitate lookups.
+	 */
+	TargetNode RelFileNode
+	TargetTid  ItemPointerData
+}
+
+func SizeofXlHeapNewCid() int64 {
+	return 34
+}
+
+/* logical rewrite xlog record header */
+type XlHeapRewriteMapping struct {
+	MappedXid   TransactionId /* xid that might need to see the row */
+	MappedDb    Oid           /* DbOid or InvalidOid for shared rels */
+	MappedRel   Oid           /* Oid of the mapped relation */
+	Offset      int64         /* How far have we written so far */
+	NumMappings uint32        /* Number of in-memory mappings */
+	StartLsn    XLogRecPtr    /* Insert LSN at begin of rewrite */
+}
+
+func SizeofXlHeapRewriteMapping() int64 {
+	return 40
+}
+
+type LogicalRewriteMappingData struct {
+	OldNode RelFileNode
+	NewNode RelFileNode
+	OldTid  ItemPointerData
+	NewTid  ItemPointerData
+}
+
+// HeapPrune decodes the pruning records: XLOG_HEAP2_PRUNE_* since
+// PostgreSQL 17, XLOG_HEAP2_PRUNE up to 16 and XLOG_HEAP2_CLEAN up to 13.
+type HeapPrune struct {
+	XlHeapPrune
+	XlHeapPrune17
+	Redirected []OffsetNumber // pairs of redirected item and its target
+	Nowdead    []OffsetNumber
+	Nowunused  []OffsetNumber
+	Plans      []XlHeapFreezePlan
+	Frozen     []OffsetNumber // the items the plans apply to, in order
+}
+
+type HeapVacuum struct {
+	XlHeapVacuum
+	Nowunused []OffsetNumber
+}
+
+type HeapCleanupInfo struct {
+	XlHeapCleanupInfo
+}
+
+type HeapFreezePage struct {
+	XlHeapFreezePage
+	Plans   []XlHeapFreezePlan  // since PostgreSQL 16
+	Offsets []OffsetNumber      // the items the plans apply to, in order
+	Tuples  []XlHeapFreezeTuple // before PostgreSQL 16
+}
+
+type HeapVisible struct {
+	XlHeapVisible
+}
+
+type HeapMultiInsertTuple struct {
+	Header XlMultiInsertTuple
+	Data   []byte
+}
+
+type HeapMultiInsert struct {
+	XlHeapMultiInsert
+	InitPage bool
+	Offsets  []OffsetNumber // nil under InitPage, the tuples fill up the page
+	Tuples   []HeapMultiInsertTuple
+}
+
+type HeapLockUpdated struct {
+	XlHeapLockUpdated
+}
+
+type HeapNewCid struct {
+	XlHeapNewCid
+}
+
+type HeapRewriteMapping struct {
+	XlHeapRewriteMapping
+	Mappings []LogicalRewriteMappingData
+}
+
+// DecodeHeap2 decodes the records of RM_HEAP2_ID into the struct of their
+// info code, e.g. *HeapPrune.
+func DecodeHeap2(record *Record) (interface{}, error) {
+	data := record.MainData
+	info := record.Info() & XLOG_HEAP_OPMASK
+	switch {
+	case info == XLOG_HEAP2_REWRITE:
+		xlrec, err := readStruct[XlHeapRewriteMapping](record, data, SizeofXlHeapRewriteMapping())
+		if err != nil {
+			return nil, err
+		}
+		mappings, err := readArray[LogicalRewriteMappingData](record, data[SizeofXlHeapRewriteMapping():], int(xlrec.NumMappings))
+		if err != nil {
+			return nil, err
+		}
+		return &HeapRewriteMapping{XlHeapRewriteMapping: *xlrec, Mappings: mappings}, nil
+	case record.Version >= PG17 && (info == XLOG_HEAP2_PRUNE_ON_ACCESS || info == XLOG_HEAP2_PRUNE_VACUUM_SCAN || info == XLOG_HEAP2_PRUNE_VACUUM_CLEANUP):
+		return decodeHeapPrune17(record)
+	case info == XLOG_HEAP2_PRUNE:
+		return decodeHeapPrune(record)
+	case record.Version < PG14 && info == XLOG_HEAP2_CLEANUP_INFO:
+		xlrec, err := readStruct[XlHeapCleanupInfo](record, data, SizeofXlHeapCleanupInfo())
+		if err != nil {
+			return nil, err
+		}
+		return &HeapCleanupInfo{XlHeapCleanupInfo: *xlrec}, nil
+	case record.Version < PG14 && info == XLOG_HEAP2_FREEZE_PAGE_PRE14,
+		record.Version >= PG14 && info == XLOG_HEAP2_FREEZE_PAGE:
+		return decodeHeapFreezePage(record)
+	case info == XLOG_HEAP2_VACUUM:
+		xlrec, err := readStruct[XlHeapVacuum](record, data, SizeofXlHeapVacuum())
+		if err != nil {
+			return nil, err
+		}
+		ret := &HeapVacuum{XlHeapVacuum: *xlrec}
+		if blockData := record.BlockData(0); blockData != nil {
+			ret.Nowunused, err = readArray[OffsetNumber](record, blockData, int(xlrec.Nunused))
+			if err != nil {
+				return nil, err
+			}
+		}
+		return ret, nil
+	case info == XLOG_HEAP2_VISIBLE:
+		xlrec, err := readStruct[XlHeapVisible](record, data, SizeofXlHeapVisible())
+		if err != nil {
+			return nil, err
+		}
+		return &HeapVisible{XlHeapVisible: *xlrec}, nil
+	case info == XLOG_HEAP2_MULTI_INSERT:
+		return decodeHeapMultiInsert(record)
+	case info == XLOG_HEAP2_LOCK_UPDATED:
+		xlrec, err := readStruct[XlHeapLockUpdated](record, data, SizeofXlHeapLockUpdated())
+		if err != nil {
+			return nil, err
+		}
+		return &HeapLockUpdated{XlHeapLockUpdated: *xlrec}, nil
+	case info == XLOG_HEAP2_NEW_CID:
+		xlrec, err := readStruct[XlHeapNewCid](record, data, SizeofXlHeapNewCid())
+		if err != nil {
+			return nil, err
+		}
+		return &HeapNewCid{XlHeapNewCid: *xlrec}, nil
+	}
+	return nil, record.unknownInfo()
+}
+
+func decodeHeapPrune(record *Record) (*HeapPrune, error) {
+	xlrec, err := readStruct[XlHeapPrune](record, record.MainData, SizeofXlHeapPrune(record.Version))
+	if err != nil {
+		return nil, err
+	}
+	ret := &HeapPrune{XlHeapPrune: *xlrec}
+	blockData := record.BlockData(0)
+	if blockData == nil {
+		return ret, nil
+	}
+	ret.Redirected, err = readArray[OffsetNumber](record, blockData, 2*int(xlrec.Nredirected))
+	if err != nil {
+		return nil, err
+	}
+	blockData = blockData[4*int(xlrec.Nredirected):]
+	ret.Nowdead, err = readArray[OffsetNumber](record, blockData, int(xlrec.Ndead))
+	if err != nil {
+		return nil, err
+	}
+	blockData = blockData[2*int(xlrec.Ndead):]
+	ret.Nowunused, err = readArray[OffsetNumber](record, blockData, len(blockData)/2)
+	if err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// decodeHeapPrune17 decodes the pruning records of PostgreSQL 17, like
+// heap_xlog_deserialize_prune_and_freeze.
+func decodeHeapPrune17(record *Record) (*HeapPrune, error) {
+	data := record.MainData
+	xlrec, err := readStruct[XlHeapPrune17](record, data, SizeofXlHeapPrune17())
+	if err != nil {
+		return nil, err
+	}
+	ret := &HeapPrune{XlHeapPrune17: *xlrec}
+	ret.IsCatalogRel = xlrec.Flags&XLHP_IS_CATALOG_REL != 0
+	if xlrec.Flags&XLHP_HAS_CONFLICT_HORIZON != 0 {
+		horizon, err := readStruct[TransactionId](record, data[SizeofXlHeapPrune17():], 4)
+		if err != nil {
+			return nil, err
+		}
+		ret.SnapshotConflictHorizon = *horizon
+	}
+
+	cursor := record.BlockData(0)
+	if cursor == nil {
+		return ret, nil
+	}
+	var nfrozen int
+	if xlrec.Flags&XLHP_HAS_FREEZE_PLANS != 0 {
+		nplans, err := readStruct[uint16](record, cursor, 2)
+		if err != nil {
+			return nil, err
+		}
+		// the plans are aligned on their xmax in xlhp_freeze_plans
+		if len(cursor) < 4 {
+			return nil, record.errorf("freeze plans past the end of block data")
+		}
+		cursor = cursor[4:]
+		ret.Plans, err = readArray[XlHeapFreezePlan](record, cursor, int(*nplans))
+		if err != nil {
+			return nil, err
+		}
+		cursor = cursor[12*int(*nplans):]
+		for _, plan := range ret.Plans {
+			nfrozen += int(plan.Ntuples)
+		}
+	}
+	for _, item := range []struct {
+		flag    uint8
+		items   *[]OffsetNumber
+		perItem int
+	}{
+		{XLHP_HAS_REDIRECTIONS, &ret.Redirected, 2},
+		{XLHP_HAS_DEAD_ITEMS, &ret.Nowdead, 1},
+		{XLHP_HAS_NOW_UNUSED_ITEMS, &ret.Nowunused, 1},
+	} {
+		if xlrec.Flags&item.flag == 0 {
+			continue
+		}
+		ntargets, err := readStruct[uint16](record, cursor, 2)
+		if err != nil {
+			return nil, err
+		}
+		n := int(*ntargets) * item.perItem
+		*item.items, err = readArray[OffsetNumber](record, cursor[2:], n)
+		if err != nil {
+			return nil, err
+		}
+		cursor = cursor[2+2*n:]
+	}
+	if nfrozen > 0 {
+		ret.Frozen, err = readArray[OffsetNumber](record, cursor, nfrozen)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return ret, nil
+}
+
+func decodeHeapFreezePage(record *Record) (*HeapFreezePage, error) {
+	xlrec, err := readStruct[XlHeapFreezePage](record, record.MainData, SizeofXlHeapFreezePage(record.Version))
+	if err != nil {
+		return nil, err
+	}
+	ret := &HeapFreezePage{XlHeapFreezePage: *xlrec}
+	blockData := record.BlockData(0)
+	if blockData == nil {
+		return ret, nil
+	}
+	if record.Version < PG16 {
+		ret.Tuples, err = readArray[XlHeapFreezeTuple](record, blockData, int(xlrec.Nplans))
+		if err != nil {
+			return nil, err
+		}
+		return ret, nil
+	}
+	ret.Plans, err = readArray[XlHeapFreezePlan](record, blockData, int(xlrec.Nplans))
+	if err != nil {
+		return nil, err
+	}
+	nfrozen := 0
+	for _, plan := range ret.Plans {
+		nfrozen += int(plan.Ntuples)
+	}
+	ret.Offsets, err = readArray[OffsetNumber](record, blockData[12*len(ret.Plans):], nfrozen)
+	if err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+func decodeHeapMultiInsert(record *Record) (*HeapMultiInsert, error) {
+	data := record.MainData
+	xlrec, err := readStruct[XlHeapMultiInsert](record, data, SizeofXlHeapMultiInsert())
+	if err != nil {
+		return nil, err
+	}
+	ret := &HeapMultiInsert{
+		XlHeapMultiInsert: *xlrec,
+		InitPage:          record.Info()&XLOG_HEAP_INIT_PAGE != 0,
+	}
+	if !ret.InitPage {
+		ret.Offsets, err = readArray[OffsetNumber](record, data[SizeofXlHeapMultiInsert():], int(xlrec.Ntuples))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	blockData := record.BlockData(0)
+	if blockData == nil {
+		return ret, nil
+	}
+	off := 0
+	for i := 0; i < int(xlrec.Ntuples); i++ {
+		// each xl_multi_insert_tuple is SHORTALIGNed
+		off += off & 1
+		if off > len(blockData) {
+			return nil, record.errorf("multi-insert tuple %d past the end of block data", i)
+		}
+		hdr, err := readStruct[XlMultiInsertTuple](record, blockData[off:], SizeofXlMultiInsertTuple())
+		if err != nil {
+			return nil, err
+		}
+		off += int(SizeofXlMultiInsertTuple())
+		if off+int(hdr.Datalen) > len(blockData) {
+			return nil, record.errorf("multi-insert tuple %d past the end of block data", i)
+		}
+		ret.Tuples = append(ret.Tuples, HeapMultiInsertTuple{Header: *hdr, Data: blockData[off : off+int(hdr.Datalen)]})
+		off += int(hdr.Datalen)
+	}
+	return ret, nil
+}
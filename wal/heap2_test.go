@@ -0,0 +1,134 @@
+package wal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// versioned sets the version of record, as testRecord writes PostgreSQL 16.
+func versioned(record *Record, v PgVersion) *Record {
+	record.Version = v
+	return record
+}
+
+func TestDecodeHeap2(t *testing.T) {
+	t.Run("prune", func(t *testing.T) {
+		record := testRecord(t, RM_HEAP2_ID, XLOG_HEAP2_PRUNE,
+			le(uint32(700), uint16(1), uint16(2), uint8(1)),
+			le(uint16(1), uint16(3), uint16(4), uint16(5), uint16(6), uint16(7)))
+		decoded, err := DecodeRmgrData(record)
+		require.NoError(t, err)
+		prune := decoded.(*HeapPrune)
+		assert.Equal(t, TransactionId(700), prune.SnapshotConflictHorizon)
+		assert.True(t, prune.IsCatalogRel)
+		assert.Equal(t, []OffsetNumber{1, 3}, prune.Redirected)
+		assert.Equal(t, []OffsetNumber{4, 5}, prune.Nowdead)
+		assert.Equal(t, []OffsetNumber{6, 7}, prune.Nowunused)
+	})
+	t.Run("clean", func(t *testing.T) {
+		record := versioned(testRecord(t, RM_HEAP2_ID, XLOG_HEAP2_CLEAN,
+			le(uint32(700), uint16(0), uint16(1)), le(uint16(4))), PG12)
+		decoded, err := DecodeRmgrData(record)
+		require.NoError(t, err)
+		prune := decoded.(*HeapPrune)
+		assert.False(t, prune.IsCatalogRel)
+		assert.Equal(t, []OffsetNumber{4}, prune.Nowdead)
+		assert.Empty(t, prune.Nowunused)
+	})
+	t.Run("prune 17", func(t *testing.T) {
+		flags := uint8(XLHP_HAS_CONFLICT_HORIZON | XLHP_HAS_FREEZE_PLANS | XLHP_HAS_REDIRECTIONS | XLHP_HAS_NOW_UNUSED_ITEMS)
+		record := versioned(testRecord(t, RM_HEAP2_ID, XLOG_HEAP2_PRUNE_VACUUM_SCAN,
+			le(uint8(1), flags, uint32(800)),
+			le(uint16(1), uint16(0),
+				uint32(0), uint16(0x0100), uint16(0x0300), uint8(0), uint8(0), uint16(2),
+				uint16(1), uint16(2), uint16(9),
+				uint16(1), uint16(12),
+				uint16(3), uint16(4))), PG17)
+		decoded, err := DecodeRmgrData(record)
+		require.NoError(t, err)
+		prune := decoded.(*HeapPrune)
+		assert.Equal(t, TransactionId(800), prune.SnapshotConflictHorizon)
+		assert.EqualValues(t, 1, prune.Reason)
+		require.Len(t, prune.Plans, 1)
+		assert.EqualValues(t, 2, prune.Plans[0].Ntuples)
+		assert.EqualValues(t, 0x0300, prune.Plans[0].TInfomask)
+		assert.Equal(t, []OffsetNumber{2, 9}, prune.Redirected)
+		assert.Empty(t, prune.Nowdead)
+		assert.Equal(t, []OffsetNumber{12}, prune.Nowunused)
+		assert.Equal(t, []OffsetNumber{3, 4}, prune.Frozen)
+	})
+	t.Run("vacuum", func(t *testing.T) {
+		record := versioned(testRecord(t, RM_HEAP2_ID, XLOG_HEAP2_VACUUM, le(uint16(2)), le(uint16(4), uint16(5))), PG15)
+		decoded, err := DecodeRmgrData(record)
+		require.NoError(t, err)
+		assert.Equal(t, []OffsetNumber{4, 5}, decoded.(*HeapVacuum).Nowunused)
+	})
+	t.Run("freeze page", func(t *testing.T) {
+		record := testRecord(t, RM_HEAP2_ID, XLOG_HEAP2_FREEZE_PAGE,
+			le(uint32(650), uint16(1), uint8(0)),
+			le(uint32(0), uint16(0), uint16(0x0300), uint8(0), uint8(0), uint16(2), uint16(7), uint16(8)))
+		decoded, err := DecodeRmgrData(record)
+		require.NoError(t, err)
+		freeze := decoded.(*HeapFreezePage)
+		assert.Equal(t, TransactionId(650), freeze.SnapshotConflictHorizon)
+		require.Len(t, freeze.Plans, 1)
+		assert.Equal(t, []OffsetNumber{7, 8}, freeze.Offsets)
+	})
+	t.Run("freeze page 13", func(t *testing.T) {
+		record := versioned(testRecord(t, RM_HEAP2_ID, XLOG_HEAP2_FREEZE_PAGE_PRE14,
+			le(uint32(650), uint16(1)),
+			le(uint32(0), uint16(7), uint16(0), uint16(0x0300), uint8(0), uint8(0))), PG13)
+		decoded, err := DecodeRmgrData(record)
+		require.NoError(t, err)
+		freeze := decoded.(*HeapFreezePage)
+		require.Len(t, freeze.Tuples, 1)
+		assert.Equal(t, OffsetNumber(7), freeze.Tuples[0].Offset)
+	})
+	t.Run("visible", func(t *testing.T) {
+		record := testRecord(t, RM_HEAP2_ID, XLOG_HEAP2_VISIBLE, le(uint32(650), uint8(VISIBILITYMAP_ALL_VISIBLE)), nil, nil)
+		decoded, err := DecodeRmgrData(record)
+		require.NoError(t, err)
+		assert.EqualValues(t, VISIBILITYMAP_ALL_VISIBLE, decoded.(*HeapVisible).Flags)
+	})
+	t.Run("multi insert", func(t *testing.T) {
+		record := testRecord(t, RM_HEAP2_ID, XLOG_HEAP2_MULTI_INSERT,
+			le(uint8(0), uint8(0), uint16(2), uint16(3), uint16(4)),
+			le(uint16(4), uint16(2), uint16(0x0800), uint8(24), []byte("abcd"), uint8(0),
+				uint16(2), uint16(2), uint16(0x0800), uint8(24), []byte("de")))
+		decoded, err := DecodeRmgrData(record)
+		require.NoError(t, err)
+		insert := decoded.(*HeapMultiInsert)
+		assert.Equal(t, []OffsetNumber{3, 4}, insert.Offsets)
+		require.Len(t, insert.Tuples, 2)
+		assert.Equal(t, []byte("abcd"), insert.Tuples[0].Data)
+		assert.Equal(t, []byte("de"), insert.Tuples[1].Data)
+	})
+	t.Run("new cid", func(t *testing.T) {
+		record := testRecord(t, RM_HEAP2_ID, XLOG_HEAP2_NEW_CID,
+			le(uint32(900), uint32(1), uint32(0xFFFFFFFF), uint32(0xFFFFFFFF),
+				uint32(1663), uint32(5), uint32(1259), uint16(0), uint16(3), uint16(12)))
+		decoded, err := DecodeRmgrData(record)
+		require.NoError(t, err)
+		cid := decoded.(*HeapNewCid)
+		assert.Equal(t, TransactionId(900), cid.TopXid)
+		assert.Equal(t, Oid(1259), cid.TargetNode.RelNode)
+		assert.Equal(t, BlockNumber(3), cid.TargetTid.BlockNumber())
+		assert.Equal(t, OffsetNumber(12), cid.TargetTid.Posid)
+	})
+	t.Run("rewrite", func(t *testing.T) {
+		record := testRecord(t, RM_HEAP2_ID, XLOG_HEAP2_REWRITE,
+			le(uint32(900), uint32(5), uint32(16384), uint32(0), uint64(72), uint32(1), uint32(0), uint64(0x1000028),
+				uint32(1663), uint32(5), uint32(16384), uint32(1663), uint32(5), uint32(16390),
+				uint16(0), uint16(1), uint16(1), uint16(0), uint16(2), uint16(1)))
+		decoded, err := DecodeRmgrData(record)
+		require.NoError(t, err)
+		rewrite := decoded.(*HeapRewriteMapping)
+		assert.EqualValues(t, 72, rewrite.Offset)
+		assert.Equal(t, XLogRecPtr(0x1000028), rewrite.StartLsn)
+		require.Len(t, rewrite.Mappings, 1)
+		assert.Equal(t, Oid(16390), rewrite.Mappings[0].NewNode.RelNode)
+		assert.Equal(t, BlockNumber(2), rewrite.Mappings[0].NewTid.BlockNumber())
+	})
+}
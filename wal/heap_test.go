@@ -0,0 +1,91 @@
+package wal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeHeap(t *testing.T) {
+	tupleHeader := le(uint16(3), uint16(0x0802), uint8(24))
+	t.Run("insert", func(t *testing.T) {
+		record := testRecord(t, RM_HEAP_ID, XLOG_HEAP_INSERT|XLOG_HEAP_INIT_PAGE,
+			le(uint16(1), uint8(XLH_INSERT_ALL_VISIBLE_CLEARED)), le(tupleHeader, []byte("row")))
+		decoded, err := DecodeRmgrData(record)
+		require.NoError(t, err)
+		insert := decoded.(*HeapInsert)
+		assert.Equal(t, OffsetNumber(1), insert.Offnum)
+		assert.True(t, insert.InitPage)
+		require.NotNil(t, insert.Tuple)
+		assert.Equal(t, XlHeapHeader{3, 0x0802, 24}, insert.Tuple.Header)
+		assert.Equal(t, []byte("row"), insert.Tuple.Data)
+	})
+	t.Run("insert without tuple", func(t *testing.T) {
+		record := testRecord(t, RM_HEAP_ID, XLOG_HEAP_INSERT, le(uint16(1), uint8(0)), nil)
+		decoded, err := DecodeRmgrData(record)
+		require.NoError(t, err)
+		assert.Nil(t, decoded.(*HeapInsert).Tuple)
+	})
+	t.Run("delete", func(t *testing.T) {
+		record := testRecord(t, RM_HEAP_ID, XLOG_HEAP_DELETE,
+			le(uint32(742), uint16(5), uint8(XLHL_KEYS_UPDATED), uint8(XLH_DELETE_CONTAINS_OLD_KEY), tupleHeader, []byte("key")), nil)
+		decoded, err := DecodeRmgrData(record)
+		require.NoError(t, err)
+		del := decoded.(*HeapDelete)
+		assert.Equal(t, TransactionId(742), del.Xmax)
+		assert.Equal(t, OffsetNumber(5), del.Offnum)
+		assert.EqualValues(t, XLHL_KEYS_UPDATED, del.InfobitsSet)
+		require.NotNil(t, del.OldTuple)
+		assert.Equal(t, []byte("key"), del.OldTuple.Data)
+	})
+	t.Run("hot update", func(t *testing.T) {
+		flags := uint8(XLH_UPDATE_PREFIX_FROM_OLD | XLH_UPDATE_SUFFIX_FROM_OLD | XLH_UPDATE_CONTAINS_OLD_TUPLE)
+		record := testRecord(t, RM_HEAP_ID, XLOG_HEAP_HOT_UPDATE,
+			le(uint32(742), uint16(5), uint8(0), flags, uint32(0), uint16(6), tupleHeader, []byte("old row")),
+			le(uint16(4), uint16(2), tupleHeader, []byte("new")))
+		decoded, err := DecodeRmgrData(record)
+		require.NoError(t, err)
+		update := decoded.(*HeapUpdate)
+		assert.True(t, update.Hot)
+		assert.Equal(t, TransactionId(742), update.OldXmax)
+		assert.Equal(t, OffsetNumber(5), update.OldOffnum)
+		assert.Equal(t, OffsetNumber(6), update.NewOffnum)
+		assert.EqualValues(t, 4, update.PrefixLen)
+		assert.EqualValues(t, 2, update.SuffixLen)
+		assert.Equal(t, []byte("new"), update.NewTuple.Data)
+		assert.Equal(t, []byte("old row"), update.OldTuple.Data)
+	})
+	t.Run("truncate", func(t *testing.T) {
+		record := testRecord(t, RM_HEAP_ID, XLOG_HEAP_TRUNCATE,
+			le(uint32(5), uint32(2), uint8(XLH_TRUNCATE_CASCADE), []byte{0, 0, 0}, uint32(16384), uint32(16390)))
+		decoded, err := DecodeRmgrData(record)
+		require.NoError(t, err)
+		truncate := decoded.(*HeapTruncate)
+		assert.Equal(t, Oid(5), truncate.DbId)
+		assert.EqualValues(t, XLH_TRUNCATE_CASCADE, truncate.Flags)
+		assert.Equal(t, []Oid{16384, 16390}, truncate.Relids)
+	})
+	t.Run("lock", func(t *testing.T) {
+		record := testRecord(t, RM_HEAP_ID, XLOG_HEAP_LOCK,
+			le(uint32(900), uint16(3), uint8(XLHL_XMAX_LOCK_ONLY|XLHL_XMAX_EXCL_LOCK), uint8(0)), nil)
+		decoded, err := DecodeRmgrData(record)
+		require.NoError(t, err)
+		lock := decoded.(*HeapLock)
+		assert.Equal(t, TransactionId(900), lock.Xmax)
+		assert.Equal(t, OffsetNumber(3), lock.Offnum)
+	})
+	t.Run("confirm and inplace", func(t *testing.T) {
+		decoded, err := DecodeRmgrData(testRecord(t, RM_HEAP_ID, XLOG_HEAP_CONFIRM, le(uint16(8)), nil))
+		require.NoError(t, err)
+		assert.Equal(t, OffsetNumber(8), decoded.(*HeapConfirm).Offnum)
+		decoded, err = DecodeRmgrData(testRecord(t, RM_HEAP_ID, XLOG_HEAP_INPLACE, le(uint16(9)), []byte("tuple")))
+		require.NoError(t, err)
+		assert.Equal(t, []byte("tuple"), decoded.(*HeapInplace).Tuple)
+	})
+	t.Run("truncated relids", func(t *testing.T) {
+		record := testRecord(t, RM_HEAP_ID, XLOG_HEAP_TRUNCATE, le(uint32(5), uint32(3), uint32(0), uint32(16384)))
+		_, err := DecodeRmgrData(record)
+		assert.ErrorIs(t, err, ErrInvalidRecord)
+	})
+}
@@ -0,0 +1,470 @@
+package wal
+
+import "fmt"
+
+// rmgrIdentifiers name the info codes of the built-in resource managers like
+// their rm_identify, "" for an info code they do not know. The info given
+// is xl_info without the XLR_INFO_MASK bits.
+var rmgrIdentifiers = map[RmgrId]func(info uint8, v PgVersion) string{
+	RM_XLOG_ID:       xlogIdentify,
+	RM_XACT_ID:       xactIdentify,
+	RM_SMGR_ID:       smgrIdentify,
+	RM_CLOG_ID:       clogIdentify,
+	RM_DBASE_ID:      dbaseIdentify,
+	RM_TBLSPC_ID:     tblspcIdentify,
+	RM_MULTIXACT_ID:  multixactIdentify,
+	RM_RELMAP_ID:     relmapIdentify,
+	RM_STANDBY_ID:    standbyIdentify,
+	RM_HEAP2_ID:      heap2Identify,
+	RM_HEAP_ID:       heapIdentify,
+	RM_BTREE_ID:      btreeIdentify,
+	RM_HASH_ID:       hashIdentify,
+	RM_GIN_ID:        ginIdentify,
+	RM_GIST_ID:       gistIdentify,
+	RM_SEQ_ID:        seqIdentify,
+	RM_SPGIST_ID:     spgIdentify,
+	RM_BRIN_ID:       brinIdentify,
+	RM_COMMIT_TS_ID:  commitTsIdentify,
+	RM_REPLORIGIN_ID: reploriginIdentify,
+	RM_GENERIC_ID:    genericIdentify,
+	RM_LOGICALMSG_ID: logicalmsgIdentify,
+}
+
+// Identify names the kind of record like pg_waldump --stats=record does,
+// the resource manager and the rm_identify name of the info code, e.g.
+// "Heap/HOT_UPDATE+INIT". An info code the resource manager does not know
+// is shown as "UNKNOWN (%x)".
+func Identify(record *Record) string {
+	return RmgrIdName(record.Hdr.XlRmid) + "/" + identify(record)
+}
+
+// identify returns the rm_identify name of the info code of record, as
+// pg_waldump shows it in front of the description.
+func identify(record *Record) string {
+	info := record.Hdr.XlInfo &^ XLR_INFO_MASK
+	if identifier, ok := rmgrIdentifiers[record.Hdr.XlRmid]; ok {
+		if id := identifier(info, record.Version); id != "" {
+			return id
+		}
+	}
+	return fmt.Sprintf("UNKNOWN (%x)", info)
+}
+
+func xlogIdentify(info uint8, v PgVersion) string {
+	switch info {
+	case XLOG_CHECKPOINT_SHUTDOWN:
+		return "CHECKPOINT_SHUTDOWN"
+	case XLOG_CHECKPOINT_ONLINE:
+		return "CHECKPOINT_ONLINE"
+	case XLOG_NOOP:
+		return "NOOP"
+	case XLOG_NEXTOID:
+		return "NEXTOID"
+	case XLOG_SWITCH:
+		return "SWITCH"
+	case XLOG_BACKUP_END:
+		return "BACKUP_END"
+	case XLOG_PARAMETER_CHANGE:
+		return "PARAMETER_CHANGE"
+	case XLOG_RESTORE_POINT:
+		return "RESTORE_POINT"
+	case XLOG_FPW_CHANGE:
+		return "FPW_CHANGE"
+	case XLOG_END_OF_RECOVERY:
+		return "END_OF_RECOVERY"
+	case XLOG_OVERWRITE_CONTRECORD:
+		return "OVERWRITE_CONTRECORD"
+	case XLOG_FPI:
+		return "FPI"
+	case XLOG_FPI_FOR_HINT:
+		return "FPI_FOR_HINT"
+	case XLOG_CHECKPOINT_REDO:
+		if v >= PG17 {
+			return "CHECKPOINT_REDO"
+		}
+	}
+	return ""
+}
+
+func xactIdentify(info uint8, v PgVersion) string {
+	switch info & XLOG_XACT_OPMASK {
+	case XLOG_XACT_COMMIT:
+		return "COMMIT"
+	case XLOG_XACT_PREPARE:
+		return "PREPARE"
+	case XLOG_XACT_ABORT:
+		return "ABORT"
+	case XLOG_XACT_COMMIT_PREPARED:
+		return "COMMIT_PREPARED"
+	case XLOG_XACT_ABORT_PREPARED:
+		return "ABORT_PREPARED"
+	case XLOG_XACT_ASSIGNMENT:
+		return "ASSIGNMENT"
+	case XLOG_XACT_INVALIDATIONS:
+		if v >= PG14 {
+			return "INVALIDATION"
+		}
+	}
+	return ""
+}
+
+func smgrIdentify(info uint8, v PgVersion) string {
+	switch info {
+	case XLOG_SMGR_CREATE:
+		return "CREATE"
+	case XLOG_SMGR_TRUNCATE:
+		return "TRUNCATE"
+	}
+	return ""
+}
+
+func clogIdentify(info uint8, v PgVersion) string {
+	switch info {
+	case CLOG_ZEROPAGE:
+		return "ZEROPAGE"
+	case CLOG_TRUNCATE:
+		return "TRUNCATE"
+	}
+	return ""
+}
+
+func dbaseIdentify(info uint8, v PgVersion) string {
+	if v < PG15 {
+		switch info {
+		case XLOG_DBASE_CREATE_PRE15:
+			return "CREATE"
+		case XLOG_DBASE_DROP_PRE15:
+			return "DROP"
+		}
+		return ""
+	}
+	switch info {
+	case XLOG_DBASE_CREATE_FILE_COPY:
+		return "CREATE_FILE_COPY"
+	case XLOG_DBASE_CREATE_WAL_LOG:
+		return "CREATE_WAL_LOG"
+	case XLOG_DBASE_DROP:
+		return "DROP"
+	}
+	return ""
+}
+
+func tblspcIdentify(info uint8, v PgVersion) string {
+	switch info {
+	case XLOG_TBLSPC_CREATE:
+		return "CREATE"
+	case XLOG_TBLSPC_DROP:
+		return "DROP"
+	}
+	return ""
+}
+
+func multixactIdentify(info uint8, v PgVersion) string {
+	switch info {
+	case XLOG_MULTIXACT_ZERO_OFF_PAGE:
+		return "ZERO_OFF_PAGE"
+	case XLOG_MULTIXACT_ZERO_MEM_PAGE:
+		return "ZERO_MEM_PAGE"
+	case XLOG_MULTIXACT_CREATE_ID:
+		return "CREATE_ID"
+	case XLOG_MULTIXACT_TRUNCATE_ID:
+		return "TRUNCATE_ID"
+	}
+	return ""
+}
+
+func relmapIdentify(info uint8, v PgVersion) string {
+	if info == XLOG_RELMAP_UPDATE {
+		return "UPDATE"
+	}
+	return ""
+}
+
+func standbyIdentify(info uint8, v PgVersion) string {
+	switch info {
+	case XLOG_STANDBY_LOCK:
+		return "LOCK"
+	case XLOG_RUNNING_XACTS:
+		return "RUNNING_XACTS"
+	case XLOG_INVALIDATIONS:
+		return "INVALIDATIONS"
+	}
+	return ""
+}
+
+func heap2Identify(info uint8, v PgVersion) string {
+	switch info {
+	case XLOG_HEAP2_VISIBLE:
+		return "VISIBLE"
+	case XLOG_HEAP2_MULTI_INSERT:
+		return "MULTI_INSERT"
+	case XLOG_HEAP2_MULTI_INSERT | XLOG_HEAP_INIT_PAGE:
+		return "MULTI_INSERT+INIT"
+	case XLOG_HEAP2_LOCK_UPDATED:
+		return "LOCK_UPDATED"
+	case XLOG_HEAP2_NEW_CID:
+		return "NEW_CID"
+	case XLOG_HEAP2_REWRITE:
+		return "REWRITE"
+	}
+	switch {
+	case v >= PG17:
+		switch info {
+		case XLOG_HEAP2_PRUNE_ON_ACCESS:
+			return "PRUNE_ON_ACCESS"
+		case XLOG_HEAP2_PRUNE_VACUUM_SCAN:
+			return "PRUNE_VACUUM_SCAN"
+		case XLOG_HEAP2_PRUNE_VACUUM_CLEANUP:
+			return "PRUNE_VACUUM_CLEANUP"
+		}
+	case v >= PG14:
+		switch info {
+		case XLOG_HEAP2_PRUNE:
+			return "PRUNE"
+		case XLOG_HEAP2_VACUUM:
+			return "VACUUM"
+		case XLOG_HEAP2_FREEZE_PAGE:
+			return "FREEZE_PAGE"
+		}
+	default:
+		switch info {
+		case XLOG_HEAP2_CLEAN:
+			return "CLEAN"
+		case XLOG_HEAP2_FREEZE_PAGE_PRE14:
+			return "FREEZE_PAGE"
+		case XLOG_HEAP2_CLEANUP_INFO:
+			return "CLEANUP_INFO"
+		}
+	}
+	return ""
+}
+
+func heapIdentify(info uint8, v PgVersion) string {
+	switch info {
+	case XLOG_HEAP_INSERT:
+		return "INSERT"
+	case XLOG_HEAP_INSERT | XLOG_HEAP_INIT_PAGE:
+		return "INSERT+INIT"
+	case XLOG_HEAP_DELETE:
+		return "DELETE"
+	case XLOG_HEAP_UPDATE:
+		return "UPDATE"
+	case XLOG_HEAP_UPDATE | XLOG_HEAP_INIT_PAGE:
+		return "UPDATE+INIT"
+	case XLOG_HEAP_HOT_UPDATE:
+		return "HOT_UPDATE"
+	case XLOG_HEAP_HOT_UPDATE | XLOG_HEAP_INIT_PAGE:
+		return "HOT_UPDATE+INIT"
+	case XLOG_HEAP_TRUNCATE:
+		return "TRUNCATE"
+	case XLOG_HEAP_CONFIRM:
+		return "CONFIRM"
+	case XLOG_HEAP_LOCK:
+		return "LOCK"
+	case XLOG_HEAP_INPLACE:
+		return "INPLACE"
+	}
+	return ""
+}
+
+func btreeIdentify(info uint8, v PgVersion) string {
+	switch info {
+	case XLOG_BTREE_INSERT_LEAF:
+		return "INSERT_LEAF"
+	case XLOG_BTREE_INSERT_UPPER:
+		return "INSERT_UPPER"
+	case XLOG_BTREE_INSERT_META:
+		return "INSERT_META"
+	case XLOG_BTREE_SPLIT_L:
+		return "SPLIT_L"
+	case XLOG_BTREE_SPLIT_R:
+		return "SPLIT_R"
+	case XLOG_BTREE_INSERT_POST:
+		if v >= PG13 {
+			return "INSERT_POST"
+		}
+	case XLOG_BTREE_DEDUP:
+		if v >= PG13 {
+			return "DEDUP"
+		}
+	case XLOG_BTREE_VACUUM:
+		return "VACUUM"
+	case XLOG_BTREE_DELETE:
+		return "DELETE"
+	case XLOG_BTREE_MARK_PAGE_HALFDEAD:
+		return "MARK_PAGE_HALFDEAD"
+	case XLOG_BTREE_UNLINK_PAGE:
+		return "UNLINK_PAGE"
+	case XLOG_BTREE_UNLINK_PAGE_META:
+		return "UNLINK_PAGE_META"
+	case XLOG_BTREE_NEWROOT:
+		return "NEWROOT"
+	case XLOG_BTREE_REUSE_PAGE:
+		return "REUSE_PAGE"
+	case XLOG_BTREE_META_CLEANUP:
+		return "META_CLEANUP"
+	}
+	return ""
+}
+
+func hashIdentify(info uint8, v PgVersion) string {
+	switch info {
+	case XLOG_HASH_INIT_META_PAGE:
+		return "INIT_META_PAGE"
+	case XLOG_HASH_INIT_BITMAP_PAGE:
+		return "INIT_BITMAP_PAGE"
+	case XLOG_HASH_INSERT:
+		return "INSERT"
+	case XLOG_HASH_ADD_OVFL_PAGE:
+		return "ADD_OVFL_PAGE"
+	case XLOG_HASH_SPLIT_ALLOCATE_PAGE:
+		return "SPLIT_ALLOCATE_PAGE"
+	case XLOG_HASH_SPLIT_PAGE:
+		return "SPLIT_PAGE"
+	case XLOG_HASH_SPLIT_COMPLETE:
+		return "SPLIT_COMPLETE"
+	case XLOG_HASH_MOVE_PAGE_CONTENTS:
+		return "MOVE_PAGE_CONTENTS"
+	case XLOG_HASH_SQUEEZE_PAGE:
+		return "SQUEEZE_PAGE"
+	case XLOG_HASH_DELETE:
+		return "DELETE"
+	case XLOG_HASH_SPLIT_CLEANUP:
+		return "SPLIT_CLEANUP"
+	case XLOG_HASH_UPDATE_META_PAGE:
+		return "UPDATE_META_PAGE"
+	case XLOG_HASH_VACUUM_ONE_PAGE:
+		return "VACUUM_ONE_PAGE"
+	}
+	return ""
+}
+
+func ginIdentify(info uint8, v PgVersion) string {
+	switch info {
+	case XLOG_GIN_CREATE_PTREE:
+		return "CREATE_PTREE"
+	case XLOG_GIN_INSERT:
+		return "INSERT"
+	case XLOG_GIN_SPLIT:
+		return "SPLIT"
+	case XLOG_GIN_VACUUM_PAGE:
+		return "VACUUM_PAGE"
+	case XLOG_GIN_VACUUM_DATA_LEAF_PAGE:
+		return "VACUUM_DATA_LEAF_PAGE"
+	case XLOG_GIN_DELETE_PAGE:
+		return "DELETE_PAGE"
+	case XLOG_GIN_UPDATE_META_PAGE:
+		return "UPDATE_META_PAGE"
+	case XLOG_GIN_INSERT_LISTPAGE:
+		return "INSERT_LISTPAGE"
+	case XLOG_GIN_DELETE_LISTPAGE:
+		return "DELETE_LISTPAGE"
+	}
+	return ""
+}
+
+func gistIdentify(info uint8, v PgVersion) string {
+	switch info {
+	case XLOG_GIST_PAGE_UPDATE:
+		return "PAGE_UPDATE"
+	case XLOG_GIST_DELETE:
+		return "DELETE"
+	case XLOG_GIST_PAGE_REUSE:
+		return "PAGE_REUSE"
+	case XLOG_GIST_PAGE_SPLIT:
+		return "PAGE_SPLIT"
+	case XLOG_GIST_PAGE_DELETE:
+		return "PAGE_DELETE"
+	case XLOG_GIST_ASSIGN_LSN:
+		if v >= PG13 {
+			return "ASSIGN_LSN"
+		}
+	}
+	return ""
+}
+
+func seqIdentify(info uint8, v PgVersion) string {
+	if info == XLOG_SEQ_LOG {
+		return "LOG"
+	}
+	return ""
+}
+
+func spgIdentify(info uint8, v PgVersion) string {
+	switch info {
+	case XLOG_SPGIST_ADD_LEAF:
+		return "ADD_LEAF"
+	case XLOG_SPGIST_MOVE_LEAFS:
+		return "MOVE_LEAFS"
+	case XLOG_SPGIST_ADD_NODE:
+		return "ADD_NODE"
+	case XLOG_SPGIST_SPLIT_TUPLE:
+		return "SPLIT_TUPLE"
+	case XLOG_SPGIST_PICKSPLIT:
+		return "PICKSPLIT"
+	case XLOG_SPGIST_VACUUM_LEAF:
+		return "VACUUM_LEAF"
+	case XLOG_SPGIST_VACUUM_ROOT:
+		return "VACUUM_ROOT"
+	case XLOG_SPGIST_VACUUM_REDIRECT:
+		return "VACUUM_REDIRECT"
+	}
+	return ""
+}
+
+func brinIdentify(info uint8, v PgVersion) string {
+	switch info {
+	case XLOG_BRIN_CREATE_INDEX:
+		return "CREATE_INDEX"
+	case XLOG_BRIN_INSERT:
+		return "INSERT"
+	case XLOG_BRIN_INSERT | XLOG_BRIN_INIT_PAGE:
+		return "INSERT+INIT"
+	case XLOG_BRIN_UPDATE:
+		return "UPDATE"
+	case XLOG_BRIN_UPDATE | XLOG_BRIN_INIT_PAGE:
+		return "UPDATE+INIT"
+	case XLOG_BRIN_SAMEPAGE_UPDATE:
+		return "SAMEPAGE_UPDATE"
+	case XLOG_BRIN_REVMAP_EXTEND:
+		return "REVMAP_EXTEND"
+	case XLOG_BRIN_DESUMMARIZE:
+		return "DESUMMARIZE"
+	}
+	return ""
+}
+
+func commitTsIdentify(info uint8, v PgVersion) string {
+	switch info {
+	case COMMIT_TS_ZEROPAGE:
+		return "ZEROPAGE"
+	case COMMIT_TS_TRUNCATE:
+		return "TRUNCATE"
+	case COMMIT_TS_SETTS:
+		if v < PG14 {
+			return "SETTS"
+		}
+	}
+	return ""
+}
+
+func reploriginIdentify(info uint8, v PgVersion) string {
+	switch info {
+	case XLOG_REPLORIGIN_SET:
+		return "SET"
+	case XLOG_REPLORIGIN_DROP:
+		return "DROP"
+	}
+	return ""
+}
+
+func genericIdentify(info uint8, v PgVersion) string {
+	return "Generic"
+}
+
+func logicalmsgIdentify(info uint8, v PgVersion) string {
+	if info == XLOG_LOGICAL_MESSAGE {
+		return "MESSAGE"
+	}
+	return ""
+}
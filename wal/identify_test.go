@@ -0,0 +1,43 @@
+package wal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIdentify(t *testing.T) {
+	for _, c := range []struct {
+		rmid    RmgrId
+		info    uint8
+		version PgVersion
+		want    string
+	}{
+		{RM_HEAP_ID, XLOG_HEAP_INSERT, PG16, "Heap/INSERT"},
+		{RM_HEAP_ID, XLOG_HEAP_HOT_UPDATE | XLOG_HEAP_INIT_PAGE, PG16, "Heap/HOT_UPDATE+INIT"},
+		{RM_HEAP_ID, XLOG_HEAP_LOCK | XLOG_HEAP_INIT_PAGE, PG16, "Heap/UNKNOWN (e0)"},
+		{RM_HEAP2_ID, XLOG_HEAP2_MULTI_INSERT | XLOG_HEAP_INIT_PAGE, PG16, "Heap2/MULTI_INSERT+INIT"},
+		{RM_HEAP2_ID, 0x10, PG13, "Heap2/CLEAN"},
+		{RM_HEAP2_ID, 0x10, PG16, "Heap2/PRUNE"},
+		{RM_HEAP2_ID, 0x10, PG17, "Heap2/PRUNE_ON_ACCESS"},
+		{RM_XACT_ID, XLOG_XACT_COMMIT | XLOG_XACT_HAS_INFO, PG16, "Transaction/COMMIT"},
+		{RM_XACT_ID, XLOG_XACT_INVALIDATIONS, PG13, "Transaction/UNKNOWN (60)"},
+		{RM_DBASE_ID, 0x10, PG14, "Database/DROP"},
+		{RM_DBASE_ID, 0x10, PG15, "Database/CREATE_WAL_LOG"},
+		{RM_BTREE_ID, XLOG_BTREE_DEDUP, PG12, "Btree/UNKNOWN (60)"},
+		{RM_BTREE_ID, XLOG_BTREE_DEDUP, PG13, "Btree/DEDUP"},
+		{RM_BRIN_ID, XLOG_BRIN_UPDATE | XLOG_BRIN_INIT_PAGE, PG16, "BRIN/UPDATE+INIT"},
+		{RM_XLOG_ID, XLOG_CHECKPOINT_REDO, PG16, "XLOG/UNKNOWN (e0)"},
+		{RM_XLOG_ID, XLOG_CHECKPOINT_REDO, PG17, "XLOG/CHECKPOINT_REDO"},
+		{RM_COMMIT_TS_ID, COMMIT_TS_SETTS, PG13, "CommitTs/SETTS"},
+		{RM_GENERIC_ID, 0, PG16, "Generic/Generic"},
+		{RM_MIN_CUSTOM_ID, 0x10, PG16, "custom128/UNKNOWN (10)"},
+	} {
+		record := versioned(testRecord(t, c.rmid, c.info, nil), c.version)
+		assert.Equal(t, c.want, Identify(record))
+	}
+
+	// the XLR_INFO_MASK bits are not part of the info code
+	record := testRecord(t, RM_HEAP_ID, XLOG_HEAP_DELETE|XLR_SPECIAL_REL_UPDATE, nil)
+	assert.Equal(t, "Heap/DELETE", Identify(record))
+}
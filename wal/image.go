@@ -0,0 +1,147 @@
+package wal
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/pierrec/lz4/v4"
+)
+
+// BLCKSZ is the size of the pages full-page images restore.
+const BLCKSZ = 8192
+
+// HasImage tells whether the block reference carries a full-page image.
+func (b *Block) HasImage() bool {
+	return b.Iheader != nil
+}
+
+// HoleOffset returns the offset of the hole left out of the page image.
+func (b *Block) HoleOffset() uint16 {
+	if b.Iheader == nil || !b.Iheader.HasHole() {
+		return 0
+	}
+	return b.Iheader.HoleOffset
+}
+
+// HoleLength returns the length of the hole left out of the page image. It is
+// only recorded for compressed images, an uncompressed one takes up the rest
+// of the page.
+func (b *Block) HoleLength() uint16 {
+	switch {
+	case b.Iheader == nil || !b.Iheader.HasHole():
+		return 0
+	case b.Cheader != nil:
+		return b.Cheader.HoleLength
+	default:
+		return BLCKSZ - b.Iheader.Length
+	}
+}
+
+// RestorePage returns the page the full-page image of the block stands for,
+// like RestoreBlockImage: the hole is zero filled.
+func (b *Block) RestorePage() ([]byte, error) {
+	if b.Iheader == nil {
+		return nil, errors.New("block has no image")
+	}
+	if err := b.checkImage(); err != nil {
+		return nil, err
+	}
+
+	image := b.PageData
+	if b.Iheader.IsCompressed(b.version) {
+		var err error
+		image, err = b.decompressImage(BLCKSZ - int(b.HoleLength()))
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(image)+int(b.HoleLength()) != BLCKSZ {
+		return nil, fmt.Errorf("invalid image length %d with hole length %d", len(image), b.HoleLength())
+	}
+
+	page := make([]byte, BLCKSZ)
+	offset := int(b.HoleOffset())
+	copy(page, image[:offset])
+	copy(page[offset+int(b.HoleLength()):], image[offset:])
+	return page, nil
+}
+
+// Compression returns the method the image is compressed with, "" if it is
+// not compressed.
+func (b *Block) Compression() string {
+	switch {
+	case b.Iheader == nil || !b.Iheader.IsCompressed(b.version):
+		return ""
+	case b.version < PG15 || b.Iheader.BimgInfo&BKPIMAGE_COMPRESS_PGLZ != 0:
+		return "pglz"
+	case b.Iheader.BimgInfo&BKPIMAGE_COMPRESS_LZ4 != 0:
+		return "lz4"
+	default:
+		return "zstd"
+	}
+}
+
+var (
+	zstdOnce    sync.Once
+	zstdDecoder *zstd.Decoder
+	zstdErr     error
+)
+
+// decompressImage decompresses the image into rawsize bytes, the page
+// without its hole.
+func (b *Block) decompressImage(rawsize int) ([]byte, error) {
+	method := b.Compression()
+	switch method {
+	case "pglz":
+		image, err := PglzDecompress(b.PageData, rawsize)
+		if err != nil {
+			return nil, fmt.Errorf("could not decompress image: %w", err)
+		}
+		return image, nil
+	case "lz4":
+		image := make([]byte, rawsize)
+		n, err := lz4.UncompressBlock(b.PageData, image)
+		if err != nil || n != rawsize {
+			return nil, fmt.Errorf("could not decompress image: invalid lz4 data")
+		}
+		return image, nil
+	default:
+		zstdOnce.Do(func() {
+			zstdDecoder, zstdErr = zstd.NewReader(nil, zstd.WithDecoderConcurrency(1))
+		})
+		if zstdErr != nil {
+			return nil, fmt.Errorf("could not decompress image: %w", zstdErr)
+		}
+		image, err := zstdDecoder.DecodeAll(b.PageData, make([]byte, 0, rawsize))
+		if err != nil {
+			return nil, fmt.Errorf("could not decompress image: %w", err)
+		}
+		if len(image) != rawsize {
+			return nil, fmt.Errorf("could not decompress image: invalid zstd data")
+		}
+		return image, nil
+	}
+}
+
+// checkImage does the sanity checks of the image header DecodeXLogRecord
+// does.
+func (b *Block) checkImage() error {
+	hdr := b.Iheader
+	compressed := hdr.IsCompressed(b.version)
+	switch {
+	case hdr.HasHole() && (hdr.HoleOffset == 0 || b.HoleLength() == 0 || hdr.Length == BLCKSZ):
+		return fmt.Errorf("BKPIMAGE_HAS_HOLE set, but hole offset %d length %d block image length %d",
+			hdr.HoleOffset, b.HoleLength(), hdr.Length)
+	case !hdr.HasHole() && hdr.HoleOffset != 0:
+		return fmt.Errorf("BKPIMAGE_HAS_HOLE not set, but hole offset %d", hdr.HoleOffset)
+	case compressed && hdr.Length == BLCKSZ:
+		return fmt.Errorf("BKPIMAGE_COMPRESSED set, but block image length %d", hdr.Length)
+	case !hdr.HasHole() && !compressed && hdr.Length != BLCKSZ:
+		return fmt.Errorf("neither BKPIMAGE_HAS_HOLE nor BKPIMAGE_COMPRESSED set, but block image length is %d", hdr.Length)
+	case int(hdr.HoleOffset)+int(b.HoleLength()) > BLCKSZ:
+		return fmt.Errorf("hole offset %d length %d past the end of the page", hdr.HoleOffset, b.HoleLength())
+	}
+	return nil
+}
@@ -0,0 +1,178 @@
+package wal
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/pierrec/lz4/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testPage returns a page whose bytes from holeOffset to holeOffset+holeLength
+// are zero.
+func testPage(holeOffset, holeLength int) []byte {
+	page := make([]byte, BLCKSZ)
+	for i := range page {
+		if i < holeOffset || i >= holeOffset+holeLength {
+			page[i] = byte(i%251 + 1)
+		}
+	}
+	return page
+}
+
+// imageBlock encodes block reference id carrying the image of page without
+// the hole.
+func imageBlock(id uint8, rfn *RelFileNode, blkno BlockNumber, page []byte, holeOffset, holeLength int, info uint8) ([]byte, []byte) {
+	image := append(append([]byte(nil), page[:holeOffset]...), page[holeOffset+holeLength:]...)
+	if holeLength > 0 {
+		info |= BKPIMAGE_HAS_HOLE
+	}
+	hdr := []byte{id, BKPBLOCK_HAS_IMAGE, 0, 0, 0, 0, 0, 0, info}
+	binary.LittleEndian.PutUint16(hdr[4:], uint16(len(image)))
+	binary.LittleEndian.PutUint16(hdr[6:], uint16(holeOffset))
+	hdr = binary.LittleEndian.AppendUint32(hdr, uint32(rfn.SpcNode))
+	hdr = binary.LittleEndian.AppendUint32(hdr, uint32(rfn.DbNode))
+	hdr = binary.LittleEndian.AppendUint32(hdr, uint32(rfn.RelNode))
+	hdr = binary.LittleEndian.AppendUint32(hdr, uint32(blkno))
+	return hdr, image
+}
+
+func TestRestorePage(t *testing.T) {
+	rfn := &RelFileNode{1663, 5, 16384}
+	holed := testPage(120, 7000)
+	full := testPage(0, 0)
+	hdr0, image0 := imageBlock(0, rfn, 1, holed, 120, 7000, BKPIMAGE_APPLY)
+	hdr1, image1 := imageBlock(1, rfn, 2, full, 0, 0, 0)
+	record, err := rawRecord(concat(hdr0, hdr1, image0, image1)).Decode()
+	require.NoError(t, err)
+	require.Len(t, record.Blocks, 2)
+
+	assert.True(t, record.Blocks[0].HasImage())
+	assert.EqualValues(t, 120, record.Blocks[0].HoleOffset())
+	assert.EqualValues(t, 7000, record.Blocks[0].HoleLength())
+	page, err := record.Blocks[0].RestorePage()
+	require.NoError(t, err)
+	assert.True(t, bytes.Equal(holed, page))
+
+	page, err = record.Blocks[1].RestorePage()
+	require.NoError(t, err)
+	assert.True(t, bytes.Equal(full, page))
+}
+
+func TestRestorePageInvalid(t *testing.T) {
+	cases := []struct {
+		name  string
+		block Block
+	}{
+		{"no image", Block{}},
+		{"hole at start", Block{Iheader: &XLogRecordBlockImageHeader{Length: 100, BimgInfo: BKPIMAGE_HAS_HOLE}, PageData: make([]byte, 100)}},
+		{"short image", Block{Iheader: &XLogRecordBlockImageHeader{Length: 100}, PageData: make([]byte, 100)}},
+		{"offset without hole", Block{Iheader: &XLogRecordBlockImageHeader{Length: BLCKSZ, HoleOffset: 10}, PageData: make([]byte, BLCKSZ)}},
+	}
+	for _, entry := range cases {
+		t.Run(entry.name, func(t *testing.T) {
+			_, err := entry.block.RestorePage()
+			assert.Error(t, err)
+		})
+	}
+}
+
+// pglzCompress is a minimal pglz encoder which only references the previous
+// byte, enough for the runs of testPage.
+func pglzCompress(data []byte) []byte {
+	var (
+		ret     []byte
+		ctrlPos int
+		ctrlc   = 8
+	)
+	item := func(isTag bool, bytes ...byte) {
+		if ctrlc == 8 {
+			ctrlPos = len(ret)
+			ret = append(ret, 0)
+			ctrlc = 0
+		}
+		if isTag {
+			ret[ctrlPos] |= 1 << ctrlc
+		}
+		ctrlc++
+		ret = append(ret, bytes...)
+	}
+	for i := 0; i < len(data); {
+		run := 0
+		for i+run+1 < len(data) && data[i+run+1] == data[i] && run < 273 {
+			run++
+		}
+		if i == 0 || run < 3 {
+			item(false, data[i])
+			i++
+			continue
+		}
+		// data[i] is a literal, the run references it
+		item(false, data[i])
+		if run >= 18 {
+			item(true, 0x0f, 1, byte(run-18))
+		} else {
+			item(true, byte(run-3), 1)
+		}
+		i += run + 1
+	}
+	return ret
+}
+
+func TestPglzDecompress(t *testing.T) {
+	page := testPage(100, 5000)
+	compressed := pglzCompress(page)
+	assert.Less(t, len(compressed), len(page))
+	data, err := PglzDecompress(compressed, len(page))
+	require.NoError(t, err)
+	assert.True(t, bytes.Equal(page, data))
+
+	_, err = PglzDecompress(compressed, len(page)+1)
+	assert.Error(t, err)
+	_, err = PglzDecompress([]byte{0x01, 0x00, 0x05}, 10)
+	assert.Error(t, err)
+}
+
+func TestRestoreCompressedPage(t *testing.T) {
+	page := testPage(200, 6000)
+	image := append(append([]byte(nil), page[:200]...), page[6200:]...)
+	zstdEncoder, err := zstd.NewWriter(nil)
+	require.NoError(t, err)
+	lz4Image := make([]byte, lz4.CompressBlockBound(len(image)))
+	n, err := lz4.CompressBlock(image, lz4Image, nil)
+	require.NoError(t, err)
+
+	cases := []struct {
+		name       string
+		version    PgVersion
+		info       uint8
+		compressed []byte
+	}{
+		{"pglz 14", PG14, BKPIMAGE_IS_COMPRESSED, pglzCompress(image)},
+		{"pglz", PG15, BKPIMAGE_COMPRESS_PGLZ, pglzCompress(image)},
+		{"lz4", PG16, BKPIMAGE_COMPRESS_LZ4, lz4Image[:n]},
+		{"zstd", PG17, BKPIMAGE_COMPRESS_ZSTD, zstdEncoder.EncodeAll(image, nil)},
+	}
+	for _, entry := range cases {
+		t.Run(entry.name, func(t *testing.T) {
+			hdr := []byte{0, BKPBLOCK_HAS_IMAGE, 0, 0, 0, 0, 200, 0, entry.info | BKPIMAGE_HAS_HOLE}
+			binary.LittleEndian.PutUint16(hdr[4:], uint16(len(entry.compressed)))
+			hdr = append(hdr, 0, 0)
+			binary.LittleEndian.PutUint16(hdr[9:], 6000)
+			hdr = append(hdr, make([]byte, 16)...)
+			raw := rawRecord(concat(hdr, entry.compressed))
+			raw.Version = entry.version
+			record, err := raw.Decode()
+			require.NoError(t, err)
+			require.Len(t, record.Blocks, 1)
+			assert.Equal(t, strings.Fields(entry.name)[0], record.Blocks[0].Compression())
+			restored, err := record.Blocks[0].RestorePage()
+			require.NoError(t, err)
+			assert.True(t, bytes.Equal(page, restored))
+		})
+	}
+}
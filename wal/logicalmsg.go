@@ -0,0 +1,52 @@
+package wal
+
+/*
+ * Generic logical decoding message wal record.
+ */
+type XlLogicalMessage struct {
+	DbId          Oid    /* database Oid emitted from */
+	Transactional bool   /* is message transactional? */
+	PrefixSize    uint64 /* length of prefix */
+	MessageSize   uint64 /* size of the message */
+	/* payload, including null-terminated prefix of length prefix_size */
+}
+
+func SizeofXlLogicalMessage() int64 {
+	return 24
+}
+
+const XLOG_LOGICAL_MESSAGE = 0x00
+
+type LogicalMessage struct {
+	XlLogicalMessage
+	Prefix  string
+	Message []byte
+}
+
+// DecodeLogicalMsg decodes the records of RM_LOGICALMSG_ID into the struct
+// of their info code, *LogicalMessage.
+func DecodeLogicalMsg(record *Record) (interface{}, error) {
+	data := record.MainData
+	switch record.Info() {
+	case XLOG_LOGICAL_MESSAGE:
+		xlrec, err := readStruct[XlLogicalMessage](record, data, SizeofXlLogicalMessage())
+		if err != nil {
+			return nil, err
+		}
+		payload := data[SizeofXlLogicalMessage():]
+		if xlrec.PrefixSize == 0 || xlrec.PrefixSize > uint64(len(payload)) ||
+			xlrec.MessageSize > uint64(len(payload))-xlrec.PrefixSize {
+			return nil, record.tooShort(payload, "the logical message payload")
+		}
+		prefix := payload[:xlrec.PrefixSize]
+		if prefix[len(prefix)-1] != 0 {
+			return nil, record.errorf("logical message prefix is not null-terminated")
+		}
+		return &LogicalMessage{
+			XlLogicalMessage: *xlrec,
+			Prefix:           string(prefix[:len(prefix)-1]),
+			Message:          payload[xlrec.PrefixSize : xlrec.PrefixSize+xlrec.MessageSize],
+		}, nil
+	}
+	return nil, record.unknownInfo()
+}
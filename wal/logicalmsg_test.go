@@ -0,0 +1,23 @@
+package wal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeLogicalMsg(t *testing.T) {
+	decoded, err := DecodeRmgrData(testRecord(t, RM_LOGICALMSG_ID, XLOG_LOGICAL_MESSAGE, le(
+		uint32(5), uint8(1), []byte{0, 0, 0}, uint64(4), uint64(5), []byte("app\x00hello"))))
+	require.NoError(t, err)
+	assert.Equal(t, &LogicalMessage{
+		XlLogicalMessage: XlLogicalMessage{DbId: 5, Transactional: true, PrefixSize: 4, MessageSize: 5},
+		Prefix:           "app",
+		Message:          []byte("hello"),
+	}, decoded)
+
+	_, err = DecodeRmgrData(testRecord(t, RM_LOGICALMSG_ID, XLOG_LOGICAL_MESSAGE, le(
+		uint32(5), uint8(1), []byte{0, 0, 0}, uint64(4), uint64(6), []byte("app\x00hello"))))
+	assert.ErrorIs(t, err, ErrInvalidRecord)
+}
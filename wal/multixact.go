@@ -0,0 +1,142 @@
+package wal
+
+import "fmt"
+
+type MultiXactId uint32
+type MultiXactOffset uint32
+
+/*
+ * Possible multixact lock modes ("status").  The first four modes are for
+ * tuple locks (FOR KEY SHARE, FOR SHARE, FOR NO KEY UPDATE, FOR UPDATE); the
+ * next two are used for update and delete modes.
+ */
+type MultiXactStatus int32
+
+const (
+	MultiXactStatusForKeyShare MultiXactStatus = iota
+	MultiXactStatusForShare
+	MultiXactStatusForNoKeyUpdate
+	MultiXactStatusForUpdate
+	/* an update that doesn't touch "key" columns */
+	MultiXactStatusNoKeyUpdate
+	/* other updates, and delete */
+	MultiXactStatusUpdate
+)
+
+var multiXactStatusNames = [...]string{
+	MultiXactStatusForKeyShare:    "keysh",
+	MultiXactStatusForShare:       "sh",
+	MultiXactStatusForNoKeyUpdate: "fornokeyupd",
+	MultiXactStatusForUpdate:      "forupd",
+	MultiXactStatusNoKeyUpdate:    "nokeyupd",
+	MultiXactStatusUpdate:         "upd",
+}
+
+// String returns the short name multixact_desc shows for the status.
+func (s MultiXactStatus) String() string {
+	if s < 0 || int(s) >= len(multiXactStatusNames) {
+		return "unk"
+	}
+	return multiXactStatusNames[s]
+}
+
+type MultiXactMember struct {
+	Xid    TransactionId
+	Status MultiXactStatus
+}
+
+func (m MultiXactMember) String() string {
+	return fmt.Sprintf("%d (%s)", m.Xid, m.Status)
+}
+
+/* ----------------
+ *		multixact-related XLOG entries
+ * ----------------
+ */
+const (
+	XLOG_MULTIXACT_ZERO_OFF_PAGE = 0x00
+	XLOG_MULTIXACT_ZERO_MEM_PAGE = 0x10
+	XLOG_MULTIXACT_CREATE_ID     = 0x20
+	XLOG_MULTIXACT_TRUNCATE_ID   = 0x30
+)
+
+type XlMultixactCreate struct {
+	Mid      MultiXactId     /* new MultiXact's ID */
+	Moff     MultiXactOffset /* its starting offset in members file */
+	Nmembers int32           /* number of member XIDs */
+	/* MultiXactMember members[FLEXIBLE_ARRAY_MEMBER] follow */
+}
+
+func SizeofXlMultixactCreate() int64 {
+	return 12
+}
+
+type XlMultixactTruncate struct {
+	OldestMultiDB Oid
+
+	/* to-be-truncated range of multixact offsets */
+	StartTruncOff MultiXactId /* just for completeness' sake */
+	EndTruncOff   MultiXactId
+
+	/* to-be-truncated range of multixact members */
+	StartTruncMemb MultiXactOffset
+	EndTruncMemb   MultiXactOffset
+}
+
+func SizeofXlMultixactTruncate() int64 {
+	return 20
+}
+
+type MultiXactZeroOffPage struct {
+	Pageno int64
+}
+
+type MultiXactZeroMemPage struct {
+	Pageno int64
+}
+
+type MultiXactCreate struct {
+	XlMultixactCreate
+	Members []MultiXactMember
+}
+
+type MultiXactTruncate struct {
+	XlMultixactTruncate
+}
+
+// DecodeMultiXact decodes the records of RM_MULTIXACT_ID into the struct of
+// their info code, e.g. *MultiXactCreate.
+func DecodeMultiXact(record *Record) (interface{}, error) {
+	data := record.MainData
+	switch record.Info() {
+	case XLOG_MULTIXACT_ZERO_OFF_PAGE:
+		pageno, err := readSlruPageno(record, data)
+		if err != nil {
+			return nil, err
+		}
+		return &MultiXactZeroOffPage{Pageno: pageno}, nil
+	case XLOG_MULTIXACT_ZERO_MEM_PAGE:
+		pageno, err := readSlruPageno(record, data)
+		if err != nil {
+			return nil, err
+		}
+		return &MultiXactZeroMemPage{Pageno: pageno}, nil
+	case XLOG_MULTIXACT_CREATE_ID:
+		xlrec, err := readStruct[XlMultixactCreate](record, data, SizeofXlMultixactCreate())
+		if err != nil {
+			return nil, err
+		}
+		members, err := readArray[MultiXactMember](record, data[SizeofXlMultixactCreate():], int(xlrec.Nmembers))
+		if err != nil {
+			return nil, err
+		}
+		return &MultiXactCreate{XlMultixactCreate: *xlrec, Members: members}, nil
+	case XLOG_MULTIXACT_TRUNCATE_ID:
+		xlrec, err := readStruct[XlMultixactTruncate](record, data, SizeofXlMultixactTruncate())
+		if err != nil {
+			return nil, err
+		}
+		return &MultiXactTruncate{XlMultixactTruncate: *xlrec}, nil
+	}
+	return nil, record.unknownInfo()
+}
@@ -0,0 +1,42 @@
+package wal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeMultiXactCreate(t *testing.T) {
+	decoded, err := DecodeRmgrData(testRecord(t, RM_MULTIXACT_ID, XLOG_MULTIXACT_CREATE_ID, le(
+		uint32(12), uint32(30), uint32(2),
+		uint32(900), uint32(MultiXactStatusForKeyShare), uint32(901), uint32(MultiXactStatusNoKeyUpdate))))
+	require.NoError(t, err)
+	create := decoded.(*MultiXactCreate)
+	assert.Equal(t, XlMultixactCreate{Mid: 12, Moff: 30, Nmembers: 2}, create.XlMultixactCreate)
+	assert.Equal(t, []MultiXactMember{{900, MultiXactStatusForKeyShare}, {901, MultiXactStatusNoKeyUpdate}}, create.Members)
+	assert.Equal(t, "901 (nokeyupd)", create.Members[1].String())
+
+	_, err = DecodeRmgrData(testRecord(t, RM_MULTIXACT_ID, XLOG_MULTIXACT_CREATE_ID, le(
+		uint32(12), uint32(30), uint32(2), uint32(900), uint32(0))))
+	assert.ErrorIs(t, err, ErrInvalidRecord)
+}
+
+func TestDecodeMultiXactZeroPage(t *testing.T) {
+	decoded, err := DecodeRmgrData(testRecord(t, RM_MULTIXACT_ID, XLOG_MULTIXACT_ZERO_MEM_PAGE, le(uint32(7))))
+	require.NoError(t, err)
+	assert.Equal(t, &MultiXactZeroMemPage{Pageno: 7}, decoded)
+
+	decoded, err = DecodeRmgrData(versioned(testRecord(t, RM_MULTIXACT_ID, XLOG_MULTIXACT_ZERO_OFF_PAGE, le(uint64(1<<33))), PG17))
+	require.NoError(t, err)
+	assert.Equal(t, &MultiXactZeroOffPage{Pageno: 1 << 33}, decoded)
+}
+
+func TestDecodeMultiXactTruncate(t *testing.T) {
+	decoded, err := DecodeRmgrData(testRecord(t, RM_MULTIXACT_ID, XLOG_MULTIXACT_TRUNCATE_ID, le(
+		uint32(5), uint32(1), uint32(100), uint32(0), uint32(250))))
+	require.NoError(t, err)
+	assert.Equal(t, &MultiXactTruncate{XlMultixactTruncate{
+		OldestMultiDB: 5, StartTruncOff: 1, EndTruncOff: 100, EndTruncMemb: 250,
+	}}, decoded)
+}
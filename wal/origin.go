@@ -0,0 +1,54 @@
+package wal
+
+/* XLOG stuff */
+const (
+	XLOG_REPLORIGIN_SET  = 0x00
+	XLOG_REPLORIGIN_DROP = 0x10
+)
+
+type XlReploriginSet struct {
+	RemoteLsn XLogRecPtr
+	NodeId    RepOriginId
+	Force     bool
+}
+
+func SizeofXlReploriginSet() int64 {
+	return 16
+}
+
+type XlReploriginDrop struct {
+	NodeId RepOriginId
+}
+
+func SizeofXlReploriginDrop() int64 {
+	return 2
+}
+
+type ReploriginSet struct {
+	XlReploriginSet
+}
+
+type ReploriginDrop struct {
+	XlReploriginDrop
+}
+
+// DecodeReplorigin decodes the records of RM_REPLORIGIN_ID into the struct
+// of their info code, e.g. *ReploriginSet.
+func DecodeReplorigin(record *Record) (interface{}, error) {
+	data := record.MainData
+	switch record.Info() {
+	case XLOG_REPLORIGIN_SET:
+		xlrec, err := readStruct[XlReploriginSet](record, data, SizeofXlReploriginSet())
+		if err != nil {
+			return nil, err
+		}
+		return &ReploriginSet{XlReploriginSet: *xlrec}, nil
+	case XLOG_REPLORIGIN_DROP:
+		xlrec, err := readStruct[XlReploriginDrop](record, data, SizeofXlReploriginDrop())
+		if err != nil {
+			return nil, err
+		}
+		return &ReploriginDrop{XlReploriginDrop: *xlrec}, nil
+	}
+	return nil, record.unknownInfo()
+}
@@ -0,0 +1,19 @@
+package wal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeReplorigin(t *testing.T) {
+	decoded, err := DecodeRmgrData(testRecord(t, RM_REPLORIGIN_ID, XLOG_REPLORIGIN_SET,
+		le(uint64(0x1_00000028), uint16(3), uint8(1), uint8(0), uint32(0))))
+	require.NoError(t, err)
+	assert.Equal(t, &ReploriginSet{XlReploriginSet{RemoteLsn: 0x1_00000028, NodeId: 3, Force: true}}, decoded)
+
+	decoded, err = DecodeRmgrData(testRecord(t, RM_REPLORIGIN_ID, XLOG_REPLORIGIN_DROP, le(uint16(3))))
+	require.NoError(t, err)
+	assert.Equal(t, &ReploriginDrop{XlReploriginDrop{NodeId: 3}}, decoded)
+}
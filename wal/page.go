@@ -6,6 +6,7 @@ import (
 )
 
 const (
+	// XLOG_PAGE_MAGIC of PostgreSQL 12, see VersionOfMagic for the others.
 	XLOG_PAGE_MAGIC = 0xD101
 
 	/* When record crosses page boundary, set this flag in new page's header */
@@ -49,8 +50,11 @@ func ReadXLogPageHeader(reader io.Reader) (XLogPageHeader, error) {
 	return &header, nil
 }
 
+// IsValidXLogPageHeader tells whether the page was written by a supported
+// server version.
 func IsValidXLogPageHeader(ptr XLogLongPageHeader) bool {
-	return ptr.Std.XlpMagic == XLOG_PAGE_MAGIC
+	_, ok := VersionOfMagic(ptr.Std.XlpMagic)
+	return ok
 }
 
 type XLogLongPageHeaderData struct {
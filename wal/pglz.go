@@ -0,0 +1,56 @@
+package wal
+
+import "errors"
+
+var errPglzCorrupt = errors.New("compressed pglz data is corrupt")
+
+// PglzDecompress decompresses src, compressed with the pglz algorithm of
+// PostgreSQL, into rawsize bytes. Like pglz_decompress with check_complete,
+// src must decompress to exactly rawsize bytes.
+func PglzDecompress(src []byte, rawsize int) ([]byte, error) {
+	dst := make([]byte, 0, rawsize)
+	sp := 0
+	for sp < len(src) && len(dst) < rawsize {
+		// each control bit tells whether the next item is a literal byte or
+		// a tag referencing earlier output
+		ctrl := src[sp]
+		sp++
+		for ctrlc := 0; ctrlc < 8 && sp < len(src) && len(dst) < rawsize; ctrlc++ {
+			if ctrl&1 == 0 {
+				dst = append(dst, src[sp])
+				sp++
+				ctrl >>= 1
+				continue
+			}
+			if sp+2 > len(src) {
+				return nil, errPglzCorrupt
+			}
+			length := int(src[sp]&0x0f) + 3
+			off := int(src[sp]&0xf0)<<4 | int(src[sp+1])
+			sp += 2
+			if length == 18 {
+				if sp >= len(src) {
+					return nil, errPglzCorrupt
+				}
+				length += int(src[sp])
+				sp++
+			}
+			if off == 0 || off > len(dst) {
+				return nil, errPglzCorrupt
+			}
+			if length > rawsize-len(dst) {
+				length = rawsize - len(dst)
+			}
+			// the referenced bytes may overlap the ones being written
+			for start := len(dst) - off; length > 0; length-- {
+				dst = append(dst, dst[start])
+				start++
+			}
+			ctrl >>= 1
+		}
+	}
+	if len(dst) != rawsize || sp != len(src) {
+		return nil, errPglzCorrupt
+	}
+	return dst, nil
+}
@@ -5,6 +5,8 @@
 package wal
 
 import (
+	"fmt"
+	"hash/crc32"
 	"io"
 	"unsafe"
 )
@@ -45,6 +47,9 @@ func SizeofXLogRecord() int64 {
 	return 24
 }
 
+// XLogRecordMaxSize is the upper bound of XlTotlen.
+const XLogRecordMaxSize = 1020 * 1024 * 1024
+
 func ReadXLogRecord(reader io.Reader) (*XLogRecord, error) {
 	var (
 		record  XLogRecord
@@ -60,6 +65,17 @@ func ReadXLogRecord(reader io.Reader) (*XLogRecord, error) {
 	return &record, nil
 }
 
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// XLogRecordCRC computes the CRC-32C of a record the way XLogInsert does,
+// over the data following the header first and then over the header up to
+// xl_crc. hdr is the raw XLogRecord header.
+func XLogRecordCRC(hdr []byte, data []byte) PgCrc32c {
+	crc := crc32.Checksum(data, castagnoli)
+	crc = crc32.Update(crc, castagnoli, hdr[:unsafe.Offsetof(XLogRecord{}.XlCrc)])
+	return PgCrc32c(crc)
+}
+
 const (
 	XLR_MAX_BLOCK_ID uint8 = 32
 
@@ -114,6 +130,31 @@ func (h *XLogRecordBlockHeader) HasFileNode() bool {
 	return h.ForkFlags&BKPBLOCK_FLAG_MASK&BKPBLOCK_SAME_REL == 0
 }
 
+// ForkNum returns the fork of the relation the block belongs to.
+func (h *XLogRecordBlockHeader) ForkNum() ForkNumber {
+	return ForkNumber(h.ForkFlags & BKPBLOCK_FORK_MASK)
+}
+
+type ForkNumber int32
+
+const (
+	MAIN_FORKNUM ForkNumber = iota
+	FSM_FORKNUM
+	VISIBILITYMAP_FORKNUM
+	INIT_FORKNUM
+
+	MAX_FORKNUM = INIT_FORKNUM
+)
+
+var forkNames = []string{"main", "fsm", "vm", "init"}
+
+func (f ForkNumber) String() string {
+	if f < 0 || f > MAX_FORKNUM {
+		return fmt.Sprintf("fork%d", int32(f))
+	}
+	return forkNames[f]
+}
+
 func ReadXLogRecordBlockHeader(reader io.Reader, id uint8) (*XLogRecordBlockHeader, error) {
 	var header XLogRecordBlockHeader
 	buf := make([]byte, SizeofXLogRecordBlockHeader())
@@ -130,14 +171,19 @@ func ReadXLogRecordBlockHeader(reader io.Reader, id uint8) (*XLogRecordBlockHead
 const (
 	/* Information stored in bimg_info */
 	BKPIMAGE_HAS_HOLE = 0x01 /* page image has "hole" */
-	// BKPIMAGE_APPLY    = 0x02 /* page image should be restored
-	//  * during replay */
-	// /* compression methods supported */
-	// BKPIMAGE_COMPRESS_PGLZ = 0x04
-	// BKPIMAGE_COMPRESS_LZ4  = 0x08
-	// BKPIMAGE_COMPRESS_ZSTD = 0x10
-
-	BKPIMAGE_IS_COMPRESSED = 0x02
+
+	/* Up to PostgreSQL 14 */
+	BKPIMAGE_IS_COMPRESSED = 0x02 /* page image is compressed */
+	BKPIMAGE_APPLY_PRE15   = 0x04 /* page image should be restored during
+	 * replay */
+
+	/* Since PostgreSQL 15 */
+	BKPIMAGE_APPLY = 0x02 /* page image should be restored
+	 * during replay */
+	/* compression methods supported */
+	BKPIMAGE_COMPRESS_PGLZ = 0x04
+	BKPIMAGE_COMPRESS_LZ4  = 0x08
+	BKPIMAGE_COMPRESS_ZSTD = 0x10
 )
 
 type XLogRecordBlockImageHeader struct {
@@ -159,14 +205,30 @@ func (h *XLogRecordBlockImageHeader) HasHole() bool {
 	return h.BimgInfo&BKPIMAGE_HAS_HOLE == BKPIMAGE_HAS_HOLE
 }
 
-// func (h *XLogRecordBlockImageHeader) HasCompressed() bool {
-// 	return (h.BimgInfo & (BKPIMAGE_COMPRESS_PGLZ | BKPIMAGE_COMPRESS_LZ4 | BKPIMAGE_COMPRESS_ZSTD)) != 0
-// }
-
+// HasCompressed reads BimgInfo the way PostgreSQL 12 to 14 do, see
+// IsCompressed for the other versions.
 func (h *XLogRecordBlockImageHeader) HasCompressed() bool {
 	return h.BimgInfo&BKPIMAGE_IS_COMPRESSED == BKPIMAGE_IS_COMPRESSED
 }
 
+// IsCompressed tells whether the image is compressed, BimgInfo being written
+// by version v.
+func (h *XLogRecordBlockImageHeader) IsCompressed(v PgVersion) bool {
+	if v < PG15 {
+		return h.HasCompressed()
+	}
+	return (h.BimgInfo & (BKPIMAGE_COMPRESS_PGLZ | BKPIMAGE_COMPRESS_LZ4 | BKPIMAGE_COMPRESS_ZSTD)) != 0
+}
+
+// IsApply tells whether the image is to be restored during replay, BimgInfo
+// being written by version v.
+func (h *XLogRecordBlockImageHeader) IsApply(v PgVersion) bool {
+	if v < PG15 {
+		return h.BimgInfo&BKPIMAGE_APPLY_PRE15 != 0
+	}
+	return h.BimgInfo&BKPIMAGE_APPLY != 0
+}
+
 func ReadXLogRecordBlockImageHeader(reader io.Reader) (*XLogRecordBlockImageHeader, error) {
 	var header XLogRecordBlockImageHeader
 	buf := make([]byte, SizeofXLogRecordBlockImageHeader())
@@ -205,6 +267,10 @@ type RelFileNode struct {
 	RelNode Oid /* relation */
 }
 
+// RelFileLocator is the name PostgreSQL 16 gave to RelFileNode, the layout
+// did not change.
+type RelFileLocator = RelFileNode
+
 func SizeofRelFileNode() int64 {
 	return 12
 }
@@ -257,11 +323,29 @@ func ReadRepOriginDummy(reader io.Reader, id uint8) (*RepOriginDummy, error) {
 	if err != nil {
 		return nil, err
 	}
-	ptr := (*RepOriginDummy)(unsafe.Pointer(&buf[0]))
-	header = *ptr
+	// the origin is unaligned in the record, unlike in RepOriginDummy
+	header.Id = id
+	header.RepOriginId = *(*RepOriginId)(unsafe.Pointer(&buf[1]))
 	return &header, nil
 }
 
+func SizeofTransactionId() int64 {
+	return 4
+}
+
+// ReadTransactionId reads the xid following XLR_BLOCK_ID_TOPLEVEL_XID.
+func ReadTransactionId(reader io.Reader) (TransactionId, error) {
+	var xid TransactionId
+	buf := make([]byte, SizeofTransactionId())
+	_, err := io.ReadFull(reader, buf)
+	if err != nil {
+		return 0, err
+	}
+	ptr := (*TransactionId)(unsafe.Pointer(&buf[0]))
+	xid = *ptr
+	return xid, nil
+}
+
 type XLogRecordDataHeaderShort struct {
 	Id         uint8 /* XLR_BLOCK_ID_DATA_SHORT */
 	DataLength uint8 /* number of payload bytes */
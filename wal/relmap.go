@@ -0,0 +1,107 @@
+package wal
+
+/* ----------------
+ *		relmap-related XLOG entries
+ * ----------------
+ */
+const XLOG_RELMAP_UPDATE = 0x00
+
+type XlRelmapUpdate struct {
+	Dbid   Oid   /* database ID, or 0 for shared map */
+	Tsid   Oid   /* database's tablespace, or pg_global */
+	Nbytes int32 /* size of relmap data */
+	/* char data[FLEXIBLE_ARRAY_MEMBER] follows */
+}
+
+func SizeofXlRelmapUpdate() int64 {
+	return 12
+}
+
+const RELMAPPER_FILEMAGIC = 0x592717 /* version ID value */
+
+// RelMapMaxMappings returns MAX_MAPPINGS, the room of a relation mapper
+// file, 62 up to PostgreSQL 15 to keep the file 512 bytes.
+func RelMapMaxMappings(v PgVersion) int {
+	if v >= PG16 {
+		return 64
+	}
+	return 62
+}
+
+// SizeofRelMapFile returns sizeof(RelMapFile), the pad making it 512 bytes
+// up to PostgreSQL 15.
+func SizeofRelMapFile(v PgVersion) int64 {
+	if v >= PG16 {
+		return int64(8 + RelMapMaxMappings(v)*8 + 4)
+	}
+	return 512
+}
+
+type RelMapping struct {
+	MapOid      Oid /* OID of a catalog */
+	MapFilenode Oid /* its rel file number */
+}
+
+/*
+ * The map file is critical data: we have no automatic method for recovering
+ * from loss or corruption of it.  We use a CRC so that we can detect
+ * corruption.
+ *
+ * Mappings holds the num_mappings valid entries of the mappings array.
+ */
+type RelMapFile struct {
+	Magic       int32 /* always RELMAPPER_FILEMAGIC */
+	NumMappings int32 /* number of valid RelMapping entries */
+	Mappings    []RelMapping
+	Crc         PgCrc32c /* CRC of all above */
+}
+
+type RelmapUpdate struct {
+	XlRelmapUpdate
+	Map RelMapFile
+	// Data is the image of the file as logged.
+	Data []byte
+}
+
+// DecodeRelmap decodes the records of RM_RELMAP_ID into the struct of their
+// info code, *RelmapUpdate.
+func DecodeRelmap(record *Record) (interface{}, error) {
+	data := record.MainData
+	switch record.Info() {
+	case XLOG_RELMAP_UPDATE:
+		xlrec, err := readStruct[XlRelmapUpdate](record, data, SizeofXlRelmapUpdate())
+		if err != nil {
+			return nil, err
+		}
+		image := data[SizeofXlRelmapUpdate():]
+		if int64(xlrec.Nbytes) != SizeofRelMapFile(record.Version) {
+			return nil, record.errorf("wrong size %d in relmap update record", xlrec.Nbytes)
+		}
+		if len(image) < int(xlrec.Nbytes) {
+			return nil, record.tooShort(image, "a relation mapper file")
+		}
+		image = image[:xlrec.Nbytes]
+		header, err := readArray[int32](record, image, 2)
+		if err != nil {
+			return nil, err
+		}
+		maxMappings := RelMapMaxMappings(record.Version)
+		if header[1] < 0 || int(header[1]) > maxMappings {
+			return nil, record.errorf("invalid number of relation mappings %d", header[1])
+		}
+		mappings, err := readArray[RelMapping](record, image[8:], int(header[1]))
+		if err != nil {
+			return nil, err
+		}
+		crc, err := readStruct[PgCrc32c](record, image[8+maxMappings*8:], 4)
+		if err != nil {
+			return nil, err
+		}
+		return &RelmapUpdate{
+			XlRelmapUpdate: *xlrec,
+			Map:            RelMapFile{Magic: header[0], NumMappings: header[1], Mappings: mappings, Crc: *crc},
+			Data:           image,
+		}, nil
+	}
+	return nil, record.unknownInfo()
+}
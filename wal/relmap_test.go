@@ -0,0 +1,42 @@
+package wal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func relMapFile(v PgVersion, mappings ...RelMapping) []byte {
+	image := le(uint32(RELMAPPER_FILEMAGIC), uint32(len(mappings)))
+	for _, m := range mappings {
+		image = append(image, le(uint32(m.MapOid), uint32(m.MapFilenode))...)
+	}
+	image = append(image, make([]byte, 8+RelMapMaxMappings(v)*8-len(image))...)
+	image = append(image, le(uint32(0xC0FFEE))...)
+	return append(image, make([]byte, int(SizeofRelMapFile(v))-len(image))...)
+}
+
+func TestDecodeRelmap(t *testing.T) {
+	for _, v := range []PgVersion{PG15, PG16} {
+		image := relMapFile(v, RelMapping{1259, 1259}, RelMapping{1249, 16390})
+		decoded, err := DecodeRmgrData(versioned(testRecord(t, RM_RELMAP_ID, XLOG_RELMAP_UPDATE,
+			le(uint32(5), uint32(1663), uint32(len(image)), image)), v))
+		require.NoError(t, err)
+		assert.Equal(t, &RelmapUpdate{
+			XlRelmapUpdate: XlRelmapUpdate{Dbid: 5, Tsid: 1663, Nbytes: int32(len(image))},
+			Map: RelMapFile{
+				Magic:       RELMAPPER_FILEMAGIC,
+				NumMappings: 2,
+				Mappings:    []RelMapping{{1259, 1259}, {1249, 16390}},
+				Crc:         0xC0FFEE,
+			},
+			Data: image,
+		}, decoded)
+	}
+
+	image := relMapFile(PG16)
+	_, err := DecodeRmgrData(versioned(testRecord(t, RM_RELMAP_ID, XLOG_RELMAP_UPDATE,
+		le(uint32(5), uint32(1663), uint32(len(image)), image)), PG15))
+	assert.ErrorIs(t, err, ErrInvalidRecord)
+}
@@ -31,6 +31,9 @@ var name = map[RmgrId]string{
 
 func RmgrIdName(id RmgrId) string {
 	v, ok := name[id]
+	if !ok && id >= RM_MIN_CUSTOM_ID {
+		return fmt.Sprintf("custom%03d", id)
+	}
 	if !ok {
 		return fmt.Sprintf("unknown %d", id)
 	}
@@ -60,4 +63,10 @@ const (
 	RM_REPLORIGIN_ID
 	RM_GENERIC_ID
 	RM_LOGICALMSG_ID
+
+	RM_MAX_ID = RM_LOGICALMSG_ID
+
+	/* Since PostgreSQL 15 extensions may use these ids */
+	RM_MIN_CUSTOM_ID RmgrId = 128
+	RM_MAX_CUSTOM_ID RmgrId = 255
 )
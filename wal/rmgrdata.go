@@ -0,0 +1,152 @@
+package wal
+
+import (
+	"fmt"
+	"unsafe"
+)
+
+// rmgrDecoders decode the main data and block data of the records of a
+// resource manager, see DecodeRmgrData.
+var rmgrDecoders = map[RmgrId]func(record *Record) (interface{}, error){
+	RM_BRIN_ID:       DecodeBrin,
+	RM_BTREE_ID:      DecodeBtree,
+	RM_CLOG_ID:       DecodeClog,
+	RM_COMMIT_TS_ID:  DecodeCommitTs,
+	RM_DBASE_ID:      DecodeDbase,
+	RM_GENERIC_ID:    DecodeGeneric,
+	RM_GIN_ID:        DecodeGin,
+	RM_GIST_ID:       DecodeGist,
+	RM_HASH_ID:       DecodeHash,
+	RM_HEAP2_ID:      DecodeHeap2,
+	RM_HEAP_ID:       DecodeHeap,
+	RM_LOGICALMSG_ID: DecodeLogicalMsg,
+	RM_MULTIXACT_ID:  DecodeMultiXact,
+	RM_RELMAP_ID:     DecodeRelmap,
+	RM_REPLORIGIN_ID: DecodeReplorigin,
+	RM_SEQ_ID:        DecodeSequence,
+	RM_SMGR_ID:       DecodeSmgr,
+	RM_SPGIST_ID:     DecodeSpgist,
+	RM_STANDBY_ID:    DecodeStandby,
+	RM_TBLSPC_ID:     DecodeTblspc,
+	RM_XACT_ID:       DecodeXact,
+	RM_XLOG_ID:       DecodeXLog,
+}
+
+// DecodeRmgrData decodes the resource manager specific part of record into
+// the typed struct of its info code, e.g. a *HeapInsert for an insert into a
+// heap. ErrInvalidRecord is returned for a resource manager nobody decodes
+// yet, or an info code it does not know.
+func DecodeRmgrData(record *Record) (interface{}, error) {
+	decode, ok := rmgrDecoders[record.Hdr.XlRmid]
+	if !ok {
+		return nil, record.errorf("no decoder for resource manager %s", RmgrIdName(record.Hdr.XlRmid))
+	}
+	return decode(record)
+}
+
+// Info returns the info bits of the record the resource manager owns.
+func (r *Record) Info() uint8 {
+	return r.Hdr.XlInfo & XLR_RMGR_INFO_MASK
+}
+
+// Block returns the block reference id of the record, like
+// XLogRecGetBlockTag.
+func (r *Record) Block(id uint8) (*Block, bool) {
+	for i := range r.Blocks {
+		if r.Blocks[i].Bheader.Id == id {
+			return &r.Blocks[i], true
+		}
+	}
+	return nil, false
+}
+
+// BlockData returns the data registered with block reference id, nil when
+// there is none, like XLogRecGetBlockData.
+func (r *Record) BlockData(id uint8) []byte {
+	block, ok := r.Block(id)
+	if !ok {
+		return nil
+	}
+	return block.TupleData
+}
+
+func (r *Record) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s at %s", ErrInvalidRecord, fmt.Sprintf(format, args...), r.LSN)
+}
+
+// tooShort reports data too short for what it must hold.
+func (r *Record) tooShort(data []byte, what string) error {
+	return r.errorf("%d bytes of %s data too short for %s", len(data), RmgrIdName(r.Hdr.XlRmid), what)
+}
+
+func (r *Record) unknownInfo() error {
+	return r.errorf("unknown %s info code 0x%02X", RmgrIdName(r.Hdr.XlRmid), r.Info())
+}
+
+// readStruct decodes the C struct T at the start of data, size being the
+// number of bytes the struct takes up in WAL, usually the SizeOf macro of
+// the struct. The trailing padding of T is not logged.
+func readStruct[T any](r *Record, data []byte, size int64) (*T, error) {
+	var ret T
+	if size > int64(unsafe.Sizeof(ret)) {
+		panic("size larger than the struct")
+	}
+	if int64(len(data)) < size {
+		return nil, r.errorf("%d bytes of %s data too short for %T", len(data), RmgrIdName(r.Hdr.XlRmid), ret)
+	}
+	copy(unsafe.Slice((*byte)(unsafe.Pointer(&ret)), size), data)
+	return &ret, nil
+}
+
+// readArray decodes the n consecutive Ts at the start of data.
+func readArray[T any](r *Record, data []byte, n int) ([]T, error) {
+	var elem T
+	size := int(unsafe.Sizeof(elem))
+	if n < 0 || len(data)/size < n {
+		return nil, r.errorf("%d bytes of %s data too short for %d %T", len(data), RmgrIdName(r.Hdr.XlRmid), n, elem)
+	}
+	ret := make([]T, n)
+	if n > 0 {
+		copy(unsafe.Slice((*byte)(unsafe.Pointer(&ret[0])), n*size), data)
+	}
+	return ret, nil
+}
+
+// readCountedArray decodes an int32 count followed by as many Ts, as in
+// xl_xact_subxacts, and returns the data following them.
+func readCountedArray[T any](r *Record, data []byte) ([]T, []byte, error) {
+	n, err := readStruct[int32](r, data, 4)
+	if err != nil {
+		return nil, nil, err
+	}
+	ret, err := readArray[T](r, data[4:], int(*n))
+	if err != nil {
+		return nil, nil, err
+	}
+	var elem T
+	return ret, data[4+len(ret)*int(unsafe.Sizeof(elem)):], nil
+}
+
+// maxAlign rounds n up like MAXALIGN.
+func maxAlign(n int) int {
+	return (n + 7) &^ 7
+}
+
+const INDEX_SIZE_MASK = 0x1FFF /* IndexTupleData t_info size bits */
+
+// readIndexTuple splits the IndexTuple at the start of data from the data
+// following it, its IndexTupleSize being MAXALIGNed if aligned.
+func readIndexTuple(r *Record, data []byte, aligned bool) ([]byte, []byte, error) {
+	// t_info follows the 6 bytes of t_tid
+	if len(data) < 8 {
+		return nil, nil, r.errorf("%d bytes of %s data too short for an index tuple", len(data), RmgrIdName(r.Hdr.XlRmid))
+	}
+	size := int(*(*uint16)(unsafe.Pointer(&data[6])) & INDEX_SIZE_MASK)
+	if aligned {
+		size = maxAlign(size)
+	}
+	if size < 8 || size > len(data) {
+		return nil, nil, r.errorf("invalid %s index tuple size %d", RmgrIdName(r.Hdr.XlRmid), size)
+	}
+	return data[:size], data[size:], nil
+}
@@ -0,0 +1,71 @@
+package wal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testRecord decodes a record of rmid whose block reference i carries
+// blockData[i], a nil one being registered without data.
+func testRecord(t *testing.T, rmid RmgrId, info uint8, main []byte, blockData ...[]byte) *Record {
+	var (
+		headers []byte
+		payload []byte
+	)
+	rfn := &RelFileNode{1663, 5, 16384}
+	for i, data := range blockData {
+		flags := uint8(0)
+		if data != nil {
+			flags |= BKPBLOCK_HAS_DATA
+		}
+		if i > 0 {
+			flags |= BKPBLOCK_SAME_REL
+			rfn = nil
+		}
+		headers = append(headers, blockHeader(uint8(i), flags, rfn, BlockNumber(i), uint16(len(data)))...)
+		payload = append(payload, data...)
+	}
+	if main != nil {
+		hdr := mainData(main)
+		headers = append(headers, hdr[:len(hdr)-len(main)]...)
+		payload = append(payload, main...)
+	}
+	raw := rawRecord(concat(headers, payload))
+	raw.Hdr.XlRmid = rmid
+	raw.Hdr.XlInfo = info
+	record, err := raw.Decode()
+	require.NoError(t, err)
+	return record
+}
+
+// le encodes values the way a little-endian C compiler lays out a struct
+// without padding.
+func le(values ...interface{}) []byte {
+	var ret []byte
+	for _, value := range values {
+		switch v := value.(type) {
+		case uint8:
+			ret = append(ret, v)
+		case uint16:
+			ret = append(ret, byte(v), byte(v>>8))
+		case uint32:
+			ret = append(ret, byte(v), byte(v>>8), byte(v>>16), byte(v>>24))
+		case uint64:
+			ret = append(ret, le(uint32(v), uint32(v>>32))...)
+		case []byte:
+			ret = append(ret, v...)
+		default:
+			panic("unsupported type")
+		}
+	}
+	return ret
+}
+
+func TestDecodeRmgrDataUnknown(t *testing.T) {
+	_, err := DecodeRmgrData(testRecord(t, RM_MIN_CUSTOM_ID, 0, []byte{1}))
+	assert.ErrorIs(t, err, ErrInvalidRecord)
+	_, err = DecodeRmgrData(testRecord(t, RM_HEAP_ID, XLOG_HEAP_INSERT, []byte{1}))
+	assert.ErrorIs(t, err, ErrInvalidRecord)
+}
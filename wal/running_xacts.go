@@ -0,0 +1,253 @@
+package wal
+
+import (
+	"errors"
+	"sort"
+)
+
+// RunningXact is a transaction in progress.
+type RunningXact struct {
+	Xid TransactionId
+	// FirstLSN is the first record of the transaction the tracker saw,
+	// zero when it is only known from a running xacts snapshot.
+	FirstLSN XLogRecPtr
+	Subxids  []TransactionId
+	Prepared bool
+}
+
+// RunningXactsTracker follows the transactions in progress as records are
+// added in LSN order, the way hot standby rebuilds them from the
+// XLOG_RUNNING_XACTS snapshots and the commit and abort records.
+type RunningXactsTracker struct {
+	lsn   XLogRecPtr
+	valid bool
+	xacts map[TransactionId]*RunningXact
+	// subxacts maps subtransactions to their top-level transaction,
+	// InvalidTransactionId when a snapshot did not tell which it is.
+	subxacts map[TransactionId]TransactionId
+	// ended holds the transactions ended since the last snapshot, which may
+	// still be listed by the next one as it is not logged atomically.
+	ended map[TransactionId]struct{}
+}
+
+func NewRunningXactsTracker() *RunningXactsTracker {
+	return &RunningXactsTracker{
+		xacts:    make(map[TransactionId]*RunningXact),
+		subxacts: make(map[TransactionId]TransactionId),
+		ended:    make(map[TransactionId]struct{}),
+	}
+}
+
+// LSN returns the LSN of the last record added.
+func (t *RunningXactsTracker) LSN() XLogRecPtr {
+	return t.lsn
+}
+
+// Valid tells whether a running xacts snapshot has been added, before that
+// only the transactions which wrote WAL since the first record are known.
+func (t *RunningXactsTracker) Valid() bool {
+	return t.valid
+}
+
+// Running returns the top-level transactions in progress after the last
+// record added, ordered by xid.
+func (t *RunningXactsTracker) Running() []RunningXact {
+	ret := make([]RunningXact, 0, len(t.xacts))
+	index := make(map[TransactionId]int, len(t.xacts))
+	for xid, xact := range t.xacts {
+		index[xid] = len(ret)
+		ret = append(ret, RunningXact{Xid: xid, FirstLSN: xact.FirstLSN, Prepared: xact.Prepared})
+	}
+	for sub, top := range t.subxacts {
+		if i, ok := index[top]; ok {
+			ret[i].Subxids = append(ret[i].Subxids, sub)
+		}
+	}
+	for i := range ret {
+		sort.Slice(ret[i].Subxids, func(a, b int) bool { return ret[i].Subxids[a].Precedes(ret[i].Subxids[b]) })
+	}
+	sort.Slice(ret, func(a, b int) bool { return ret[a].Xid.Precedes(ret[b].Xid) })
+	return ret
+}
+
+// Add applies the next record.
+func (t *RunningXactsTracker) Add(record *Record) error {
+	t.lsn = record.LSN
+	switch record.Hdr.XlRmid {
+	case RM_XACT_ID:
+		switch record.Info() & XLOG_XACT_OPMASK {
+		case XLOG_XACT_COMMIT, XLOG_XACT_COMMIT_PREPARED, XLOG_XACT_ABORT, XLOG_XACT_ABORT_PREPARED:
+			decoded, err := DecodeRmgrData(record)
+			if err != nil {
+				return err
+			}
+			switch rec := decoded.(type) {
+			case *XactCommit:
+				t.end(xactXid(record, rec.Twophase), rec.Subxacts)
+			case *XactAbort:
+				t.end(xactXid(record, rec.Twophase), rec.Subxacts)
+			}
+			return nil
+		case XLOG_XACT_PREPARE:
+			if xact := t.start(record); xact != nil {
+				xact.Prepared = true
+			}
+			return nil
+		case XLOG_XACT_ASSIGNMENT:
+			decoded, err := DecodeRmgrData(record)
+			if err != nil {
+				return err
+			}
+			rec := decoded.(*XactAssignment)
+			t.assign(rec.Xtop, rec.Xsub, record.LSN)
+			return nil
+		}
+	case RM_STANDBY_ID:
+		if record.Info() == XLOG_RUNNING_XACTS {
+			decoded, err := DecodeRmgrData(record)
+			if err != nil {
+				return err
+			}
+			t.snapshot(decoded.(*StandbyRunningXacts))
+			return nil
+		}
+	case RM_XLOG_ID:
+		if record.Info() == XLOG_CHECKPOINT_SHUTDOWN {
+			// only prepared transactions survive a shutdown
+			for xid, xact := range t.xacts {
+				if !xact.Prepared {
+					t.end(xid, nil)
+				}
+			}
+			for sub, top := range t.subxacts {
+				if _, ok := t.xacts[top]; !ok {
+					delete(t.subxacts, sub)
+				}
+			}
+		}
+	}
+	t.start(record)
+	return nil
+}
+
+// start records that the transaction of record is in progress and returns
+// its top-level transaction, nil for a record without xid.
+func (t *RunningXactsTracker) start(record *Record) *RunningXact {
+	xid := record.Hdr.XlXid
+	if xid == InvalidTransactionId {
+		return nil
+	}
+	if record.TopLevelXid != InvalidTransactionId {
+		t.assign(record.TopLevelXid, []TransactionId{xid}, record.LSN)
+		return t.xacts[record.TopLevelXid]
+	}
+	if top, ok := t.subxacts[xid]; ok {
+		return t.xacts[top]
+	}
+	xact, ok := t.xacts[xid]
+	if !ok {
+		xact = &RunningXact{Xid: xid, FirstLSN: record.LSN}
+		t.xacts[xid] = xact
+	}
+	return xact
+}
+
+func (t *RunningXactsTracker) assign(top TransactionId, subxids []TransactionId, lsn XLogRecPtr) {
+	xact, ok := t.xacts[top]
+	if !ok {
+		xact = &RunningXact{Xid: top, FirstLSN: lsn}
+		t.xacts[top] = xact
+	}
+	for _, sub := range subxids {
+		if subxact, ok := t.xacts[sub]; ok {
+			// the subtransaction wrote WAL before being assigned
+			if subxact.FirstLSN != 0 && (xact.FirstLSN == 0 || subxact.FirstLSN < xact.FirstLSN) {
+				xact.FirstLSN = subxact.FirstLSN
+			}
+			delete(t.xacts, sub)
+		}
+		t.subxacts[sub] = top
+	}
+}
+
+func (t *RunningXactsTracker) end(xid TransactionId, subxids []TransactionId) {
+	for _, sub := range subxids {
+		// a subtransaction that wrote WAL without being assigned is
+		// tracked as a top-level transaction
+		delete(t.xacts, sub)
+		delete(t.subxacts, sub)
+		t.ended[sub] = struct{}{}
+	}
+	delete(t.xacts, xid)
+	delete(t.subxacts, xid)
+	t.ended[xid] = struct{}{}
+}
+
+// snapshot replaces the transactions in progress by those of a running
+// xacts record, keeping what is already known about them.
+func (t *RunningXactsTracker) snapshot(rec *StandbyRunningXacts) {
+	xacts := make(map[TransactionId]*RunningXact, len(rec.Xids))
+	subxacts := make(map[TransactionId]TransactionId, len(rec.Subxids))
+	for _, xid := range rec.Xids {
+		if _, ok := t.ended[xid]; ok {
+			continue
+		}
+		if xact, ok := t.xacts[xid]; ok {
+			xacts[xid] = xact
+		} else if _, ok := t.subxacts[xid]; !ok {
+			xacts[xid] = &RunningXact{Xid: xid}
+		}
+	}
+	for _, xid := range rec.Subxids {
+		if _, ok := t.ended[xid]; !ok {
+			subxacts[xid] = InvalidTransactionId
+		}
+	}
+	// transactions which started after the snapshot was taken, but wrote
+	// their records before it was logged, are not listed
+	for xid, xact := range t.xacts {
+		if !xid.Precedes(rec.NextXid) {
+			xacts[xid] = xact
+		}
+	}
+	for sub, top := range t.subxacts {
+		if _, ok := xacts[top]; ok {
+			subxacts[sub] = top
+		} else if _, ok := subxacts[sub]; ok {
+			subxacts[sub] = top
+		}
+	}
+	t.xacts = xacts
+	t.subxacts = subxacts
+	t.ended = make(map[TransactionId]struct{})
+	t.valid = true
+}
+
+// TrackRunningXacts adds the records reader reads before lsn to a new
+// tracker, which then knows the transactions in progress at lsn. The record
+// at or after lsn which stopped the tracking is left for the next
+// ReadRecord. If the WAL ends before lsn, the tracker built so far is
+// returned along with the ErrEndOfWAL error.
+func TrackRunningXacts(reader *XLogReader, lsn XLogRecPtr) (*RunningXactsTracker, error) {
+	tracker := NewRunningXactsTracker()
+	for {
+		raw, err := reader.ReadRecord()
+		if errors.Is(err, ErrEndOfWAL) {
+			return tracker, err
+		}
+		if err != nil {
+			return nil, err
+		}
+		if raw.LSN >= lsn {
+			reader.pending = raw
+			return tracker, nil
+		}
+		record, err := raw.Decode()
+		if err != nil {
+			return nil, err
+		}
+		if err := tracker.Add(record); err != nil {
+			return nil, err
+		}
+	}
+}
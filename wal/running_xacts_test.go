@@ -0,0 +1,97 @@
+package wal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func xactRecord(t *testing.T, lsn XLogRecPtr, xid TransactionId, rmid RmgrId, info uint8, main []byte) *Record {
+	record := testRecord(t, rmid, info, main)
+	record.LSN = lsn
+	record.Hdr.XlXid = xid
+	return record
+}
+
+func TestRunningXactsTracker(t *testing.T) {
+	tracker := NewRunningXactsTracker()
+	add := func(record *Record) {
+		require.NoError(t, tracker.Add(record))
+	}
+
+	add(xactRecord(t, 0x1000, 900, RM_HEAP_ID, XLOG_HEAP_INSERT, []byte{1, 0, 0}))
+	add(xactRecord(t, 0x1100, 902, RM_HEAP_ID, XLOG_HEAP_INSERT, []byte{1, 0, 0}))
+	assert.False(t, tracker.Valid())
+	assert.Equal(t, []RunningXact{{Xid: 900, FirstLSN: 0x1000}, {Xid: 902, FirstLSN: 0x1100}}, tracker.Running())
+
+	// 902 is a subtransaction of 901, which wrote nothing yet
+	add(xactRecord(t, 0x1200, 0, RM_XACT_ID, XLOG_XACT_ASSIGNMENT, le(uint32(901), uint32(1), uint32(902))))
+	assert.Equal(t, []RunningXact{
+		{Xid: 900, FirstLSN: 0x1000},
+		{Xid: 901, FirstLSN: 0x1100, Subxids: []TransactionId{902}},
+	}, tracker.Running())
+
+	// 900 committed before the snapshot was logged, 890 was running from
+	// before the first record
+	add(xactRecord(t, 0x1300, 900, RM_XACT_ID, XLOG_XACT_COMMIT, le(uint64(42))))
+	add(xactRecord(t, 0x1400, 0, RM_STANDBY_ID, XLOG_RUNNING_XACTS, le(
+		uint32(3), uint32(1), uint32(0), uint32(903), uint32(890), uint32(899),
+		uint32(890), uint32(900), uint32(901), uint32(902))))
+	assert.True(t, tracker.Valid())
+	assert.Equal(t, XLogRecPtr(0x1400), tracker.LSN())
+	assert.Equal(t, []RunningXact{
+		{Xid: 890},
+		{Xid: 901, FirstLSN: 0x1100, Subxids: []TransactionId{902}},
+	}, tracker.Running())
+
+	add(xactRecord(t, 0x1500, 890, RM_XACT_ID, XLOG_XACT_PREPARE, le(uint32(0))))
+	add(xactRecord(t, 0x1600, 901, RM_XACT_ID, XLOG_XACT_ABORT|XLOG_XACT_HAS_INFO, le(
+		uint64(43), uint32(XACT_XINFO_HAS_SUBXACTS), uint32(1), uint32(902))))
+	assert.Equal(t, []RunningXact{{Xid: 890, Prepared: true}}, tracker.Running())
+
+	add(xactRecord(t, 0x1700, 0, RM_XACT_ID, XLOG_XACT_COMMIT_PREPARED|XLOG_XACT_HAS_INFO, le(
+		uint64(44), uint32(XACT_XINFO_HAS_TWOPHASE), uint32(890))))
+	assert.Empty(t, tracker.Running())
+}
+
+func TestRunningXactsTrackerUnassignedSubxact(t *testing.T) {
+	tracker := NewRunningXactsTracker()
+	require.NoError(t, tracker.Add(xactRecord(t, 0x1000, 900, RM_HEAP_ID, XLOG_HEAP_INSERT, []byte{1, 0, 0})))
+	require.NoError(t, tracker.Add(xactRecord(t, 0x1100, 901, RM_HEAP_ID, XLOG_HEAP_INSERT, []byte{1, 0, 0})))
+	assert.Equal(t, []RunningXact{{Xid: 900, FirstLSN: 0x1000}, {Xid: 901, FirstLSN: 0x1100}}, tracker.Running())
+
+	// 901 is a subtransaction of 900 that was never assigned
+	require.NoError(t, tracker.Add(xactRecord(t, 0x1200, 900, RM_XACT_ID, XLOG_XACT_COMMIT|XLOG_XACT_HAS_INFO, le(
+		uint64(42), uint32(XACT_XINFO_HAS_SUBXACTS), uint32(1), uint32(901)))))
+	assert.Empty(t, tracker.Running())
+}
+
+func TestTrackRunningXacts(t *testing.T) {
+	b := newWalBuilderVersion(1, 0x300000, PG16)
+	b.record(RM_HEAP_ID, XLOG_HEAP_INSERT, 800, mainData([]byte{1, 0, 0}))
+	insert := b.record(RM_HEAP_ID, XLOG_HEAP_INSERT, 801, mainData([]byte{1, 0, 0}))
+	b.record(RM_XACT_ID, XLOG_XACT_COMMIT, 800, mainData(le(uint64(42))))
+	commit := b.record(RM_XACT_ID, XLOG_XACT_COMMIT, 801, mainData(le(uint64(43))))
+	last := b.record(RM_HEAP_ID, XLOG_HEAP_INSERT, 802, mainData([]byte{1, 0, 0}))
+
+	reader, err := NewXLogReaderDir(b.dump(t), "000000010000000000000003", 8)
+	require.NoError(t, err)
+	defer reader.Close()
+	tracker, err := TrackRunningXacts(reader, commit)
+	require.NoError(t, err)
+	assert.Equal(t, []RunningXact{{Xid: 801, FirstLSN: insert}}, tracker.Running())
+
+	// the record at lsn is read next
+	raw, err := reader.ReadRecord()
+	require.NoError(t, err)
+	assert.Equal(t, commit, raw.LSN)
+
+	reader, err = NewXLogReaderDir(b.dump(t), "000000010000000000000003", 8)
+	require.NoError(t, err)
+	defer reader.Close()
+	tracker, err = TrackRunningXacts(reader, last+0x1000)
+	assert.ErrorIs(t, err, ErrEndOfWAL)
+	require.NotNil(t, tracker)
+	assert.Equal(t, []RunningXact{{Xid: 802, FirstLSN: last}}, tracker.Running())
+}
@@ -0,0 +1,275 @@
+package wal
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// WALSource provides the segment files an XLogReader walks through.
+type WALSource interface {
+	// OpenSegment opens segment segno of timeline tli. If there is no such
+	// segment, the error satisfies errors.Is(err, fs.ErrNotExist).
+	OpenSegment(tli TimeLineID, segno XLogSegNo, segmentSize uint32) (io.ReadCloser, error)
+	// SegmentSize reports the size of the segments the source holds.
+	SegmentSize() (uint32, error)
+}
+
+// NewDirSource returns a WALSource reading the segment files of a pg_wal or
+// archive directory. Segments compressed with gzip, zstd or lz4 and named
+// with the matching suffix are decompressed on the fly. A segment named with
+// PartialSuffix is used if the complete one is missing.
+func NewDirSource(dir string) WALSource {
+	return NewFSSource(os.DirFS(dir))
+}
+
+// NewFSSource returns a WALSource reading the segment files at the root of
+// fsys, e.g. an embed.FS holding test fixtures. Like NewDirSource, the source
+// is a HistorySource as well.
+func NewFSSource(fsys fs.FS) WALSource {
+	return &fsSource{fsys: fsys}
+}
+
+type fsSource struct {
+	fsys fs.FS
+}
+
+func (s *fsSource) OpenSegment(tli TimeLineID, segno XLogSegNo, segmentSize uint32) (io.ReadCloser, error) {
+	return openSegmentFile(s.open, SegmentName(tli, segno, segmentSize))
+}
+
+func (s *fsSource) OpenHistory(tli TimeLineID) (io.ReadCloser, error) {
+	return openSegmentFile(s.open, TimeLineHistoryFileName(tli))
+}
+
+func (s *fsSource) open(name string) (io.ReadCloser, error) {
+	return s.fsys.Open(name)
+}
+
+func (s *fsSource) SegmentSize() (uint32, error) {
+	entries, err := fs.ReadDir(s.fsys, ".")
+	if err != nil {
+		return 0, err
+	}
+	var names []string
+	for _, entry := range entries {
+		if entry.Type().IsRegular() && isWalName(TrimSegmentSuffix(entry.Name())) {
+			names = append(names, entry.Name())
+		}
+	}
+	return probeSegmentSize(names, s.open)
+}
+
+// NewMemorySource returns a WALSource serving the segments in segments,
+// keyed by file name. Compressed segments are recognized as in NewDirSource.
+// History files may be served as well.
+func NewMemorySource(segments map[string][]byte) WALSource {
+	return memorySource(segments)
+}
+
+type memorySource map[string][]byte
+
+func (s memorySource) OpenSegment(tli TimeLineID, segno XLogSegNo, segmentSize uint32) (io.ReadCloser, error) {
+	return openSegmentFile(s.open, SegmentName(tli, segno, segmentSize))
+}
+
+func (s memorySource) OpenHistory(tli TimeLineID) (io.ReadCloser, error) {
+	return openSegmentFile(s.open, TimeLineHistoryFileName(tli))
+}
+
+func (s memorySource) open(name string) (io.ReadCloser, error) {
+	data, ok := s[name]
+	if !ok {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (s memorySource) SegmentSize() (uint32, error) {
+	names := make([]string, 0, len(s))
+	for name := range s {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return probeSegmentSize(names, s.open)
+}
+
+// NewStreamSource returns a WALSource reading consecutive segments from r,
+// such as the output of a restore command piped to stdin. Segments must be
+// opened in ascending order, the ones skipped are discarded.
+func NewStreamSource(r io.Reader) WALSource {
+	return &streamSource{reader: bufio.NewReader(r)}
+}
+
+// NewStdinSource returns a WALSource reading consecutive segments from
+// standard input.
+func NewStdinSource() WALSource {
+	return NewStreamSource(os.Stdin)
+}
+
+type streamSource struct {
+	reader *bufio.Reader
+	size   uint32
+	next   XLogSegNo // segment at the head of the stream
+	cur    *io.LimitedReader
+}
+
+func (s *streamSource) OpenSegment(tli TimeLineID, segno XLogSegNo, segmentSize uint32) (io.ReadCloser, error) {
+	size, err := s.SegmentSize()
+	if err != nil {
+		return nil, err
+	}
+	if s.cur != nil {
+		_, err = io.Copy(io.Discard, s.cur)
+		if err != nil {
+			return nil, err
+		}
+		s.cur = nil
+	}
+	name := SegmentName(tli, segno, size)
+	if segno < s.next {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	n, err := io.CopyN(io.Discard, s.reader, int64(segno-s.next)*int64(size))
+	s.next += XLogSegNo(n / int64(size))
+	if err == nil {
+		_, err = s.reader.Peek(1)
+	}
+	if err == io.EOF {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	if err != nil {
+		return nil, err
+	}
+	s.next = segno + 1
+	s.cur = &io.LimitedReader{R: s.reader, N: int64(size)}
+	return io.NopCloser(s.cur), nil
+}
+
+func (s *streamSource) SegmentSize() (uint32, error) {
+	if s.size != 0 {
+		return s.size, nil
+	}
+	buf, err := s.reader.Peek(int(SizeofXLogLongPageHeaderData()))
+	if err != nil {
+		return 0, err
+	}
+	hdr, err := ReadXLogLongPageHeader(bytes.NewReader(buf))
+	if err != nil {
+		return 0, err
+	}
+	if !IsValidXLogPageHeader(hdr) || !isValidWalSegSize(hdr.XlpSegSize) {
+		return 0, errors.New("stream does not start with a valid segment")
+	}
+	s.size = hdr.XlpSegSize
+	s.next = XLogSegNo(hdr.Std.XlpPageAddr / XLogRecPtr(s.size))
+	return s.size, nil
+}
+
+// listSource serves an explicit list of segment files, which are recognized
+// by their long page header rather than by their names. They may be
+// compressed.
+type listSource struct {
+	size  uint32
+	first string // segment name of the first path
+	paths map[string]string
+}
+
+func newListSource(paths []string) (*listSource, error) {
+	ret := &listSource{paths: make(map[string]string, len(paths))}
+	for _, path := range paths {
+		f, err := openFile(path)
+		if err != nil {
+			return nil, err
+		}
+		hdr, err := ReadXLogLongPageHeader(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("invalid segment file %s: %w", path, err)
+		}
+		if !IsValidXLogPageHeader(hdr) || !isValidWalSegSize(hdr.XlpSegSize) {
+			return nil, fmt.Errorf("invalid segment file %s", path)
+		}
+		if ret.size != 0 && ret.size != hdr.XlpSegSize {
+			return nil, fmt.Errorf("segment file %s has a different segment size", path)
+		}
+		ret.size = hdr.XlpSegSize
+
+		tli := hdr.Std.XlpTli
+		if name := TrimSegmentSuffix(filepath.Base(path)); isWalName(name) {
+			tli, _, err = ParseWalName(name, ret.size)
+			if err != nil {
+				return nil, err
+			}
+		}
+		name, err := WalName(tli, hdr.Std.XlpPageAddr, ret.size)
+		if err != nil {
+			return nil, err
+		}
+		if other, ok := ret.paths[name]; ok {
+			return nil, fmt.Errorf("segment files %s and %s are both segment %s", other, path, name)
+		}
+		if ret.first == "" {
+			ret.first = name
+		}
+		ret.paths[name] = path
+	}
+	return ret, nil
+}
+
+func (s *listSource) OpenSegment(tli TimeLineID, segno XLogSegNo, segmentSize uint32) (io.ReadCloser, error) {
+	name := SegmentName(tli, segno, segmentSize)
+	path, ok := s.paths[name]
+	if !ok {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	return openFile(path)
+}
+
+func openFile(path string) (io.ReadCloser, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	return decompress(f)
+}
+
+func (s *listSource) SegmentSize() (uint32, error) {
+	return s.size, nil
+}
+
+// probeSegmentSize returns the segment size recorded in the long page
+// header of the first valid segment among names.
+func probeSegmentSize(names []string, open func(name string) (io.ReadCloser, error)) (uint32, error) {
+	var lastErr error
+	for _, name := range names {
+		f, err := open(name)
+		if err == nil {
+			f, err = decompress(f)
+		}
+		if err != nil {
+			lastErr = fmt.Errorf("could not read segment file %s: %w", name, err)
+			continue
+		}
+		hdr, err := ReadXLogLongPageHeader(f)
+		f.Close()
+		if err != nil {
+			lastErr = fmt.Errorf("invalid segment file %s: %w", name, err)
+			continue
+		}
+		if IsValidXLogPageHeader(hdr) && isValidWalSegSize(hdr.XlpSegSize) {
+			return hdr.XlpSegSize, nil
+		}
+		lastErr = fmt.Errorf("invalid segment file %s", name)
+	}
+	if len(names) == 0 {
+		return 0, errors.New("no segment file found")
+	}
+	return 0, fmt.Errorf("no valid segment file found in %d files: %w", len(names), lastErr)
+}
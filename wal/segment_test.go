@@ -0,0 +1,196 @@
+package wal
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"testing/fstest"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/pierrec/lz4/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSegmentName(t *testing.T) {
+	assert.Equal(t, "000000010000000A000000FF", SegmentName(1, 0xAFF, 16*1024*1024))
+	assert.Equal(t, "000000010000000000000003", SegmentName(1, 3, 64*1024*1024))
+
+	tli, segno, err := ParseWalName("000000020000000A000000FF", 16*1024*1024)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, tli)
+	assert.EqualValues(t, 0xAFF, segno)
+
+	_, _, err = ParseWalName("000000020000000A000000FF.partial", 16*1024*1024)
+	assert.Error(t, err)
+}
+
+func TestWALSources(t *testing.T) {
+	b := newWalBuilder(1, 0x300000)
+	var lsns []XLogRecPtr
+	for i := 0; i < 800; i++ {
+		lsns = append(lsns, b.record(RM_HEAP_ID, 0, TransactionId(i), mainData(bytes.Repeat([]byte{byte(i)}, 3000))))
+	}
+	segments := b.segments()
+	mapfs := fstest.MapFS{}
+	var names []string
+	for name, data := range segments {
+		mapfs[name] = &fstest.MapFile{Data: data}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var stream []byte
+	for _, name := range names {
+		stream = append(stream, segments[name]...)
+	}
+
+	sources := map[string]func() WALSource{
+		"dir":    func() WALSource { return NewDirSource(b.dump(t)) },
+		"memory": func() WALSource { return NewMemorySource(segments) },
+		"fs":     func() WALSource { return NewFSSource(mapfs) },
+		"stream": func() WALSource { return NewStreamSource(bytes.NewReader(stream)) },
+	}
+	for name, source := range sources {
+		t.Run(name, func(t *testing.T) {
+			size, err := source().SegmentSize()
+			require.NoError(t, err)
+			assert.EqualValues(t, 1024*1024, size)
+
+			reader, err := NewXLogReaderSource(source(), 1, 0x300000, 8)
+			require.NoError(t, err)
+			records, err := readAll(t, reader)
+			assert.ErrorIs(t, err, ErrEndOfWAL)
+			assert.Len(t, records, len(lsns))
+			reader.Close()
+
+			reader, err = NewXLogReaderSource(source(), 1, lsns[500], 8)
+			require.NoError(t, err)
+			records, err = readAll(t, reader)
+			assert.ErrorIs(t, err, ErrEndOfWAL)
+			require.Len(t, records, len(lsns)-500)
+			assert.Equal(t, lsns[500], records[0].LSN)
+			reader.Close()
+		})
+	}
+}
+
+func TestTrimSegmentSuffix(t *testing.T) {
+	assert.Equal(t, "000000010000000A000000FF", TrimSegmentSuffix("000000010000000A000000FF.gz"))
+	assert.Equal(t, "000000010000000A000000FF", TrimSegmentSuffix("000000010000000A000000FF.zst"))
+	assert.Equal(t, "000000010000000A000000FF", TrimSegmentSuffix("000000010000000A000000FF"))
+
+	lsn, err := PageLSN(TrimSegmentSuffix("000000010000000A000000FF.lz4"), 16*1024*1024)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 0xAFF000000, lsn)
+}
+
+func TestCompressedSegments(t *testing.T) {
+	b := newWalBuilder(1, 0x300000)
+	var lsns []XLogRecPtr
+	for i := 0; i < 800; i++ {
+		lsns = append(lsns, b.record(RM_HEAP_ID, 0, TransactionId(i), mainData(bytes.Repeat([]byte{byte(i)}, 3000))))
+	}
+
+	compressors := map[string]func(io.Writer) io.WriteCloser{
+		".gz": func(w io.Writer) io.WriteCloser { return gzip.NewWriter(w) },
+		".zst": func(w io.Writer) io.WriteCloser {
+			zw, _ := zstd.NewWriter(w)
+			return zw
+		},
+		".lz4": func(w io.Writer) io.WriteCloser { return lz4.NewWriter(w) },
+	}
+	segments := b.segments()
+	var names []string
+	for name := range segments {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	require.Len(t, names, 3)
+
+	dir := t.TempDir()
+	var paths []string
+	for i, name := range names {
+		data := segments[name]
+		suffix := []string{".gz", ".zst", ".lz4"}[i]
+		path := filepath.Join(dir, name+suffix)
+		paths = append(paths, path)
+		var buf bytes.Buffer
+		w := compressors[suffix](&buf)
+		_, err := w.Write(data)
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+		require.NoError(t, os.WriteFile(path, buf.Bytes(), 0o600))
+	}
+
+	reader, err := NewXLogReaderAt(dir, 1, lsns[100], 8)
+	require.NoError(t, err)
+	records, err := readAll(t, reader)
+	assert.ErrorIs(t, err, ErrEndOfWAL)
+	assert.Len(t, records, len(lsns)-100)
+	reader.Close()
+
+	reader, err = NewXLogReaderFiles(paths, 8)
+	require.NoError(t, err)
+	records, err = readAll(t, reader)
+	assert.ErrorIs(t, err, ErrEndOfWAL)
+	assert.Len(t, records, len(lsns))
+	reader.Close()
+
+	// the same segment from another directory
+	other := filepath.Join(t.TempDir(), names[0])
+	require.NoError(t, os.WriteFile(other, segments[names[0]], 0o600))
+	_, err = NewXLogReaderFiles(append(paths, other), 8)
+	assert.ErrorContains(t, err, paths[0])
+	assert.ErrorContains(t, err, other)
+}
+
+func TestPartialSegment(t *testing.T) {
+	b := newWalBuilder(1, 0x300000)
+	var lsns []XLogRecPtr
+	for i := 0; i < 400; i++ {
+		lsns = append(lsns, b.record(RM_HEAP_ID, 0, TransactionId(i), mainData(bytes.Repeat([]byte{byte(i)}, 3000))))
+	}
+	end := b.pos()
+	segments := b.segments()
+	require.Len(t, segments, 2)
+
+	// the tail of the partial segment is left over from segment 1.
+	stale := newWalBuilder(1, 0x100000)
+	for i := 0; i < 400; i++ {
+		stale.record(RM_HEAP_ID, 0, TransactionId(i), mainData(bytes.Repeat([]byte{byte(i)}, 3000)))
+	}
+	partial := append([]byte(nil), segments["000000010000000000000004"]...)
+	validPages := int(end-0x400000+8191) / 8192 * 8192
+	copy(partial[validPages:], stale.segments()["000000010000000000000001"][validPages:])
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "000000010000000000000003"), segments["000000010000000000000003"], 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "000000010000000000000004.partial"), partial, 0o600))
+
+	reader, err := NewXLogReaderAt(dir, 1, 0x300000, 8)
+	require.NoError(t, err)
+	defer reader.Close()
+	records, err := readAll(t, reader)
+	assert.Len(t, records, len(lsns))
+	assert.ErrorIs(t, err, ErrEndOfWAL)
+	var rerr *ReadError
+	require.ErrorAs(t, err, &rerr)
+	assert.Equal(t, "000000010000000000000004", rerr.Segment)
+	assert.EqualValues(t, (end+7)&^7-0x400000, rerr.Offset)
+	assert.Equal(t, end, reader.EndRecPtr())
+
+	size, err := NewDirSource(dir).SegmentSize()
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1024*1024, size)
+
+	// an unreadable segment does not hide the valid ones
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "000000010000000000000002.gz"), []byte{0x1f, 0x8b, 8}, 0o600))
+	size, err = NewDirSource(dir).SegmentSize()
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1024*1024, size)
+	assert.Equal(t, "000000010000000000000004", TrimSegmentSuffix("000000010000000000000004.gz.partial"))
+}
@@ -0,0 +1,63 @@
+package wal
+
+/* XLOG stuff */
+const XLOG_SEQ_LOG = 0x00
+
+type XlSeqRec struct {
+	Node RelFileNode
+	/* SEQUENCE TUPLE DATA FOLLOWS AT THE END */
+}
+
+func SizeofXlSeqRec() int64 {
+	return 12
+}
+
+/*
+ * The "special area" of a sequence's buffer page, the data of its only
+ * tuple.
+ */
+type FormDataPgSequenceData struct {
+	LastValue int64
+	LogCnt    int64
+	IsCalled  bool
+}
+
+func SizeofFormDataPgSequenceData() int64 {
+	return 17
+}
+
+const sizeofHeapTupleHeader = 23 /* offsetof(HeapTupleHeaderData, t_bits) */
+
+type SeqLog struct {
+	XlSeqRec
+	FormDataPgSequenceData
+	// Tuple is the whole sequence tuple, from its HeapTupleHeaderData.
+	Tuple []byte
+}
+
+// DecodeSequence decodes the records of RM_SEQ_ID into the struct of their
+// info code, *SeqLog.
+func DecodeSequence(record *Record) (interface{}, error) {
+	data := record.MainData
+	switch record.Info() {
+	case XLOG_SEQ_LOG:
+		xlrec, err := readStruct[XlSeqRec](record, data, SizeofXlSeqRec())
+		if err != nil {
+			return nil, err
+		}
+		tuple := data[SizeofXlSeqRec():]
+		if len(tuple) < sizeofHeapTupleHeader {
+			return nil, record.tooShort(tuple, "a sequence tuple")
+		}
+		hoff := int(tuple[22]) /* t_hoff */
+		if hoff < sizeofHeapTupleHeader || hoff > len(tuple) {
+			return nil, record.errorf("invalid sequence tuple header size %d", hoff)
+		}
+		seq, err := readStruct[FormDataPgSequenceData](record, tuple[hoff:], SizeofFormDataPgSequenceData())
+		if err != nil {
+			return nil, err
+		}
+		return &SeqLog{XlSeqRec: *xlrec, FormDataPgSequenceData: *seq, Tuple: tuple}, nil
+	}
+	return nil, record.unknownInfo()
+}
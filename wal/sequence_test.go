@@ -0,0 +1,26 @@
+package wal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeSequence(t *testing.T) {
+	header := make([]byte, 24)
+	header[22] = 24 /* t_hoff */
+	tuple := le(header, uint64(33), uint64(32), uint8(1))
+	decoded, err := DecodeRmgrData(testRecord(t, RM_SEQ_ID, XLOG_SEQ_LOG,
+		le(uint32(1663), uint32(5), uint32(16400), tuple), nil))
+	require.NoError(t, err)
+	assert.Equal(t, &SeqLog{
+		XlSeqRec:               XlSeqRec{Node: RelFileNode{1663, 5, 16400}},
+		FormDataPgSequenceData: FormDataPgSequenceData{LastValue: 33, LogCnt: 32, IsCalled: true},
+		Tuple:                  tuple,
+	}, decoded)
+
+	_, err = DecodeRmgrData(testRecord(t, RM_SEQ_ID, XLOG_SEQ_LOG,
+		le(uint32(1663), uint32(5), uint32(16400), tuple[:30])))
+	assert.ErrorIs(t, err, ErrInvalidRecord)
+}
@@ -0,0 +1,479 @@
+package wal
+
+import "unsafe"
+
+const (
+	/* XLOG record types for SPGiST */
+	/* 0x00 was XLOG_SPGIST_CREATE_INDEX */
+	XLOG_SPGIST_ADD_LEAF        = 0x10
+	XLOG_SPGIST_MOVE_LEAFS      = 0x20
+	XLOG_SPGIST_ADD_NODE        = 0x30
+	XLOG_SPGIST_SPLIT_TUPLE     = 0x40
+	XLOG_SPGIST_PICKSPLIT       = 0x50
+	XLOG_SPGIST_VACUUM_LEAF     = 0x60
+	XLOG_SPGIST_VACUUM_ROOT     = 0x70
+	XLOG_SPGIST_VACUUM_REDIRECT = 0x80
+)
+
+/*
+ * Some redo functions need an SpGistState, although only a few of its fields
+ * need to be valid.  spgxlogState carries the required info in xlog records.
+ * (See fillFakeState in spgxlog.c for more comments.)
+ */
+type SpgxlogState struct {
+	MyXid   TransactionId
+	IsBuild bool
+}
+
+/*
+ * Backup Blk 0: destination page for leaf tuple
+ * Backup Blk 1: parent page (if any)
+ */
+type SpgxlogAddLeaf struct {
+	NewPage        bool         /* init dest page? */
+	StoresNulls    bool         /* page is in the nulls tree? */
+	OffnumLeaf     OffsetNumber /* offset where leaf tuple gets placed */
+	OffnumHeadLeaf OffsetNumber /* offset of head tuple in chain, if any */
+
+	OffnumParent OffsetNumber /* where the parent downlink is, if any */
+	NodeI        uint16
+
+	/* new leaf tuple follows (unaligned!) */
+}
+
+func SizeofSpgxlogAddLeaf() int64 {
+	return 10
+}
+
+/*
+ * Backup Blk 0: source leaf page
+ * Backup Blk 1: destination leaf page
+ * Backup Blk 2: parent page
+ */
+type SpgxlogMoveLeafs struct {
+	NMoves      uint16 /* number of tuples moved from source page */
+	NewPage     bool   /* init dest page? */
+	ReplaceDead bool   /* are we replacing a DEAD source tuple? */
+	StoresNulls bool   /* pages are in the nulls tree? */
+
+	/* where the parent downlink is */
+	OffnumParent OffsetNumber
+	NodeI        uint16
+
+	StateSrc SpgxlogState
+
+	/*----------
+	 * data follows:
+	 *		array of deleted tuple numbers, length nMoves
+	 *		array of inserted tuple numbers, length nMoves + 1 or 1
+	 *		list of leaf tuples, length nMoves + 1 or 1 (unaligned!)
+	 *
+	 * Note: if replaceDead is true then there is only one inserted tuple
+	 * number and only one leaf tuple in the data, because we are not copying
+	 * the dead tuple from the source
+	 *----------
+	 */
+}
+
+func SizeofSpgxlogMoveLeafs() int64 {
+	return 20
+}
+
+/*
+ * Backup Blk 0: original page
+ * Backup Blk 1: where new tuple goes, if not same place
+ * Backup Blk 2: where parent downlink is, if updated and different from
+ *				 the old and new
+ */
+type SpgxlogAddNode struct {
+	/*
+	 * Offset of the original inner tuple, in the original page (on backup
+	 * block 0).
+	 */
+	Offnum OffsetNumber
+
+	/*
+	 * Offset of the new tuple, on the new page (on backup block 1). Invalid,
+	 * if we overwrote the old tuple in the original page).
+	 */
+	OffnumNew OffsetNumber
+	NewPage   bool /* init new page? */
+
+	/*----
+	 * Where is the parent downlink? parentBlk indicates which page it's on,
+	 * and offnumParent is the offset within the page. The possible values for
+	 * parentBlk are:
+	 *
+	 * 0: parent == original page
+	 * 1: parent == new page
+	 * 2: parent == different page (blk ref 2)
+	 * -1: parent not updated
+	 *----
+	 */
+	ParentBlk    int8
+	OffnumParent OffsetNumber /* offset within the parent page */
+
+	NodeI uint16
+
+	StateSrc SpgxlogState
+
+	/*
+	 * updated inner tuple follows (unaligned!)
+	 */
+}
+
+func SizeofSpgxlogAddNode() int64 {
+	return 20
+}
+
+/*
+ * Backup Blk 0: where the prefix tuple goes
+ * Backup Blk 1: where the postfix tuple goes (if different page)
+ */
+type SpgxlogSplitTuple struct {
+	/* where the prefix tuple goes */
+	OffnumPrefix OffsetNumber
+
+	/* where the postfix tuple goes */
+	OffnumPostfix  OffsetNumber
+	NewPage        bool /* need to init that page? */
+	PostfixBlkSame bool /* was postfix tuple put on same page as
+	 * prefix? */
+
+	/*
+	 * new prefix inner tuple follows, then new postfix inner tuple (both are
+	 * unaligned!)
+	 */
+}
+
+func SizeofSpgxlogSplitTuple() int64 {
+	return 6
+}
+
+/*
+ * Buffer references in the rdata array are:
+ * Backup Blk 0: Src page (only if not root)
+ * Backup Blk 1: Dest page (if used)
+ * Backup Blk 2: Inner page
+ * Backup Blk 3: Parent page (if any, and different from Inner)
+ */
+type SpgxlogPickSplit struct {
+	IsRootSplit bool
+
+	NDelete  uint16 /* n to delete from Src */
+	NInsert  uint16 /* n to insert on Src and/or Dest */
+	InitSrc  bool   /* re-init the Src page? */
+	InitDest bool   /* re-init the Dest page? */
+
+	/* where to put new inner tuple */
+	OffnumInner OffsetNumber
+	InitInner   bool /* re-init the Inner page? */
+
+	StoresNulls bool /* pages are in the nulls tree? */
+
+	/* where the parent downlink is, if any */
+	InnerIsParent bool /* is parent the same as inner page? */
+	OffnumParent  OffsetNumber
+	NodeI         uint16
+
+	StateSrc SpgxlogState
+
+	/*----------
+	 * data follows:
+	 *		array of deleted tuple numbers, length nDelete
+	 *		array of inserted tuple numbers, length nInsert
+	 *		array of page selector bytes for inserted tuples, length nInsert
+	 *		new inner tuple (unaligned!)
+	 *		list of leaf tuples, length nInsert (unaligned!)
+	 *----------
+	 */
+}
+
+func SizeofSpgxlogPickSplit() int64 {
+	return 28
+}
+
+type SpgxlogVacuumLeaf struct {
+	NDead        uint16 /* number of tuples to become DEAD */
+	NPlaceholder uint16 /* number of tuples to become PLACEHOLDER */
+	NMove        uint16 /* number of tuples to move */
+	NChain       uint16 /* number of tuples to re-chain */
+
+	StateSrc SpgxlogState
+
+	/*----------
+	 * data follows:
+	 *		tuple numbers to become DEAD
+	 *		tuple numbers to become PLACEHOLDER
+	 *		tuple numbers to move from (and replace with PLACEHOLDER)
+	 *		tuple numbers to move to (replacing what is there)
+	 *		tuple numbers to update nextOffset links of
+	 *		tuple numbers to insert in nextOffset links
+	 *----------
+	 */
+}
+
+func SizeofSpgxlogVacuumLeaf() int64 {
+	return 16
+}
+
+type SpgxlogVacuumRoot struct {
+	/* vacuum a root page when it is also a leaf */
+	NDelete uint16 /* number of tuples to delete */
+
+	StateSrc SpgxlogState
+
+	/* offsets of tuples to delete follow */
+}
+
+func SizeofSpgxlogVacuumRoot() int64 {
+	return 12
+}
+
+type SpgxlogVacuumRedirect struct {
+	NToPlaceholder          uint16        /* number of redirects to make placeholders */
+	FirstPlaceholder        OffsetNumber  /* first placeholder tuple to remove */
+	SnapshotConflictHorizon TransactionId /* newestRedirectXid before 16 */
+	IsCatalogRel            bool          /* Since PostgreSQL 16 */
+
+	/* offsets of redirect tuples to make placeholders follow */
+}
+
+func SizeofSpgxlogVacuumRedirect(v PgVersion) int64 {
+	if v >= PG16 {
+		return 10
+	}
+	return 8
+}
+
+type SpgAddLeaf struct {
+	SpgxlogAddLeaf
+	LeafTuple []byte
+}
+
+type SpgMoveLeafs struct {
+	SpgxlogMoveLeafs
+	ToDelete   []OffsetNumber
+	ToInsert   []OffsetNumber
+	LeafTuples [][]byte
+}
+
+type SpgAddNode struct {
+	SpgxlogAddNode
+	InnerTuple []byte
+}
+
+type SpgSplitTuple struct {
+	SpgxlogSplitTuple
+	PrefixTuple  []byte
+	PostfixTuple []byte
+}
+
+type SpgPickSplit struct {
+	SpgxlogPickSplit
+	ToDelete       []OffsetNumber
+	ToInsert       []OffsetNumber
+	LeafPageSelect []uint8 // whether each inserted tuple goes to Dest
+	InnerTuple     []byte
+	LeafTuples     [][]byte
+}
+
+type SpgVacuumLeaf struct {
+	SpgxlogVacuumLeaf
+	ToDead        []OffsetNumber
+	ToPlaceholder []OffsetNumber
+	MoveSrc       []OffsetNumber
+	MoveDest      []OffsetNumber
+	ChainSrc      []OffsetNumber
+	ChainDest     []OffsetNumber
+}
+
+type SpgVacuumRoot struct {
+	SpgxlogVacuumRoot
+	ToDelete []OffsetNumber
+}
+
+type SpgVacuumRedirect struct {
+	SpgxlogVacuumRedirect
+	ItemToPlaceholder []OffsetNumber
+}
+
+// DecodeSpgist decodes the records of RM_SPGIST_ID into the struct of their
+// info code, e.g. *SpgPickSplit.
+func DecodeSpgist(record *Record) (interface{}, error) {
+	data := record.MainData
+	switch record.Info() {
+	case XLOG_SPGIST_ADD_LEAF:
+		xlrec, err := readStruct[SpgxlogAddLeaf](record, data, SizeofSpgxlogAddLeaf())
+		if err != nil {
+			return nil, err
+		}
+		leafTuple, _, err := readSpgLeafTuple(record, data[SizeofSpgxlogAddLeaf():])
+		if err != nil {
+			return nil, err
+		}
+		return &SpgAddLeaf{SpgxlogAddLeaf: *xlrec, LeafTuple: leafTuple}, nil
+	case XLOG_SPGIST_MOVE_LEAFS:
+		xlrec, err := readStruct[SpgxlogMoveLeafs](record, data, SizeofSpgxlogMoveLeafs())
+		if err != nil {
+			return nil, err
+		}
+		ret := &SpgMoveLeafs{SpgxlogMoveLeafs: *xlrec}
+		nInsert := 1
+		if !xlrec.ReplaceDead {
+			nInsert = int(xlrec.NMoves) + 1
+		}
+		data = data[SizeofSpgxlogMoveLeafs():]
+		if ret.ToDelete, data, err = readOffsets(record, data, int(xlrec.NMoves)); err != nil {
+			return nil, err
+		}
+		if ret.ToInsert, data, err = readOffsets(record, data, nInsert); err != nil {
+			return nil, err
+		}
+		for i := 0; i < nInsert; i++ {
+			var leafTuple []byte
+			if leafTuple, data, err = readSpgLeafTuple(record, data); err != nil {
+				return nil, err
+			}
+			ret.LeafTuples = append(ret.LeafTuples, leafTuple)
+		}
+		return ret, nil
+	case XLOG_SPGIST_ADD_NODE:
+		xlrec, err := readStruct[SpgxlogAddNode](record, data, SizeofSpgxlogAddNode())
+		if err != nil {
+			return nil, err
+		}
+		innerTuple, _, err := readSpgInnerTuple(record, data[SizeofSpgxlogAddNode():])
+		if err != nil {
+			return nil, err
+		}
+		return &SpgAddNode{SpgxlogAddNode: *xlrec, InnerTuple: innerTuple}, nil
+	case XLOG_SPGIST_SPLIT_TUPLE:
+		xlrec, err := readStruct[SpgxlogSplitTuple](record, data, SizeofSpgxlogSplitTuple())
+		if err != nil {
+			return nil, err
+		}
+		ret := &SpgSplitTuple{SpgxlogSplitTuple: *xlrec}
+		data = data[SizeofSpgxlogSplitTuple():]
+		if ret.PrefixTuple, data, err = readSpgInnerTuple(record, data); err != nil {
+			return nil, err
+		}
+		if ret.PostfixTuple, _, err = readSpgInnerTuple(record, data); err != nil {
+			return nil, err
+		}
+		return ret, nil
+	case XLOG_SPGIST_PICKSPLIT:
+		xlrec, err := readStruct[SpgxlogPickSplit](record, data, SizeofSpgxlogPickSplit())
+		if err != nil {
+			return nil, err
+		}
+		ret := &SpgPickSplit{SpgxlogPickSplit: *xlrec}
+		data = data[SizeofSpgxlogPickSplit():]
+		if ret.ToDelete, data, err = readOffsets(record, data, int(xlrec.NDelete)); err != nil {
+			return nil, err
+		}
+		if ret.ToInsert, data, err = readOffsets(record, data, int(xlrec.NInsert)); err != nil {
+			return nil, err
+		}
+		if len(data) < int(xlrec.NInsert) {
+			return nil, record.tooShort(data, "leaf page selectors")
+		}
+		ret.LeafPageSelect, data = data[:xlrec.NInsert], data[xlrec.NInsert:]
+		if ret.InnerTuple, data, err = readSpgInnerTuple(record, data); err != nil {
+			return nil, err
+		}
+		for i := 0; i < int(xlrec.NInsert); i++ {
+			var leafTuple []byte
+			if leafTuple, data, err = readSpgLeafTuple(record, data); err != nil {
+				return nil, err
+			}
+			ret.LeafTuples = append(ret.LeafTuples, leafTuple)
+		}
+		return ret, nil
+	case XLOG_SPGIST_VACUUM_LEAF:
+		xlrec, err := readStruct[SpgxlogVacuumLeaf](record, data, SizeofSpgxlogVacuumLeaf())
+		if err != nil {
+			return nil, err
+		}
+		ret := &SpgVacuumLeaf{SpgxlogVacuumLeaf: *xlrec}
+		data = data[SizeofSpgxlogVacuumLeaf():]
+		for _, part := range []struct {
+			offsets *[]OffsetNumber
+			n       uint16
+		}{
+			{&ret.ToDead, xlrec.NDead},
+			{&ret.ToPlaceholder, xlrec.NPlaceholder},
+			{&ret.MoveSrc, xlrec.NMove},
+			{&ret.MoveDest, xlrec.NMove},
+			{&ret.ChainSrc, xlrec.NChain},
+			{&ret.ChainDest, xlrec.NChain},
+		} {
+			if *part.offsets, data, err = readOffsets(record, data, int(part.n)); err != nil {
+				return nil, err
+			}
+		}
+		return ret, nil
+	case XLOG_SPGIST_VACUUM_ROOT:
+		xlrec, err := readStruct[SpgxlogVacuumRoot](record, data, SizeofSpgxlogVacuumRoot())
+		if err != nil {
+			return nil, err
+		}
+		toDelete, _, err := readOffsets(record, data[SizeofSpgxlogVacuumRoot():], int(xlrec.NDelete))
+		if err != nil {
+			return nil, err
+		}
+		return &SpgVacuumRoot{SpgxlogVacuumRoot: *xlrec, ToDelete: toDelete}, nil
+	case XLOG_SPGIST_VACUUM_REDIRECT:
+		size := SizeofSpgxlogVacuumRedirect(record.Version)
+		xlrec, err := readStruct[SpgxlogVacuumRedirect](record, data, size)
+		if err != nil {
+			return nil, err
+		}
+		if record.Version < PG16 {
+			xlrec.IsCatalogRel = false
+		}
+		offsets, _, err := readOffsets(record, data[size:], int(xlrec.NToPlaceholder))
+		if err != nil {
+			return nil, err
+		}
+		return &SpgVacuumRedirect{SpgxlogVacuumRedirect: *xlrec, ItemToPlaceholder: offsets}, nil
+	}
+	return nil, record.unknownInfo()
+}
+
+// readOffsets decodes the n OffsetNumbers at the start of data and returns
+// the data following them.
+func readOffsets(record *Record, data []byte, n int) ([]OffsetNumber, []byte, error) {
+	offsets, err := readArray[OffsetNumber](record, data, n)
+	if err != nil {
+		return nil, nil, err
+	}
+	return offsets, data[2*n:], nil
+}
+
+// readSpgLeafTuple splits the unaligned SpGistLeafTuple at the start of data,
+// whose size is the upper 30 bits of its first word, from the data following
+// it.
+func readSpgLeafTuple(record *Record, data []byte) ([]byte, []byte, error) {
+	if len(data) < 4 {
+		return nil, nil, record.tooShort(data, "leaf tuple")
+	}
+	size := int(*(*uint32)(unsafe.Pointer(&data[0])) >> 2)
+	if size < 4 || size > len(data) {
+		return nil, nil, record.errorf("invalid SPGist leaf tuple size %d", size)
+	}
+	return data[:size], data[size:], nil
+}
+
+// readSpgInnerTuple splits the unaligned SpGistInnerTuple at the start of
+// data, whose uint16 size follows its first word, from the data following it.
+func readSpgInnerTuple(record *Record, data []byte) ([]byte, []byte, error) {
+	if len(data) < 6 {
+		return nil, nil, record.tooShort(data, "inner tuple")
+	}
+	size := int(*(*uint16)(unsafe.Pointer(&data[4])))
+	if size < 6 || size > len(data) {
+		return nil, nil, record.errorf("invalid SPGist inner tuple size %d", size)
+	}
+	return data[:size], data[size:], nil
+}
@@ -0,0 +1,101 @@
+package wal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// spgLeafTuple returns an SpGistLeafTuple of size bytes.
+func spgLeafTuple(size int) []byte {
+	return append(le(uint32(size<<2|1)), make([]byte, size-4)...)
+}
+
+// spgInnerTuple returns an SpGistInnerTuple of size bytes.
+func spgInnerTuple(size int) []byte {
+	return append(le(uint32(0), uint16(size)), make([]byte, size-6)...)
+}
+
+func TestDecodeSpgist(t *testing.T) {
+	state := le(uint32(700), uint8(0), []byte{0, 0, 0})
+
+	t.Run("add leaf", func(t *testing.T) {
+		record := testRecord(t, RM_SPGIST_ID, XLOG_SPGIST_ADD_LEAF,
+			le(uint8(1), uint8(0), uint16(2), uint16(0), uint16(0), uint16(0), spgLeafTuple(13)), nil)
+		decoded, err := DecodeRmgrData(record)
+		require.NoError(t, err)
+		leaf := decoded.(*SpgAddLeaf)
+		assert.True(t, leaf.NewPage)
+		assert.EqualValues(t, 2, leaf.OffnumLeaf)
+		assert.Len(t, leaf.LeafTuple, 13)
+	})
+	t.Run("move leafs", func(t *testing.T) {
+		record := testRecord(t, RM_SPGIST_ID, XLOG_SPGIST_MOVE_LEAFS,
+			le(uint16(1), uint8(0), uint8(0), uint8(0), uint8(0), uint16(3), uint16(1), uint16(0), state,
+				uint16(5), uint16(1), uint16(2), spgLeafTuple(13), spgLeafTuple(16)), nil, nil, nil)
+		decoded, err := DecodeRmgrData(record)
+		require.NoError(t, err)
+		move := decoded.(*SpgMoveLeafs)
+		assert.Equal(t, TransactionId(700), move.StateSrc.MyXid)
+		assert.Equal(t, []OffsetNumber{5}, move.ToDelete)
+		assert.Equal(t, []OffsetNumber{1, 2}, move.ToInsert)
+		require.Len(t, move.LeafTuples, 2)
+		assert.Len(t, move.LeafTuples[1], 16)
+	})
+	t.Run("split tuple", func(t *testing.T) {
+		record := testRecord(t, RM_SPGIST_ID, XLOG_SPGIST_SPLIT_TUPLE,
+			le(uint16(1), uint16(2), uint8(0), uint8(1), spgInnerTuple(10), spgInnerTuple(7)), nil)
+		decoded, err := DecodeRmgrData(record)
+		require.NoError(t, err)
+		split := decoded.(*SpgSplitTuple)
+		assert.True(t, split.PostfixBlkSame)
+		assert.Len(t, split.PrefixTuple, 10)
+		assert.Len(t, split.PostfixTuple, 7)
+	})
+	t.Run("picksplit", func(t *testing.T) {
+		record := testRecord(t, RM_SPGIST_ID, XLOG_SPGIST_PICKSPLIT,
+			le(uint8(0), uint8(0), uint16(1), uint16(2), uint8(0), uint8(1), uint16(1), uint8(0), uint8(0),
+				uint8(1), uint8(0), uint16(1), uint16(0), uint16(0), state,
+				uint16(3), uint16(1), uint16(2), uint8(0), uint8(1),
+				spgInnerTuple(8), spgLeafTuple(12), spgLeafTuple(12)), nil, nil, nil)
+		decoded, err := DecodeRmgrData(record)
+		require.NoError(t, err)
+		split := decoded.(*SpgPickSplit)
+		assert.True(t, split.InitDest)
+		assert.True(t, split.InnerIsParent)
+		assert.Equal(t, []OffsetNumber{3}, split.ToDelete)
+		assert.Equal(t, []OffsetNumber{1, 2}, split.ToInsert)
+		assert.Equal(t, []uint8{0, 1}, split.LeafPageSelect)
+		assert.Len(t, split.InnerTuple, 8)
+		assert.Len(t, split.LeafTuples, 2)
+	})
+	t.Run("vacuum leaf", func(t *testing.T) {
+		record := testRecord(t, RM_SPGIST_ID, XLOG_SPGIST_VACUUM_LEAF,
+			le(uint16(1), uint16(0), uint16(1), uint16(1), state,
+				uint16(2), uint16(3), uint16(4), uint16(5), uint16(6)), nil)
+		decoded, err := DecodeRmgrData(record)
+		require.NoError(t, err)
+		vacuum := decoded.(*SpgVacuumLeaf)
+		assert.Equal(t, []OffsetNumber{2}, vacuum.ToDead)
+		assert.Empty(t, vacuum.ToPlaceholder)
+		assert.Equal(t, []OffsetNumber{3}, vacuum.MoveSrc)
+		assert.Equal(t, []OffsetNumber{4}, vacuum.MoveDest)
+		assert.Equal(t, []OffsetNumber{6}, vacuum.ChainDest)
+	})
+	t.Run("vacuum redirect", func(t *testing.T) {
+		record := versioned(testRecord(t, RM_SPGIST_ID, XLOG_SPGIST_VACUUM_REDIRECT,
+			le(uint16(2), uint16(9), uint32(800), uint16(4), uint16(5)), nil), PG15)
+		decoded, err := DecodeRmgrData(record)
+		require.NoError(t, err)
+		redirect := decoded.(*SpgVacuumRedirect)
+		assert.Equal(t, TransactionId(800), redirect.SnapshotConflictHorizon)
+		assert.Equal(t, []OffsetNumber{4, 5}, redirect.ItemToPlaceholder)
+	})
+	t.Run("truncated", func(t *testing.T) {
+		record := testRecord(t, RM_SPGIST_ID, XLOG_SPGIST_ADD_LEAF,
+			le(uint8(1), uint8(0), uint16(2), uint16(0), uint16(0), uint16(0), spgLeafTuple(13)[:8]), nil)
+		_, err := DecodeRmgrData(record)
+		assert.ErrorIs(t, err, ErrInvalidRecord)
+	})
+}
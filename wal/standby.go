@@ -0,0 +1,108 @@
+package wal
+
+/* Recovery handlers for the Standby Rmgr (RM_STANDBY_ID) */
+const (
+	XLOG_STANDBY_LOCK  = 0x00
+	XLOG_RUNNING_XACTS = 0x10
+	XLOG_INVALIDATIONS = 0x20
+)
+
+type XlStandbyLock struct {
+	Xid    TransactionId /* xid of holder of AccessExclusiveLock */
+	DbOid  Oid           /* locked table is in this database */
+	RelOid Oid           /* OID of locked relation */
+}
+
+/*
+ * When we write running xact data to WAL, we use this structure.
+ */
+type XlRunningXacts struct {
+	Xcnt               int32         /* # of xact ids in xids[] */
+	Subxcnt            int32         /* # of subxact ids in xids[] */
+	SubxidOverflow     bool          /* snapshot overflowed, subxids missing */
+	NextXid            TransactionId /* xid from TransamVariables->nextXid */
+	OldestRunningXid   TransactionId /* *not* oldestXmin */
+	LatestCompletedXid TransactionId /* so we can set xmax */
+
+	/* xids[FLEXIBLE_ARRAY_MEMBER] follow */
+}
+
+func SizeofXlRunningXacts() int64 {
+	return 24
+}
+
+/*
+ * Invalidations for standby, currently only when transactions without an
+ * assigned xid commit.
+ */
+type XlInvalidations struct {
+	DbId                  Oid   /* MyDatabaseId */
+	TsId                  Oid   /* MyDatabaseTableSpace */
+	RelcacheInitFileInval bool  /* invalidate relcache init files */
+	Nmsgs                 int32 /* number of shared inval msgs */
+
+	/* msgs[FLEXIBLE_ARRAY_MEMBER] follow */
+}
+
+func SizeofXlInvalidations() int64 {
+	return 16
+}
+
+type StandbyLock struct {
+	Locks []XlStandbyLock
+}
+
+// StandbyRunningXacts is the snapshot of the transactions running when it
+// was logged.
+type StandbyRunningXacts struct {
+	XlRunningXacts
+	Xids    []TransactionId
+	Subxids []TransactionId
+}
+
+type StandbyInvalidations struct {
+	XlInvalidations
+	Msgs []SharedInvalidationMessage
+}
+
+// DecodeStandby decodes the records of RM_STANDBY_ID into the struct of their
+// info code, e.g. *StandbyRunningXacts.
+func DecodeStandby(record *Record) (interface{}, error) {
+	data := record.MainData
+	switch record.Info() {
+	case XLOG_STANDBY_LOCK:
+		locks, _, err := readCountedArray[XlStandbyLock](record, data)
+		if err != nil {
+			return nil, err
+		}
+		return &StandbyLock{Locks: locks}, nil
+	case XLOG_RUNNING_XACTS:
+		xlrec, err := readStruct[XlRunningXacts](record, data, SizeofXlRunningXacts())
+		if err != nil {
+			return nil, err
+		}
+		if xlrec.Xcnt < 0 || xlrec.Subxcnt < 0 {
+			return nil, record.errorf("invalid running xacts count %d/%d", xlrec.Xcnt, xlrec.Subxcnt)
+		}
+		xids, err := readArray[TransactionId](record, data[SizeofXlRunningXacts():], int(xlrec.Xcnt)+int(xlrec.Subxcnt))
+		if err != nil {
+			return nil, err
+		}
+		return &StandbyRunningXacts{
+			XlRunningXacts: *xlrec,
+			Xids:           xids[:xlrec.Xcnt],
+			Subxids:        xids[xlrec.Xcnt:],
+		}, nil
+	case XLOG_INVALIDATIONS:
+		xlrec, err := readStruct[XlInvalidations](record, data, SizeofXlInvalidations())
+		if err != nil {
+			return nil, err
+		}
+		msgs, err := readArray[SharedInvalidationMessage](record, data[SizeofXlInvalidations():], int(xlrec.Nmsgs))
+		if err != nil {
+			return nil, err
+		}
+		return &StandbyInvalidations{XlInvalidations: *xlrec, Msgs: msgs}, nil
+	}
+	return nil, record.unknownInfo()
+}
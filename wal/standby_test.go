@@ -0,0 +1,42 @@
+package wal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeStandbyLock(t *testing.T) {
+	decoded, err := DecodeRmgrData(testRecord(t, RM_STANDBY_ID, XLOG_STANDBY_LOCK,
+		le(uint32(2), uint32(900), uint32(5), uint32(16384), uint32(901), uint32(5), uint32(16390))))
+	require.NoError(t, err)
+	assert.Equal(t, &StandbyLock{Locks: []XlStandbyLock{{900, 5, 16384}, {901, 5, 16390}}}, decoded)
+}
+
+func TestDecodeStandbyRunningXacts(t *testing.T) {
+	decoded, err := DecodeRmgrData(testRecord(t, RM_STANDBY_ID, XLOG_RUNNING_XACTS, le(
+		uint32(2), uint32(1), uint32(0), uint32(905), uint32(900), uint32(904),
+		uint32(900), uint32(903), uint32(902))))
+	require.NoError(t, err)
+	assert.Equal(t, &StandbyRunningXacts{
+		XlRunningXacts: XlRunningXacts{Xcnt: 2, Subxcnt: 1, NextXid: 905, OldestRunningXid: 900, LatestCompletedXid: 904},
+		Xids:           []TransactionId{900, 903},
+		Subxids:        []TransactionId{902},
+	}, decoded)
+
+	_, err = DecodeRmgrData(testRecord(t, RM_STANDBY_ID, XLOG_RUNNING_XACTS, le(
+		uint32(2), uint32(1), uint32(0), uint32(905), uint32(900), uint32(904), uint32(900))))
+	assert.ErrorIs(t, err, ErrInvalidRecord)
+}
+
+func TestDecodeStandbyInvalidations(t *testing.T) {
+	decoded, err := DecodeRmgrData(testRecord(t, RM_STANDBY_ID, XLOG_INVALIDATIONS, le(
+		uint32(5), uint32(1663), uint32(1), uint32(1),
+		uint8(0xFE), uint8(0), uint16(0), uint32(5), uint32(16384), uint32(0))))
+	require.NoError(t, err)
+	assert.Equal(t, &StandbyInvalidations{
+		XlInvalidations: XlInvalidations{DbId: 5, TsId: 1663, RelcacheInitFileInval: true, Nmsgs: 1},
+		Msgs:            []SharedInvalidationMessage{{Id: SHAREDINVALRELCACHE_ID, Words: [3]uint32{5, 16384, 0}}},
+	}, decoded)
+}
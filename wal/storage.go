@@ -0,0 +1,70 @@
+package wal
+
+/*
+ * Declarations for smgr-related XLOG records
+ *
+ * Note: we log file creation and truncation here, but logging of deletion
+ * actions is handled by xact.c, because it is part of transaction commit.
+ */
+
+/* XLOG gives us high 4 bits */
+const (
+	XLOG_SMGR_CREATE   = 0x10
+	XLOG_SMGR_TRUNCATE = 0x20
+)
+
+type XlSmgrCreate struct {
+	Rnode   RelFileNode
+	ForkNum ForkNumber
+}
+
+func SizeofXlSmgrCreate() int64 {
+	return 16
+}
+
+/* flags for xl_smgr_truncate */
+const (
+	SMGR_TRUNCATE_HEAP = 0x0001
+	SMGR_TRUNCATE_VM   = 0x0002
+	SMGR_TRUNCATE_FSM  = 0x0004
+	SMGR_TRUNCATE_ALL  = SMGR_TRUNCATE_HEAP | SMGR_TRUNCATE_VM | SMGR_TRUNCATE_FSM
+)
+
+type XlSmgrTruncate struct {
+	Blkno BlockNumber
+	Rnode RelFileNode
+	Flags int32
+}
+
+func SizeofXlSmgrTruncate() int64 {
+	return 20
+}
+
+type SmgrCreate struct {
+	XlSmgrCreate
+}
+
+type SmgrTruncate struct {
+	XlSmgrTruncate
+}
+
+// DecodeSmgr decodes the records of RM_SMGR_ID into the struct of their info
+// code, e.g. *SmgrTruncate.
+func DecodeSmgr(record *Record) (interface{}, error) {
+	data := record.MainData
+	switch record.Info() {
+	case XLOG_SMGR_CREATE:
+		xlrec, err := readStruct[XlSmgrCreate](record, data, SizeofXlSmgrCreate())
+		if err != nil {
+			return nil, err
+		}
+		return &SmgrCreate{XlSmgrCreate: *xlrec}, nil
+	case XLOG_SMGR_TRUNCATE:
+		xlrec, err := readStruct[XlSmgrTruncate](record, data, SizeofXlSmgrTruncate())
+		if err != nil {
+			return nil, err
+		}
+		return &SmgrTruncate{XlSmgrTruncate: *xlrec}, nil
+	}
+	return nil, record.unknownInfo()
+}
@@ -0,0 +1,177 @@
+package wal
+
+import "fmt"
+
+type StorageEventKind int
+
+const (
+	RelationCreated StorageEventKind = iota
+	RelationTruncated
+	RelationDropped
+	DatabaseCreated
+	DatabaseDropped
+	TablespaceCreated
+	TablespaceDropped
+)
+
+var storageEventKindNames = [...]string{
+	RelationCreated:   "relation file created",
+	RelationTruncated: "relation truncated",
+	RelationDropped:   "relation dropped",
+	DatabaseCreated:   "database created",
+	DatabaseDropped:   "database dropped",
+	TablespaceCreated: "tablespace created",
+	TablespaceDropped: "tablespace dropped",
+}
+
+func (k StorageEventKind) String() string {
+	if k < 0 || int(k) >= len(storageEventKindNames) {
+		return fmt.Sprintf("StorageEventKind(%d)", int(k))
+	}
+	return storageEventKindNames[k]
+}
+
+// StorageEvent is a relation file, database or tablespace appearing,
+// shrinking or disappearing at LSN. Which fields are set depends on Kind:
+//
+//   - RelationCreated: Rel and ForkNum
+//   - RelationTruncated: Rel, Blocks and Flags, the SMGR_TRUNCATE_* forks
+//     truncated to Blocks blocks
+//   - RelationDropped: Rel and Xid, the transaction whose commit or abort
+//     dropped all forks of the relation
+//   - DatabaseCreated: DbId and TsId, plus SrcDbId and SrcTsId when copied
+//     from a template with the FILE_COPY strategy
+//   - DatabaseDropped: DbId and TsId, one event per tablespace
+//   - TablespaceCreated: TsId and Path
+//   - TablespaceDropped: TsId
+type StorageEvent struct {
+	LSN     XLogRecPtr
+	Kind    StorageEventKind
+	Rel     RelFileNode
+	ForkNum ForkNumber
+	Blocks  BlockNumber
+	Flags   int32
+	Xid     TransactionId
+	DbId    Oid
+	TsId    Oid
+	SrcDbId Oid
+	SrcTsId Oid
+	Path    string
+}
+
+func (e *StorageEvent) String() string {
+	switch e.Kind {
+	case RelationCreated:
+		return fmt.Sprintf("%s: %s %d/%d/%d fork %s", e.LSN, e.Kind, e.Rel.SpcNode, e.Rel.DbNode, e.Rel.RelNode, e.ForkNum)
+	case RelationTruncated:
+		return fmt.Sprintf("%s: relation %d/%d/%d truncated to %d blocks", e.LSN, e.Rel.SpcNode, e.Rel.DbNode, e.Rel.RelNode, e.Blocks)
+	case RelationDropped:
+		return fmt.Sprintf("%s: %s %d/%d/%d by transaction %d", e.LSN, e.Kind, e.Rel.SpcNode, e.Rel.DbNode, e.Rel.RelNode, e.Xid)
+	case DatabaseCreated, DatabaseDropped:
+		return fmt.Sprintf("%s: %s %d in tablespace %d", e.LSN, e.Kind, e.DbId, e.TsId)
+	case TablespaceCreated:
+		return fmt.Sprintf("%s: %s %d at %q", e.LSN, e.Kind, e.TsId, e.Path)
+	}
+	return fmt.Sprintf("%s: %s %d", e.LSN, e.Kind, e.TsId)
+}
+
+// StorageEvents returns the storage events of record, none for a record that
+// does not create, truncate or drop files.
+func StorageEvents(record *Record) ([]StorageEvent, error) {
+	switch record.Hdr.XlRmid {
+	case RM_SMGR_ID, RM_DBASE_ID, RM_TBLSPC_ID:
+	case RM_XACT_ID:
+		switch record.Info() & XLOG_XACT_OPMASK {
+		case XLOG_XACT_COMMIT, XLOG_XACT_COMMIT_PREPARED, XLOG_XACT_ABORT, XLOG_XACT_ABORT_PREPARED:
+		default:
+			return nil, nil
+		}
+	default:
+		return nil, nil
+	}
+	decoded, err := DecodeRmgrData(record)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []StorageEvent
+	add := func(event StorageEvent) {
+		event.LSN = record.LSN
+		events = append(events, event)
+	}
+	dropped := func(xnodes []RelFileNode, xid TransactionId) {
+		for _, rel := range xnodes {
+			add(StorageEvent{Kind: RelationDropped, Rel: rel, Xid: xid})
+		}
+	}
+	switch rec := decoded.(type) {
+	case *SmgrCreate:
+		add(StorageEvent{Kind: RelationCreated, Rel: rec.Rnode, ForkNum: rec.ForkNum})
+	case *SmgrTruncate:
+		add(StorageEvent{Kind: RelationTruncated, Rel: rec.Rnode, Blocks: rec.Blkno, Flags: rec.Flags})
+	case *DbaseCreateFileCopy:
+		add(StorageEvent{Kind: DatabaseCreated, DbId: rec.DbId, TsId: rec.TablespaceId, SrcDbId: rec.SrcDbId, SrcTsId: rec.SrcTablespaceId})
+	case *DbaseCreateWalLog:
+		add(StorageEvent{Kind: DatabaseCreated, DbId: rec.DbId, TsId: rec.TablespaceId})
+	case *DbaseDrop:
+		for _, tsId := range rec.TablespaceIds {
+			add(StorageEvent{Kind: DatabaseDropped, DbId: rec.DbId, TsId: tsId})
+		}
+	case *TblspcCreate:
+		add(StorageEvent{Kind: TablespaceCreated, TsId: rec.TsId, Path: rec.TsPath})
+	case *TblspcDrop:
+		add(StorageEvent{Kind: TablespaceDropped, TsId: rec.TsId})
+	case *XactCommit:
+		dropped(rec.Xnodes, xactXid(record, rec.Twophase))
+	case *XactAbort:
+		dropped(rec.Xnodes, xactXid(record, rec.Twophase))
+	}
+	return events, nil
+}
+
+// xactXid returns the transaction a commit or abort record ends, the prepared
+// one for COMMIT PREPARED and ABORT PREPARED.
+func xactXid(record *Record, twophase TransactionId) TransactionId {
+	if twophase != 0 {
+		return twophase
+	}
+	return record.Hdr.XlXid
+}
+
+// StorageEventReader reads the storage events of the records an XLogReader
+// reads, for tools tracking relation files.
+type StorageEventReader struct {
+	reader  *XLogReader
+	pending []StorageEvent
+}
+
+func NewStorageEventReader(reader *XLogReader) *StorageEventReader {
+	return &StorageEventReader{reader: reader}
+}
+
+// ReadEvent returns the next storage event. The errors of ReadRecord are
+// returned as they are, ErrEndOfWAL at the end of the available WAL.
+func (r *StorageEventReader) ReadEvent() (*StorageEvent, error) {
+	for len(r.pending) == 0 {
+		raw, err := r.reader.ReadRecord()
+		if err != nil {
+			return nil, err
+		}
+		switch raw.Hdr.XlRmid {
+		case RM_SMGR_ID, RM_DBASE_ID, RM_TBLSPC_ID, RM_XACT_ID:
+		default:
+			continue
+		}
+		record, err := raw.Decode()
+		if err != nil {
+			return nil, err
+		}
+		r.pending, err = StorageEvents(record)
+		if err != nil {
+			return nil, err
+		}
+	}
+	event := r.pending[0]
+	r.pending = r.pending[1:]
+	return &event, nil
+}
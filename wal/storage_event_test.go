@@ -0,0 +1,60 @@
+package wal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStorageEvents(t *testing.T) {
+	events, err := StorageEvents(testRecord(t, RM_SMGR_ID, XLOG_SMGR_TRUNCATE,
+		le(uint32(10), uint32(1663), uint32(5), uint32(16384), uint32(SMGR_TRUNCATE_HEAP))))
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, RelationTruncated, events[0].Kind)
+	assert.Equal(t, "0/00300028: relation 1663/5/16384 truncated to 10 blocks", events[0].String())
+
+	events, err = StorageEvents(testRecord(t, RM_DBASE_ID, XLOG_DBASE_DROP,
+		le(uint32(16400), uint32(2), uint32(1663), uint32(16500))))
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, StorageEvent{LSN: 0x300028, Kind: DatabaseDropped, DbId: 16400, TsId: 16500}, events[1])
+	assert.Equal(t, "0/00300028: database dropped 16400 in tablespace 1663", events[0].String())
+
+	record := testRecord(t, RM_XACT_ID, XLOG_XACT_ABORT|XLOG_XACT_HAS_INFO, le(
+		uint64(42), uint32(XACT_XINFO_HAS_RELFILENODES),
+		uint32(1), uint32(1663), uint32(5), uint32(16384)))
+	record.Hdr.XlXid = 900
+	events, err = StorageEvents(record)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, StorageEvent{LSN: 0x300028, Kind: RelationDropped, Rel: RelFileNode{1663, 5, 16384}, Xid: 900}, events[0])
+
+	events, err = StorageEvents(testRecord(t, RM_HEAP_ID, XLOG_HEAP_INSERT, []byte{1}))
+	require.NoError(t, err)
+	assert.Empty(t, events)
+}
+
+func TestStorageEventReader(t *testing.T) {
+	b := newWalBuilderVersion(1, 0x300000, PG16)
+	create := b.record(RM_SMGR_ID, XLOG_SMGR_CREATE, 0,
+		mainData(le(uint32(1663), uint32(5), uint32(16384), uint32(MAIN_FORKNUM))))
+	b.record(RM_HEAP_ID, XLOG_HEAP_INSERT, 800, mainData([]byte{1, 0, 0}))
+	b.record(RM_XACT_ID, XLOG_XACT_COMMIT, 800, mainData(le(uint64(42))))
+	tblspc := b.record(RM_TBLSPC_ID, XLOG_TBLSPC_CREATE, 0, mainData(le(uint32(16500), []byte("/mnt/ts1\x00"))))
+
+	reader, err := NewXLogReaderDir(b.dump(t), "000000010000000000000003", 8)
+	require.NoError(t, err)
+	defer reader.Close()
+	events := NewStorageEventReader(reader)
+
+	event, err := events.ReadEvent()
+	require.NoError(t, err)
+	assert.Equal(t, &StorageEvent{LSN: create, Kind: RelationCreated, Rel: RelFileNode{1663, 5, 16384}}, event)
+	event, err = events.ReadEvent()
+	require.NoError(t, err)
+	assert.Equal(t, &StorageEvent{LSN: tblspc, Kind: TablespaceCreated, TsId: 16500, Path: "/mnt/ts1"}, event)
+	_, err = events.ReadEvent()
+	assert.ErrorIs(t, err, ErrEndOfWAL)
+}
@@ -0,0 +1,28 @@
+package wal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeSmgr(t *testing.T) {
+	decoded, err := DecodeRmgrData(testRecord(t, RM_SMGR_ID, XLOG_SMGR_CREATE,
+		le(uint32(1663), uint32(5), uint32(16384), uint32(VISIBILITYMAP_FORKNUM))))
+	require.NoError(t, err)
+	create := decoded.(*SmgrCreate)
+	assert.Equal(t, RelFileNode{1663, 5, 16384}, create.Rnode)
+	assert.Equal(t, VISIBILITYMAP_FORKNUM, create.ForkNum)
+
+	decoded, err = DecodeRmgrData(testRecord(t, RM_SMGR_ID, XLOG_SMGR_TRUNCATE,
+		le(uint32(10), uint32(1663), uint32(5), uint32(16384), uint32(SMGR_TRUNCATE_ALL))))
+	require.NoError(t, err)
+	truncate := decoded.(*SmgrTruncate)
+	assert.Equal(t, BlockNumber(10), truncate.Blkno)
+	assert.Equal(t, RelFileNode{1663, 5, 16384}, truncate.Rnode)
+	assert.EqualValues(t, SMGR_TRUNCATE_ALL, truncate.Flags)
+
+	_, err = DecodeRmgrData(testRecord(t, RM_SMGR_ID, XLOG_SMGR_TRUNCATE, le(uint32(10))))
+	assert.ErrorIs(t, err, ErrInvalidRecord)
+}
@@ -0,0 +1,44 @@
+package wal
+
+import "bytes"
+
+/* XLOG stuff */
+const (
+	XLOG_TBLSPC_CREATE = 0x00
+	XLOG_TBLSPC_DROP   = 0x10
+)
+
+type TblspcCreate struct {
+	TsId   Oid
+	TsPath string /* null-terminated string */
+}
+
+type TblspcDrop struct {
+	TsId Oid
+}
+
+// DecodeTblspc decodes the records of RM_TBLSPC_ID into the struct of their
+// info code, e.g. *TblspcCreate.
+func DecodeTblspc(record *Record) (interface{}, error) {
+	data := record.MainData
+	switch record.Info() {
+	case XLOG_TBLSPC_CREATE:
+		tsId, err := readStruct[Oid](record, data, 4)
+		if err != nil {
+			return nil, err
+		}
+		path := data[4:]
+		end := bytes.IndexByte(path, 0)
+		if end < 0 {
+			return nil, record.errorf("tablespace path is not null-terminated")
+		}
+		return &TblspcCreate{TsId: *tsId, TsPath: string(path[:end])}, nil
+	case XLOG_TBLSPC_DROP:
+		tsId, err := readStruct[Oid](record, data, 4)
+		if err != nil {
+			return nil, err
+		}
+		return &TblspcDrop{TsId: *tsId}, nil
+	}
+	return nil, record.unknownInfo()
+}
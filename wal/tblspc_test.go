@@ -0,0 +1,22 @@
+package wal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeTblspc(t *testing.T) {
+	decoded, err := DecodeRmgrData(testRecord(t, RM_TBLSPC_ID, XLOG_TBLSPC_CREATE,
+		le(uint32(16500), []byte("/mnt/ts1\x00"))))
+	require.NoError(t, err)
+	assert.Equal(t, &TblspcCreate{TsId: 16500, TsPath: "/mnt/ts1"}, decoded)
+
+	decoded, err = DecodeRmgrData(testRecord(t, RM_TBLSPC_ID, XLOG_TBLSPC_DROP, le(uint32(16500))))
+	require.NoError(t, err)
+	assert.Equal(t, &TblspcDrop{TsId: 16500}, decoded)
+
+	_, err = DecodeRmgrData(testRecord(t, RM_TBLSPC_ID, XLOG_TBLSPC_CREATE, le(uint32(16500), []byte("/mnt"))))
+	assert.ErrorIs(t, err, ErrInvalidRecord)
+}
@@ -0,0 +1,139 @@
+package wal
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// TimeLineHistoryEntry tells that timeline Tli was in use from Begin up to
+// End, where the next timeline branched off. Begin of the first timeline
+// and End of the last one are 0.
+type TimeLineHistoryEntry struct {
+	Tli    TimeLineID
+	Begin  XLogRecPtr
+	End    XLogRecPtr
+	Reason string
+}
+
+// TimeLineHistory lists the timelines a timeline descends from, oldest
+// first. The last entry is the timeline itself.
+type TimeLineHistory []TimeLineHistoryEntry
+
+// TimeLineHistoryFileName returns the name of the history file of tli.
+func TimeLineHistoryFileName(tli TimeLineID) string {
+	return fmt.Sprintf("%08X.history", tli)
+}
+
+// ParseTimeLineHistory parses the content of the history file of timeline
+// tli, like readTimeLineHistory.
+func ParseTimeLineHistory(reader io.Reader, tli TimeLineID) (TimeLineHistory, error) {
+	var (
+		ret     TimeLineHistory
+		prevEnd XLogRecPtr
+	)
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+
+		var (
+			entry     TimeLineHistoryEntry
+			high, low uint32
+		)
+		fields := strings.SplitN(line, "\t", 3)
+		if _, err := fmt.Sscanf(fields[0], "%d", &entry.Tli); err != nil {
+			return nil, fmt.Errorf("syntax error in history file: %s", line)
+		}
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("syntax error in history file: %s, expected a write-ahead log switchpoint location", line)
+		}
+		if _, err := fmt.Sscanf(strings.TrimSpace(fields[1]), "%X/%X", &high, &low); err != nil {
+			return nil, fmt.Errorf("syntax error in history file: %s, expected a write-ahead log switchpoint location", line)
+		}
+		if len(fields) == 3 {
+			entry.Reason = strings.TrimSpace(fields[2])
+		}
+		if len(ret) > 0 && entry.Tli <= ret[len(ret)-1].Tli {
+			return nil, errors.New("invalid data in history file: timeline IDs must be in increasing sequence")
+		}
+
+		entry.Begin = prevEnd
+		entry.End = XLogRecPtr(uint64(high)<<32 | uint64(low))
+		prevEnd = entry.End
+		ret = append(ret, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(ret) > 0 && tli <= ret[len(ret)-1].Tli {
+		return nil, errors.New("invalid data in history file: timeline IDs must be less than child timeline's ID")
+	}
+	return append(ret, TimeLineHistoryEntry{Tli: tli, Begin: prevEnd}), nil
+}
+
+// HistorySource is implemented by the WALSources which also hold timeline
+// history files.
+type HistorySource interface {
+	OpenHistory(tli TimeLineID) (io.ReadCloser, error)
+}
+
+// ReadTimeLineHistory reads the history of timeline tli from source. The
+// first timeline has no history file.
+func ReadTimeLineHistory(source WALSource, tli TimeLineID) (TimeLineHistory, error) {
+	if tli == 1 {
+		return TimeLineHistory{{Tli: tli}}, nil
+	}
+	hs, ok := source.(HistorySource)
+	if !ok {
+		return nil, errors.New("source does not hold history files")
+	}
+	rc, err := hs.OpenHistory(tli)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return ParseTimeLineHistory(rc, tli)
+}
+
+// TimeLineOf returns the timeline lsn belongs to, like tliOfPointInHistory.
+func (h TimeLineHistory) TimeLineOf(lsn XLogRecPtr) (TimeLineID, error) {
+	for _, entry := range h {
+		if (entry.Begin == 0 || entry.Begin <= lsn) && (entry.End == 0 || lsn < entry.End) {
+			return entry.Tli, nil
+		}
+	}
+	return 0, fmt.Errorf("timeline history does not contain %s", lsn)
+}
+
+// Contains tells whether tli is one of the timelines of the history.
+func (h TimeLineHistory) Contains(tli TimeLineID) bool {
+	for _, entry := range h {
+		if entry.Tli == tli {
+			return true
+		}
+	}
+	return false
+}
+
+// segmentTimeLines returns the timelines whose file of segment segno may be
+// read, newest first, like XLogFileReadAnyTLI. A timeline is only a
+// candidate from the segment it begins on, and never older than minTli.
+func (h TimeLineHistory) segmentTimeLines(segno XLogSegNo, segmentSize uint32, minTli TimeLineID) []TimeLineID {
+	var ret []TimeLineID
+	for i := len(h) - 1; i >= 0; i-- {
+		entry := h[i]
+		if entry.Tli < minTli {
+			break
+		}
+		if entry.Begin != 0 && segno < XLogSegNo(entry.Begin/XLogRecPtr(segmentSize)) {
+			continue
+		}
+		ret = append(ret, entry.Tli)
+	}
+	return ret
+}
@@ -0,0 +1,94 @@
+package wal
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTimeLineHistory(t *testing.T) {
+	content := "1\t0/5000000\tno recovery target specified\n\n# comment\n2\t1/A0000028\tbefore 2024-01-01 00:00:00+00\n"
+	history, err := ParseTimeLineHistory(strings.NewReader(content), 3)
+	require.NoError(t, err)
+	assert.Equal(t, TimeLineHistory{
+		{Tli: 1, Begin: 0, End: 0x5000000, Reason: "no recovery target specified"},
+		{Tli: 2, Begin: 0x5000000, End: 0x1A0000028, Reason: "before 2024-01-01 00:00:00+00"},
+		{Tli: 3, Begin: 0x1A0000028},
+	}, history)
+
+	for lsn, tli := range map[XLogRecPtr]TimeLineID{0: 1, 0x4FFFFFF: 1, 0x5000000: 2, 0x1A0000028: 3, 0xFFFFFFFFF: 3} {
+		got, err := history.TimeLineOf(lsn)
+		assert.NoError(t, err)
+		assert.Equal(t, tli, got, "%s", lsn)
+	}
+	assert.True(t, history.Contains(2))
+	assert.False(t, history.Contains(4))
+
+	for _, invalid := range []string{"x\t0/1\n", "1\n", "1\tzz\n", "2\t0/1\n1\t0/2\n", "3\t0/1\n"} {
+		_, err := ParseTimeLineHistory(strings.NewReader(invalid), 3)
+		assert.Error(t, err, invalid)
+	}
+	assert.Equal(t, "0000000A.history", TimeLineHistoryFileName(10))
+}
+
+func TestXLogReaderHistory(t *testing.T) {
+	parent := newWalBuilder(1, 0x300000)
+	var lsns []XLogRecPtr
+	for i := 0; i < 400; i++ {
+		lsns = append(lsns, parent.record(RM_HEAP_ID, 0, TransactionId(i), mainData(bytes.Repeat([]byte{1}, 3000))))
+	}
+	switchpoint := parent.pos()
+	child := parent.fork(2)
+	for i := 0; i < 400; i++ {
+		// the old primary went on after the promotion
+		parent.record(RM_HEAP_ID, 0, TransactionId(i), mainData(bytes.Repeat([]byte{0xFF}, 3000)))
+		lsns = append(lsns, child.record(RM_HEAP_ID, 0, TransactionId(i), mainData(bytes.Repeat([]byte{2}, 3000))))
+	}
+
+	segments := parent.segments()
+	for name, data := range child.segments() {
+		if name >= "000000020000000000000004" {
+			segments[name] = data
+		}
+	}
+	segments[TimeLineHistoryFileName(2)] = []byte(fmt.Sprintf("1\t%s\tno recovery target specified\n", switchpoint))
+	source := NewMemorySource(segments)
+
+	history, err := ReadTimeLineHistory(source, 2)
+	require.NoError(t, err)
+	reader, err := NewXLogReaderHistory(source, history, 0x300000, 8)
+	require.NoError(t, err)
+	defer reader.Close()
+	records, err := readAll(t, reader)
+	assert.ErrorIs(t, err, ErrEndOfWAL)
+	require.Len(t, records, len(lsns))
+	for i, record := range records {
+		assert.Equal(t, lsns[i], record.LSN)
+		if record.LSN < switchpoint {
+			assert.EqualValues(t, 1, record.MainData[0])
+		} else {
+			assert.EqualValues(t, 2, record.MainData[0])
+		}
+	}
+	assert.EqualValues(t, 2, reader.TimeLine())
+
+	// without the history the reader stays on the parent timeline
+	reader, err = NewXLogReaderSource(source, 1, 0x300000, 8)
+	require.NoError(t, err)
+	defer reader.Close()
+	records, _ = readAll(t, reader)
+	assert.EqualValues(t, 0xFF, records[len(records)-1].MainData[0])
+
+	// a truncated segment on the new timeline leaves the reader on the old one
+	segments["000000020000000000000004"] = segments["000000020000000000000004"][:10]
+	reader, err = NewXLogReaderHistory(source, history, 0x300000, 8)
+	require.NoError(t, err)
+	defer reader.Close()
+	_, err = readAll(t, reader)
+	assert.Error(t, err)
+	assert.EqualValues(t, 1, reader.TimeLine())
+}
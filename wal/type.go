@@ -1,6 +1,9 @@
 package wal
 
-import "fmt"
+import (
+	"fmt"
+	"time"
+)
 
 type Oid uint32
 type TransactionId uint32
@@ -9,8 +12,35 @@ type TimeLineID uint32
 
 type XLogRecPtr uint64
 
+// XLogSegNo numbers the segments of the WAL, the segment holding lsn is
+// lsn / segment size.
+type XLogSegNo uint64
+
 func (lsn XLogRecPtr) String() string {
 	high := uint64(lsn) >> 32
 	low := uint64(lsn) & 0xFFFFFFFF
 	return fmt.Sprintf("%X/%08X", high, low)
 }
+
+type OffsetNumber uint16
+
+// TimestampTz is a timestamp of the server, in microseconds since
+// 2000-01-01 00:00:00 UTC.
+type TimestampTz int64
+
+// postgresEpoch is the Unix time of 2000-01-01 00:00:00 UTC.
+const postgresEpoch = 946684800
+
+// Time converts the timestamp to a time.Time, like timestamptz_to_time_t
+// does with a better precision.
+func (ts TimestampTz) Time() time.Time {
+	return time.Unix(postgresEpoch+int64(ts)/1e6, int64(ts)%1e6*1e3).UTC()
+}
+
+const InvalidTransactionId TransactionId = 0
+
+// Precedes compares two transaction ids modulo 2^32, like
+// TransactionIdPrecedes for normal transaction ids.
+func (xid TransactionId) Precedes(other TransactionId) bool {
+	return int32(xid-other) < 0
+}
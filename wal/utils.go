@@ -21,3 +21,44 @@ func WalName(tli TimeLineID, lsn XLogRecPtr, segmentSize uint32) (string, error)
 	logSeq := uint64(lsn) & mask
 	return fmt.Sprintf("%08X%08X%08X", tli, logId, logSeq/uint64(segmentSize)), nil
 }
+
+// SegmentName returns the file name of segment segno of timeline tli.
+func SegmentName(tli TimeLineID, segno XLogSegNo, segmentSize uint32) string {
+	perId := uint64(0x100000000) / uint64(segmentSize)
+	return fmt.Sprintf("%08X%08X%08X", tli, uint64(segno)/perId, uint64(segno)%perId)
+}
+
+// ParseWalName is the reverse of SegmentName.
+func ParseWalName(walname string, segmentSize uint32) (TimeLineID, XLogSegNo, error) {
+	var (
+		tli    TimeLineID
+		logId  uint64
+		logSeq uint64
+	)
+	if !isWalName(walname) {
+		return 0, 0, fmt.Errorf("invalid segment file name %s", walname)
+	}
+	_, err := fmt.Sscanf(walname, "%08X%08X%08X", &tli, &logId, &logSeq)
+	if err != nil {
+		return 0, 0, err
+	}
+	perId := uint64(0x100000000) / uint64(segmentSize)
+	return tli, XLogSegNo(logId*perId + logSeq), nil
+}
+
+func isWalName(name string) bool {
+	if len(name) != 24 {
+		return false
+	}
+	for _, c := range name {
+		if !('0' <= c && c <= '9' || 'A' <= c && c <= 'F') {
+			return false
+		}
+	}
+	return true
+}
+
+// isValidWalSegSize mirrors IsValidWalSegSize: a power of 2 between 1MB and 1GB.
+func isValidWalSegSize(size uint32) bool {
+	return size&(size-1) == 0 && size >= 1024*1024 && size <= 1024*1024*1024
+}
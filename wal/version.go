@@ -0,0 +1,57 @@
+package wal
+
+import "fmt"
+
+// PgVersion is the major version of the PostgreSQL server which wrote the
+// WAL. It is told by the XLOG_PAGE_MAGIC of the page headers.
+type PgVersion uint32
+
+const (
+	PG12 PgVersion = 12
+	PG13 PgVersion = 13
+	PG14 PgVersion = 14
+	PG15 PgVersion = 15
+	PG16 PgVersion = 16
+	PG17 PgVersion = 17
+)
+
+var pageMagics = map[uint16]PgVersion{
+	0xD101: PG12,
+	0xD106: PG13,
+	0xD10D: PG14,
+	0xD110: PG15,
+	0xD113: PG16,
+	0xD116: PG17,
+}
+
+// VersionOfMagic returns the server version which writes page headers with
+// xlp_magic magic.
+func VersionOfMagic(magic uint16) (PgVersion, bool) {
+	v, ok := pageMagics[magic]
+	return v, ok
+}
+
+// PageMagic returns the XLOG_PAGE_MAGIC of version v.
+func (v PgVersion) PageMagic() uint16 {
+	for magic, version := range pageMagics {
+		if version == v {
+			return magic
+		}
+	}
+	return 0
+}
+
+func (v PgVersion) String() string {
+	return fmt.Sprintf("PostgreSQL %d", uint32(v))
+}
+
+// catalogVersions are the CATALOG_VERSION_NO of the releases, which name
+// the tablespace directories of the server.
+var catalogVersions = map[PgVersion]uint32{
+	PG12: 201909212,
+	PG13: 202007201,
+	PG14: 202107181,
+	PG15: 202209061,
+	PG16: 202307071,
+	PG17: 202406281,
+}
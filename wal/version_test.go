@@ -0,0 +1,63 @@
+package wal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVersionOfMagic(t *testing.T) {
+	for _, v := range []PgVersion{PG12, PG13, PG14, PG15, PG16, PG17} {
+		got, ok := VersionOfMagic(v.PageMagic())
+		assert.True(t, ok)
+		assert.Equal(t, v, got)
+	}
+	_, ok := VersionOfMagic(0xD000)
+	assert.False(t, ok)
+	assert.EqualValues(t, XLOG_PAGE_MAGIC, PG12.PageMagic())
+}
+
+func TestXLogReaderVersion(t *testing.T) {
+	b := newWalBuilderVersion(1, 0x300000, PG16)
+	b.record(RM_HEAP_ID, 0, 1, mainData([]byte("first")))
+	custom := b.record(RM_MIN_CUSTOM_ID, 0, 2, mainData([]byte("custom")))
+	dir := b.dump(t)
+
+	reader, err := NewXLogReaderDir(dir, "000000010000000000000003", 8)
+	require.NoError(t, err)
+	defer reader.Close()
+	records, err := readAll(t, reader)
+	assert.ErrorIs(t, err, ErrEndOfWAL)
+	require.Len(t, records, 2)
+	assert.Equal(t, PG16, reader.Version())
+	assert.Equal(t, PG16, records[0].Version)
+	assert.Equal(t, custom, records[1].LSN)
+	assert.Equal(t, "custom128", RmgrIdName(records[1].Hdr.XlRmid))
+}
+
+func TestXLogReaderMixedVersions(t *testing.T) {
+	b := newWalBuilderVersion(1, 0x300000, PG15)
+	b.record(RM_HEAP_ID, 0, 1, mainData([]byte("first")))
+	b.magic = PG16.PageMagic()
+	b.record(RM_HEAP_ID, 0, 2, mainData(make([]byte, 10000)))
+	dir := b.dump(t)
+
+	reader, err := NewXLogReaderDir(dir, "000000010000000000000003", 8)
+	require.NoError(t, err)
+	defer reader.Close()
+	records, err := readAll(t, reader)
+	assert.Len(t, records, 1)
+	assert.ErrorIs(t, err, ErrInvalidPageHeader)
+}
+
+func TestBlockImageFlags(t *testing.T) {
+	pre15 := XLogRecordBlockImageHeader{BimgInfo: BKPIMAGE_HAS_HOLE | BKPIMAGE_IS_COMPRESSED}
+	assert.True(t, pre15.IsCompressed(PG14))
+	assert.False(t, pre15.IsApply(PG14))
+	assert.True(t, pre15.IsApply(PG15))
+	assert.False(t, pre15.IsCompressed(PG15))
+
+	lz4 := XLogRecordBlockImageHeader{BimgInfo: BKPIMAGE_COMPRESS_LZ4}
+	assert.True(t, lz4.IsCompressed(PG16))
+}
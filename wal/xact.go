@@ -0,0 +1,430 @@
+package wal
+
+import (
+	"bytes"
+	"unsafe"
+)
+
+const (
+	/*
+	 * XLOG allows to store some information in high 4 bits of log record xl_info
+	 * field. We use 3 for the opcode, and one about an optional flag variable.
+	 */
+	XLOG_XACT_COMMIT          = 0x00
+	XLOG_XACT_PREPARE         = 0x10
+	XLOG_XACT_ABORT           = 0x20
+	XLOG_XACT_COMMIT_PREPARED = 0x30
+	XLOG_XACT_ABORT_PREPARED  = 0x40
+	XLOG_XACT_ASSIGNMENT      = 0x50
+	/* Since PostgreSQL 14 */
+	XLOG_XACT_INVALIDATIONS = 0x60
+	/* free opcode 0x70 */
+
+	/* mask for filtering opcodes out of xl_info */
+	XLOG_XACT_OPMASK = 0x70
+
+	/* does this record have a 'xinfo' field or not */
+	XLOG_XACT_HAS_INFO = 0x80
+)
+
+const (
+	/*
+	 * The following flags, stored in xinfo, determine which information is
+	 * contained in commit/abort records.
+	 */
+	XACT_XINFO_HAS_DBINFO       = 1 << 0
+	XACT_XINFO_HAS_SUBXACTS     = 1 << 1
+	XACT_XINFO_HAS_RELFILENODES = 1 << 2
+	XACT_XINFO_HAS_INVALS       = 1 << 3
+	XACT_XINFO_HAS_TWOPHASE     = 1 << 4
+	XACT_XINFO_HAS_ORIGIN       = 1 << 5
+	XACT_XINFO_HAS_AE_LOCKS     = 1 << 6
+	XACT_XINFO_HAS_GID          = 1 << 7
+	/* Since PostgreSQL 15 */
+	XACT_XINFO_HAS_DROPPED_STATS = 1 << 8
+
+	/*
+	 * Also stored in xinfo, these indicating a variety of additional actions that
+	 * need to occur when emulating transaction effects during recovery.
+	 *
+	 * They are named XactCompletion... to differentiate them from
+	 * EOXact... routines which run at the end of the original transaction.
+	 *
+	 * Consider XACT_COMPLETION_UPDATE_RELCACHE_FILE as a separate flag from
+	 * XACT_XINFO_HAS_INVALS.
+	 */
+	XACT_COMPLETION_APPLY_FEEDBACK       = 1 << 29
+	XACT_COMPLETION_UPDATE_RELCACHE_FILE = 1 << 30
+	XACT_COMPLETION_FORCE_SYNC_COMMIT    = 1 << 31
+)
+
+/*
+ * SharedInvalidationMessage is a union of the invalidation messages. Id
+ * tells the kind of message, a catalog cache id when not negative, and how
+ * the words are to be read.
+ */
+type SharedInvalidationMessage struct {
+	Id        int8
+	BackendHi int8 /* smgr invalidation only */
+	BackendLo uint16
+	Words     [3]uint32
+}
+
+const (
+	SHAREDINVALCATALOG_ID  = -1
+	SHAREDINVALRELCACHE_ID = -2
+	SHAREDINVALSMGR_ID     = -3
+	SHAREDINVALRELMAP_ID   = -4
+	SHAREDINVALSNAPSHOT_ID = -5
+)
+
+type XlXactAssignment struct {
+	Xtop      TransactionId /* assigned XID's top-level XID */
+	Nsubxacts int32         /* number of subtransaction XIDs */
+	/* SUBTRANSACTION XIDS FOLLOW */
+}
+
+func SizeofXlXactAssignment() int64 {
+	return 8
+}
+
+/* xl_xact_stats_item, since PostgreSQL 15 */
+type XlXactStatsItem struct {
+	Kind   int32
+	Dboid  Oid
+	Objoid Oid
+}
+
+/*
+ * xl_xact_prepare, the header of the two-phase state of a transaction, since
+ * PostgreSQL 15. The GID, subxacts, rels, stats and invalidation messages
+ * follow, MAXALIGNed.
+ */
+type XlXactPrepare struct {
+	Magic           uint32        /* format identifier */
+	TotalLen        uint32        /* actual file length */
+	Xid             TransactionId /* original transaction XID */
+	Database        Oid           /* OID of database it was in */
+	PreparedAt      TimestampTz   /* time of preparation */
+	Owner           Oid           /* user running the transaction */
+	Nsubxacts       int32         /* number of following subxact XIDs */
+	Ncommitrels     int32         /* number of delete-on-commit rels */
+	Nabortrels      int32         /* number of delete-on-abort rels */
+	Ncommitstats    int32         /* number of stats to drop on commit */
+	Nabortstats     int32         /* number of stats to drop on abort */
+	Ninvalmsgs      int32         /* number of cache invalidation messages */
+	Initfileinval   bool          /* does relcache init file need invalidation? */
+	Gidlen          uint16        /* length of the GID - GID follows the header */
+	OriginLsn       XLogRecPtr    /* lsn of this record at origin node */
+	OriginTimestamp TimestampTz   /* time of prepare at origin node */
+}
+
+func SizeofXlXactPrepare(v PgVersion) int64 {
+	if v >= PG15 {
+		return 72
+	}
+	return 64
+}
+
+/* xl_xact_prepare up to PostgreSQL 14, without the stats */
+type xlXactPrepare14 struct {
+	Magic           uint32
+	TotalLen        uint32
+	Xid             TransactionId
+	Database        Oid
+	PreparedAt      TimestampTz
+	Owner           Oid
+	Nsubxacts       int32
+	Ncommitrels     int32
+	Nabortrels      int32
+	Ninvalmsgs      int32
+	Initfileinval   bool
+	Gidlen          uint16
+	OriginLsn       XLogRecPtr
+	OriginTimestamp TimestampTz
+}
+
+/* xl_xact_origin, unaligned in the record */
+type XlXactOrigin struct {
+	OriginLsn       XLogRecPtr
+	OriginTimestamp TimestampTz
+}
+
+// XactCommit is an XLOG_XACT_COMMIT or XLOG_XACT_COMMIT_PREPARED record with
+// its optional parts, like xl_xact_parsed_commit.
+type XactCommit struct {
+	XactTime TimestampTz
+	Xinfo    uint32
+	Prepared bool // XLOG_XACT_COMMIT_PREPARED
+
+	DbId Oid /* MyDatabaseId */
+	TsId Oid /* MyDatabaseTableSpace */
+
+	Subxacts        []TransactionId
+	Xnodes          []RelFileNode
+	Stats           []XlXactStatsItem
+	Msgs            []SharedInvalidationMessage
+	Twophase        TransactionId /* only for 2PC */
+	TwophaseGid     string        /* only for 2PC */
+	OriginLsn       XLogRecPtr
+	OriginTimestamp TimestampTz
+}
+
+// XactAbort is an XLOG_XACT_ABORT or XLOG_XACT_ABORT_PREPARED record with
+// its optional parts, like xl_xact_parsed_abort.
+type XactAbort struct {
+	XactTime TimestampTz
+	Xinfo    uint32
+	Prepared bool // XLOG_XACT_ABORT_PREPARED
+
+	DbId Oid
+	TsId Oid
+
+	Subxacts        []TransactionId
+	Xnodes          []RelFileNode
+	Stats           []XlXactStatsItem
+	Twophase        TransactionId
+	TwophaseGid     string
+	OriginLsn       XLogRecPtr
+	OriginTimestamp TimestampTz
+}
+
+// XactPrepare is an XLOG_XACT_PREPARE record, like
+// xl_xact_parsed_prepare.
+type XactPrepare struct {
+	XlXactPrepare
+	Gid         string
+	Subxacts    []TransactionId
+	CommitRels  []RelFileNode
+	AbortRels   []RelFileNode
+	CommitStats []XlXactStatsItem
+	AbortStats  []XlXactStatsItem
+	Msgs        []SharedInvalidationMessage
+}
+
+type XactAssignment struct {
+	XlXactAssignment
+	Xsub []TransactionId
+}
+
+// XactInvalidations is an XLOG_XACT_INVALIDATIONS record, logged under
+// wal_level=logical since PostgreSQL 14.
+type XactInvalidations struct {
+	Msgs []SharedInvalidationMessage
+}
+
+// DecodeXact decodes the records of RM_XACT_ID into the struct of their info
+// code, e.g. *XactCommit.
+func DecodeXact(record *Record) (interface{}, error) {
+	data := record.MainData
+	switch record.Info() & XLOG_XACT_OPMASK {
+	case XLOG_XACT_COMMIT, XLOG_XACT_COMMIT_PREPARED:
+		parsed, err := parseXact(record, true)
+		if err != nil {
+			return nil, err
+		}
+		parsed.Prepared = record.Info()&XLOG_XACT_OPMASK == XLOG_XACT_COMMIT_PREPARED
+		return parsed, nil
+	case XLOG_XACT_ABORT, XLOG_XACT_ABORT_PREPARED:
+		parsed, err := parseXact(record, false)
+		if err != nil {
+			return nil, err
+		}
+		return &XactAbort{
+			XactTime:        parsed.XactTime,
+			Xinfo:           parsed.Xinfo,
+			Prepared:        record.Info()&XLOG_XACT_OPMASK == XLOG_XACT_ABORT_PREPARED,
+			DbId:            parsed.DbId,
+			TsId:            parsed.TsId,
+			Subxacts:        parsed.Subxacts,
+			Xnodes:          parsed.Xnodes,
+			Stats:           parsed.Stats,
+			Twophase:        parsed.Twophase,
+			TwophaseGid:     parsed.TwophaseGid,
+			OriginLsn:       parsed.OriginLsn,
+			OriginTimestamp: parsed.OriginTimestamp,
+		}, nil
+	case XLOG_XACT_PREPARE:
+		return decodeXactPrepare(record)
+	case XLOG_XACT_ASSIGNMENT:
+		xlrec, err := readStruct[XlXactAssignment](record, data, SizeofXlXactAssignment())
+		if err != nil {
+			return nil, err
+		}
+		xsub, err := readArray[TransactionId](record, data[SizeofXlXactAssignment():], int(xlrec.Nsubxacts))
+		if err != nil {
+			return nil, err
+		}
+		return &XactAssignment{XlXactAssignment: *xlrec, Xsub: xsub}, nil
+	case XLOG_XACT_INVALIDATIONS:
+		msgs, _, err := readCountedArray[SharedInvalidationMessage](record, data)
+		if err != nil {
+			return nil, err
+		}
+		return &XactInvalidations{Msgs: msgs}, nil
+	}
+	return nil, record.unknownInfo()
+}
+
+// parseXact decodes a commit or abort record, like ParseCommitRecord and
+// ParseAbortRecord. Only commit records hold invalidation messages.
+func parseXact(record *Record, commit bool) (*XactCommit, error) {
+	data := record.MainData
+	xactTime, err := readStruct[TimestampTz](record, data, 8)
+	if err != nil {
+		return nil, err
+	}
+	data = data[8:]
+	ret := &XactCommit{XactTime: *xactTime}
+
+	if record.Info()&XLOG_XACT_HAS_INFO != 0 {
+		xinfo, err := readStruct[uint32](record, data, 4)
+		if err != nil {
+			return nil, err
+		}
+		ret.Xinfo = *xinfo
+		data = data[4:]
+	}
+	if ret.Xinfo&XACT_XINFO_HAS_DBINFO != 0 {
+		ids, err := readArray[Oid](record, data, 2)
+		if err != nil {
+			return nil, err
+		}
+		ret.DbId, ret.TsId = ids[0], ids[1]
+		data = data[8:]
+	}
+	if ret.Xinfo&XACT_XINFO_HAS_SUBXACTS != 0 {
+		ret.Subxacts, data, err = readCountedArray[TransactionId](record, data)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if ret.Xinfo&XACT_XINFO_HAS_RELFILENODES != 0 {
+		ret.Xnodes, data, err = readCountedArray[RelFileNode](record, data)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if ret.Xinfo&XACT_XINFO_HAS_DROPPED_STATS != 0 {
+		ret.Stats, data, err = readCountedArray[XlXactStatsItem](record, data)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if commit && ret.Xinfo&XACT_XINFO_HAS_INVALS != 0 {
+		ret.Msgs, data, err = readCountedArray[SharedInvalidationMessage](record, data)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if ret.Xinfo&XACT_XINFO_HAS_TWOPHASE != 0 {
+		xid, err := readStruct[TransactionId](record, data, 4)
+		if err != nil {
+			return nil, err
+		}
+		ret.Twophase = *xid
+		data = data[4:]
+		if ret.Xinfo&XACT_XINFO_HAS_GID != 0 {
+			end := bytes.IndexByte(data, 0)
+			if end < 0 {
+				return nil, record.errorf("unterminated GID")
+			}
+			ret.TwophaseGid = string(data[:end])
+			data = data[end+1:]
+		}
+	}
+	if ret.Xinfo&XACT_XINFO_HAS_ORIGIN != 0 {
+		origin, err := readStruct[XlXactOrigin](record, data, 16)
+		if err != nil {
+			return nil, err
+		}
+		ret.OriginLsn = origin.OriginLsn
+		ret.OriginTimestamp = origin.OriginTimestamp
+	}
+	return ret, nil
+}
+
+// decodeXactPrepare decodes a two-phase state, like ParsePrepareRecord.
+func decodeXactPrepare(record *Record) (*XactPrepare, error) {
+	data := record.MainData
+	ret := &XactPrepare{}
+	if record.Version >= PG15 {
+		xlrec, err := readStruct[XlXactPrepare](record, data, SizeofXlXactPrepare(record.Version))
+		if err != nil {
+			return nil, err
+		}
+		ret.XlXactPrepare = *xlrec
+	} else {
+		xlrec, err := readStruct[xlXactPrepare14](record, data, SizeofXlXactPrepare(record.Version))
+		if err != nil {
+			return nil, err
+		}
+		ret.XlXactPrepare = XlXactPrepare{
+			Magic:           xlrec.Magic,
+			TotalLen:        xlrec.TotalLen,
+			Xid:             xlrec.Xid,
+			Database:        xlrec.Database,
+			PreparedAt:      xlrec.PreparedAt,
+			Owner:           xlrec.Owner,
+			Nsubxacts:       xlrec.Nsubxacts,
+			Ncommitrels:     xlrec.Ncommitrels,
+			Nabortrels:      xlrec.Nabortrels,
+			Ninvalmsgs:      xlrec.Ninvalmsgs,
+			Initfileinval:   xlrec.Initfileinval,
+			Gidlen:          xlrec.Gidlen,
+			OriginLsn:       xlrec.OriginLsn,
+			OriginTimestamp: xlrec.OriginTimestamp,
+		}
+	}
+
+	off := maxAlign(int(SizeofXlXactPrepare(record.Version)))
+	// next returns the following MAXALIGNed part of n bytes.
+	next := func(n int) ([]byte, error) {
+		if n < 0 || off+n > len(data) {
+			return nil, record.errorf("two-phase state past the end of record")
+		}
+		part := data[off : off+n]
+		off += maxAlign(n)
+		if off > len(data) {
+			off = len(data)
+		}
+		return part, nil
+	}
+
+	gid, err := next(int(ret.Gidlen))
+	if err != nil {
+		return nil, err
+	}
+	ret.Gid = string(bytes.TrimRight(gid, "\x00"))
+	if ret.Subxacts, err = readPreparePart[TransactionId](record, next, int(ret.Nsubxacts)); err != nil {
+		return nil, err
+	}
+	if ret.CommitRels, err = readPreparePart[RelFileNode](record, next, int(ret.Ncommitrels)); err != nil {
+		return nil, err
+	}
+	if ret.AbortRels, err = readPreparePart[RelFileNode](record, next, int(ret.Nabortrels)); err != nil {
+		return nil, err
+	}
+	if ret.CommitStats, err = readPreparePart[XlXactStatsItem](record, next, int(ret.Ncommitstats)); err != nil {
+		return nil, err
+	}
+	if ret.AbortStats, err = readPreparePart[XlXactStatsItem](record, next, int(ret.Nabortstats)); err != nil {
+		return nil, err
+	}
+	if ret.Msgs, err = readPreparePart[SharedInvalidationMessage](record, next, int(ret.Ninvalmsgs)); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+func readPreparePart[T any](record *Record, next func(n int) ([]byte, error), n int) ([]T, error) {
+	var elem T
+	if n < 0 {
+		return nil, record.errorf("negative count %d in two-phase state", n)
+	}
+	data, err := next(n * int(unsafe.Sizeof(elem)))
+	if err != nil {
+		return nil, err
+	}
+	return readArray[T](record, data, n)
+}
@@ -0,0 +1,110 @@
+package wal
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimestampTz(t *testing.T) {
+	assert.Equal(t, time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC), TimestampTz(0).Time())
+	assert.Equal(t, time.Date(2024, 3, 1, 12, 30, 0, 1000, time.UTC),
+		TimestampTz(time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC).Sub(time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC))/time.Microsecond+1).Time())
+	assert.Equal(t, time.Date(1999, 12, 31, 23, 59, 59, 999999000, time.UTC), TimestampTz(-1).Time())
+}
+
+func TestDecodeXact(t *testing.T) {
+	t.Run("commit", func(t *testing.T) {
+		xinfo := uint32(XACT_XINFO_HAS_DBINFO | XACT_XINFO_HAS_SUBXACTS | XACT_XINFO_HAS_RELFILENODES |
+			XACT_XINFO_HAS_DROPPED_STATS | XACT_XINFO_HAS_INVALS | XACT_XINFO_HAS_TWOPHASE | XACT_XINFO_HAS_GID |
+			XACT_XINFO_HAS_ORIGIN | XACT_COMPLETION_FORCE_SYNC_COMMIT)
+		record := testRecord(t, RM_XACT_ID, XLOG_XACT_COMMIT_PREPARED|XLOG_XACT_HAS_INFO, le(
+			uint64(1000000), xinfo,
+			uint32(5), uint32(1663),
+			uint32(2), uint32(801), uint32(802),
+			uint32(1), uint32(1663), uint32(5), uint32(16384),
+			uint32(1), uint32(1), uint32(5), uint32(16384),
+			uint32(1), uint8(0xFE), uint8(0), uint16(0), uint32(5), uint32(16384), uint32(0),
+			uint32(800), []byte("gid-1\x00"),
+			uint64(0x1000028), uint64(2000000),
+		))
+		decoded, err := DecodeRmgrData(record)
+		require.NoError(t, err)
+		commit := decoded.(*XactCommit)
+		assert.True(t, commit.Prepared)
+		assert.Equal(t, time.Date(2000, 1, 1, 0, 0, 1, 0, time.UTC), commit.XactTime.Time())
+		assert.Equal(t, Oid(5), commit.DbId)
+		assert.Equal(t, Oid(1663), commit.TsId)
+		assert.Equal(t, []TransactionId{801, 802}, commit.Subxacts)
+		assert.Equal(t, []RelFileNode{{1663, 5, 16384}}, commit.Xnodes)
+		assert.Equal(t, []XlXactStatsItem{{1, 5, 16384}}, commit.Stats)
+		require.Len(t, commit.Msgs, 1)
+		assert.EqualValues(t, SHAREDINVALRELCACHE_ID, commit.Msgs[0].Id)
+		assert.Equal(t, uint32(16384), commit.Msgs[0].Words[1])
+		assert.Equal(t, TransactionId(800), commit.Twophase)
+		assert.Equal(t, "gid-1", commit.TwophaseGid)
+		assert.Equal(t, XLogRecPtr(0x1000028), commit.OriginLsn)
+		assert.Equal(t, TimestampTz(2000000), commit.OriginTimestamp)
+	})
+	t.Run("plain commit", func(t *testing.T) {
+		decoded, err := DecodeRmgrData(testRecord(t, RM_XACT_ID, XLOG_XACT_COMMIT, le(uint64(42))))
+		require.NoError(t, err)
+		commit := decoded.(*XactCommit)
+		assert.Equal(t, TimestampTz(42), commit.XactTime)
+		assert.False(t, commit.Prepared)
+		assert.Zero(t, commit.Xinfo)
+	})
+	t.Run("abort", func(t *testing.T) {
+		record := testRecord(t, RM_XACT_ID, XLOG_XACT_ABORT|XLOG_XACT_HAS_INFO, le(
+			uint64(42), uint32(XACT_XINFO_HAS_SUBXACTS|XACT_XINFO_HAS_RELFILENODES),
+			uint32(1), uint32(801),
+			uint32(1), uint32(1663), uint32(5), uint32(16384),
+		))
+		decoded, err := DecodeRmgrData(record)
+		require.NoError(t, err)
+		abort := decoded.(*XactAbort)
+		assert.Equal(t, []TransactionId{801}, abort.Subxacts)
+		assert.Equal(t, []RelFileNode{{1663, 5, 16384}}, abort.Xnodes)
+	})
+	t.Run("truncated", func(t *testing.T) {
+		record := testRecord(t, RM_XACT_ID, XLOG_XACT_ABORT|XLOG_XACT_HAS_INFO, le(
+			uint64(42), uint32(XACT_XINFO_HAS_SUBXACTS), uint32(3), uint32(801)))
+		_, err := DecodeRmgrData(record)
+		assert.ErrorIs(t, err, ErrInvalidRecord)
+	})
+	t.Run("assignment", func(t *testing.T) {
+		decoded, err := DecodeRmgrData(testRecord(t, RM_XACT_ID, XLOG_XACT_ASSIGNMENT,
+			le(uint32(800), uint32(2), uint32(801), uint32(802))))
+		require.NoError(t, err)
+		assignment := decoded.(*XactAssignment)
+		assert.Equal(t, TransactionId(800), assignment.Xtop)
+		assert.Equal(t, []TransactionId{801, 802}, assignment.Xsub)
+	})
+	for _, v := range []PgVersion{PG14, PG16} {
+		t.Run("prepare "+v.String(), func(t *testing.T) {
+			header := le(uint32(0x57F94534), uint32(0), uint32(800), uint32(5), uint64(42), uint32(10), uint32(1), uint32(1), uint32(0))
+			if v >= PG15 {
+				header = le(header, uint32(0), uint32(0))
+			}
+			header = le(header, uint32(0), uint8(0), uint8(0), uint16(6), uint64(0), uint64(0))
+			record := versioned(testRecord(t, RM_XACT_ID, XLOG_XACT_PREPARE, le(
+				header,
+				[]byte("gid-1\x00"), make([]byte, 2),
+				uint32(801), uint32(0),
+				uint32(1663), uint32(5), uint32(16384), uint32(0),
+				make([]byte, 64),
+			)), v)
+			decoded, err := DecodeRmgrData(record)
+			require.NoError(t, err)
+			prepare := decoded.(*XactPrepare)
+			assert.Equal(t, TransactionId(800), prepare.Xid)
+			assert.Equal(t, Oid(10), prepare.Owner)
+			assert.Equal(t, "gid-1", prepare.Gid)
+			assert.Equal(t, []TransactionId{801}, prepare.Subxacts)
+			assert.Equal(t, []RelFileNode{{1663, 5, 16384}}, prepare.CommitRels)
+			assert.Empty(t, prepare.AbortRels)
+		})
+	}
+}
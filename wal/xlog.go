@@ -0,0 +1,265 @@
+package wal
+
+import "bytes"
+
+const (
+	/* XLOG info values for XLOG rmgr */
+	XLOG_CHECKPOINT_SHUTDOWN = 0x00
+	XLOG_CHECKPOINT_ONLINE   = 0x10
+	XLOG_NOOP                = 0x20
+	XLOG_NEXTOID             = 0x30
+	XLOG_SWITCH              = 0x40
+	XLOG_BACKUP_END          = 0x50
+	XLOG_PARAMETER_CHANGE    = 0x60
+	XLOG_RESTORE_POINT       = 0x70
+	XLOG_FPW_CHANGE          = 0x80
+	XLOG_END_OF_RECOVERY     = 0x90
+	XLOG_FPI_FOR_HINT        = 0xA0
+	XLOG_FPI                 = 0xB0
+	/* 0xC0 is used in Postgres 9.5-11 */
+	XLOG_OVERWRITE_CONTRECORD = 0xD0
+	/* Since PostgreSQL 17 */
+	XLOG_CHECKPOINT_REDO = 0xE0
+)
+
+const (
+	WAL_LEVEL_MINIMAL = iota
+	WAL_LEVEL_REPLICA
+	WAL_LEVEL_LOGICAL
+)
+
+const MAXFNAMELEN = 64
+
+/*
+ * Body of CheckPoint XLOG records.  This is declared here because we keep
+ * a copy of the latest one in pg_control for possible disaster recovery.
+ * Changing this struct requires a PG_CONTROL_VERSION bump.
+ */
+type CheckPoint struct {
+	Redo XLogRecPtr /* next RecPtr available when we began to
+	 * create CheckPoint (i.e. REDO start point) */
+	ThisTimeLineID TimeLineID /* current TLI */
+	PrevTimeLineID TimeLineID /* previous TLI, if this record begins a new
+	 * timeline (equals ThisTimeLineID otherwise) */
+	FullPageWrites    bool          /* current full_page_writes */
+	WalLevel          int32         /* current wal_level, since PostgreSQL 17 */
+	NextXid           uint64        /* next free transaction ID */
+	NextOid           Oid           /* next free OID */
+	NextMulti         uint32        /* next free MultiXactId */
+	NextMultiOffset   uint32        /* next free MultiXact offset */
+	OldestXid         TransactionId /* cluster-wide minimum datfrozenxid */
+	OldestXidDB       Oid           /* database with minimum datfrozenxid */
+	OldestMulti       uint32        /* cluster-wide minimum datminmxid */
+	OldestMultiDB     Oid           /* database with minimum datminmxid */
+	Time              int64         /* time stamp of checkpoint */
+	OldestCommitTsXid TransactionId /* oldest Xid with valid commit
+	 * timestamp */
+	NewestCommitTsXid TransactionId /* newest Xid with valid commit
+	 * timestamp */
+
+	/*
+	 * Oldest XID still running. This is only needed to initialize hot standby
+	 * mode from an online checkpoint, so we only bother calculating this for
+	 * online checkpoints and only when wal_level is replica. Otherwise it's
+	 * set to InvalidTransactionId.
+	 */
+	OldestActiveXid TransactionId
+}
+
+func SizeofCheckPoint() int64 {
+	return 88
+}
+
+// NextXidEpoch returns the epoch of NextXid, like EpochFromFullTransactionId.
+func (c *CheckPoint) NextXidEpoch() uint32 {
+	return uint32(c.NextXid >> 32)
+}
+
+/* parameter change */
+type XlParameterChange struct {
+	MaxConnections       int32
+	MaxWorkerProcesses   int32
+	MaxWalSenders        int32
+	MaxPreparedXacts     int32
+	MaxLocksPerXact      int32
+	WalLevel             int32
+	WalLogHints          bool
+	TrackCommitTimestamp bool
+}
+
+func SizeofXlParameterChange() int64 {
+	return 28
+}
+
+/* logs restore point */
+type XlRestorePoint struct {
+	RpTime TimestampTz
+	RpName [MAXFNAMELEN]byte
+}
+
+func SizeofXlRestorePoint() int64 {
+	return 72
+}
+
+/* Overwritten contrecord */
+type XlOverwriteContrecord struct {
+	OverwrittenLsn XLogRecPtr
+	OverwriteTime  TimestampTz
+}
+
+func SizeofXlOverwriteContrecord() int64 {
+	return 16
+}
+
+/* End of recovery mark, when we don't do an END_OF_RECOVERY checkpoint */
+type XlEndOfRecovery struct {
+	EndTime        TimestampTz
+	ThisTimeLineID TimeLineID /* new TLI */
+	PrevTimeLineID TimeLineID /* previous TLI we forked off from */
+	WalLevel       int32      /* Since PostgreSQL 17 */
+}
+
+func SizeofXlEndOfRecovery(v PgVersion) int64 {
+	if v >= PG17 {
+		return 24
+	}
+	return 16
+}
+
+// XLogCheckPoint is an XLOG_CHECKPOINT_SHUTDOWN or XLOG_CHECKPOINT_ONLINE
+// record.
+type XLogCheckPoint struct {
+	CheckPoint
+	Shutdown bool
+}
+
+type XLogNoop struct {
+	Data []byte
+}
+
+type XLogNextOid struct {
+	NextOid Oid
+}
+
+type XLogSwitch struct{}
+
+type XLogBackupEnd struct {
+	StartPoint XLogRecPtr
+}
+
+type XLogParameterChange struct {
+	XlParameterChange
+}
+
+type XLogRestorePoint struct {
+	XlRestorePoint
+}
+
+// Name returns rp_name up to its terminating NUL.
+func (r *XLogRestorePoint) Name() string {
+	name := r.RpName[:]
+	if end := bytes.IndexByte(name, 0); end >= 0 {
+		name = name[:end]
+	}
+	return string(name)
+}
+
+type XLogFPWChange struct {
+	FullPageWrites bool
+}
+
+type XLogEndOfRecovery struct {
+	XlEndOfRecovery
+}
+
+// XLogFPI is an XLOG_FPI or XLOG_FPI_FOR_HINT record, whose full-page images
+// are in the blocks of the record.
+type XLogFPI struct {
+	ForHint bool
+}
+
+type XLogOverwriteContrecord struct {
+	XlOverwriteContrecord
+}
+
+// XLogCheckpointRedo marks the redo point of an online checkpoint since
+// PostgreSQL 17.
+type XLogCheckpointRedo struct {
+	WalLevel int32
+}
+
+// DecodeXLog decodes the records of RM_XLOG_ID into the struct of their info
+// code, e.g. *XLogCheckPoint.
+func DecodeXLog(record *Record) (interface{}, error) {
+	data := record.MainData
+	switch record.Info() {
+	case XLOG_CHECKPOINT_SHUTDOWN, XLOG_CHECKPOINT_ONLINE:
+		checkpoint, err := readStruct[CheckPoint](record, data, SizeofCheckPoint())
+		if err != nil {
+			return nil, err
+		}
+		if record.Version < PG17 {
+			// padding up to PostgreSQL 16
+			checkpoint.WalLevel = 0
+		}
+		return &XLogCheckPoint{CheckPoint: *checkpoint, Shutdown: record.Info() == XLOG_CHECKPOINT_SHUTDOWN}, nil
+	case XLOG_NOOP:
+		return &XLogNoop{Data: data}, nil
+	case XLOG_NEXTOID:
+		oid, err := readStruct[Oid](record, data, 4)
+		if err != nil {
+			return nil, err
+		}
+		return &XLogNextOid{NextOid: *oid}, nil
+	case XLOG_SWITCH:
+		return &XLogSwitch{}, nil
+	case XLOG_BACKUP_END:
+		startpoint, err := readStruct[XLogRecPtr](record, data, 8)
+		if err != nil {
+			return nil, err
+		}
+		return &XLogBackupEnd{StartPoint: *startpoint}, nil
+	case XLOG_PARAMETER_CHANGE:
+		xlrec, err := readStruct[XlParameterChange](record, data, SizeofXlParameterChange())
+		if err != nil {
+			return nil, err
+		}
+		return &XLogParameterChange{XlParameterChange: *xlrec}, nil
+	case XLOG_RESTORE_POINT:
+		xlrec, err := readStruct[XlRestorePoint](record, data, SizeofXlRestorePoint())
+		if err != nil {
+			return nil, err
+		}
+		return &XLogRestorePoint{XlRestorePoint: *xlrec}, nil
+	case XLOG_FPW_CHANGE:
+		fpw, err := readStruct[bool](record, data, 1)
+		if err != nil {
+			return nil, err
+		}
+		return &XLogFPWChange{FullPageWrites: *fpw}, nil
+	case XLOG_END_OF_RECOVERY:
+		xlrec, err := readStruct[XlEndOfRecovery](record, data, SizeofXlEndOfRecovery(record.Version))
+		if err != nil {
+			return nil, err
+		}
+		return &XLogEndOfRecovery{XlEndOfRecovery: *xlrec}, nil
+	case XLOG_FPI, XLOG_FPI_FOR_HINT:
+		return &XLogFPI{ForHint: record.Info() == XLOG_FPI_FOR_HINT}, nil
+	case XLOG_OVERWRITE_CONTRECORD:
+		xlrec, err := readStruct[XlOverwriteContrecord](record, data, SizeofXlOverwriteContrecord())
+		if err != nil {
+			return nil, err
+		}
+		return &XLogOverwriteContrecord{XlOverwriteContrecord: *xlrec}, nil
+	case XLOG_CHECKPOINT_REDO:
+		if record.Version < PG17 {
+			break
+		}
+		walLevel, err := readStruct[int32](record, data, 4)
+		if err != nil {
+			return nil, err
+		}
+		return &XLogCheckpointRedo{WalLevel: *walLevel}, nil
+	}
+	return nil, record.unknownInfo()
+}
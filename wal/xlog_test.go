@@ -0,0 +1,88 @@
+package wal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeXLog(t *testing.T) {
+	checkpoint := le(uint64(0x1000028), uint32(2), uint32(1), uint8(1), []byte{0, 0, 0}, uint32(WAL_LEVEL_LOGICAL),
+		uint64(1)<<32|750, uint32(24576), uint32(1), uint32(0), uint32(716), uint32(1), uint32(1), uint32(1), uint32(0),
+		uint64(1700000000), uint32(0), uint32(0), uint32(750), uint32(0))
+	for _, v := range []PgVersion{PG16, PG17} {
+		t.Run("checkpoint "+v.String(), func(t *testing.T) {
+			decoded, err := DecodeRmgrData(versioned(testRecord(t, RM_XLOG_ID, XLOG_CHECKPOINT_SHUTDOWN, checkpoint), v))
+			require.NoError(t, err)
+			ckpt := decoded.(*XLogCheckPoint)
+			assert.True(t, ckpt.Shutdown)
+			assert.Equal(t, XLogRecPtr(0x1000028), ckpt.Redo)
+			assert.Equal(t, TimeLineID(2), ckpt.ThisTimeLineID)
+			assert.True(t, ckpt.FullPageWrites)
+			assert.EqualValues(t, 1, ckpt.NextXidEpoch())
+			assert.Equal(t, Oid(24576), ckpt.NextOid)
+			assert.Equal(t, TransactionId(716), ckpt.OldestXid)
+			assert.EqualValues(t, 1700000000, ckpt.Time)
+			assert.Equal(t, TransactionId(750), ckpt.OldestActiveXid)
+			if v >= PG17 {
+				assert.EqualValues(t, WAL_LEVEL_LOGICAL, ckpt.WalLevel)
+			} else {
+				assert.Zero(t, ckpt.WalLevel)
+			}
+		})
+	}
+	t.Run("restore point", func(t *testing.T) {
+		name := make([]byte, MAXFNAMELEN)
+		copy(name, "before_upgrade")
+		decoded, err := DecodeRmgrData(testRecord(t, RM_XLOG_ID, XLOG_RESTORE_POINT, le(uint64(42), name)))
+		require.NoError(t, err)
+		rp := decoded.(*XLogRestorePoint)
+		assert.Equal(t, "before_upgrade", rp.Name())
+		assert.Equal(t, TimestampTz(42), rp.RpTime)
+	})
+	t.Run("parameter change", func(t *testing.T) {
+		decoded, err := DecodeRmgrData(testRecord(t, RM_XLOG_ID, XLOG_PARAMETER_CHANGE,
+			le(uint32(100), uint32(8), uint32(10), uint32(0), uint32(64), uint32(WAL_LEVEL_REPLICA), uint8(0), uint8(1), uint16(0))))
+		require.NoError(t, err)
+		change := decoded.(*XLogParameterChange)
+		assert.EqualValues(t, 100, change.MaxConnections)
+		assert.EqualValues(t, WAL_LEVEL_REPLICA, change.WalLevel)
+		assert.True(t, change.TrackCommitTimestamp)
+	})
+	t.Run("end of recovery", func(t *testing.T) {
+		decoded, err := DecodeRmgrData(versioned(testRecord(t, RM_XLOG_ID, XLOG_END_OF_RECOVERY,
+			le(uint64(42), uint32(3), uint32(2))), PG15))
+		require.NoError(t, err)
+		eor := decoded.(*XLogEndOfRecovery)
+		assert.Equal(t, TimeLineID(3), eor.ThisTimeLineID)
+		assert.Equal(t, TimeLineID(2), eor.PrevTimeLineID)
+	})
+	t.Run("small records", func(t *testing.T) {
+		decoded, err := DecodeRmgrData(testRecord(t, RM_XLOG_ID, XLOG_NEXTOID, le(uint32(32768))))
+		require.NoError(t, err)
+		assert.Equal(t, Oid(32768), decoded.(*XLogNextOid).NextOid)
+		decoded, err = DecodeRmgrData(testRecord(t, RM_XLOG_ID, XLOG_BACKUP_END, le(uint64(0x2000028))))
+		require.NoError(t, err)
+		assert.Equal(t, XLogRecPtr(0x2000028), decoded.(*XLogBackupEnd).StartPoint)
+		decoded, err = DecodeRmgrData(testRecord(t, RM_XLOG_ID, XLOG_FPW_CHANGE, le(uint8(1))))
+		require.NoError(t, err)
+		assert.True(t, decoded.(*XLogFPWChange).FullPageWrites)
+		decoded, err = DecodeRmgrData(testRecord(t, RM_XLOG_ID, XLOG_FPI_FOR_HINT, nil, nil))
+		require.NoError(t, err)
+		assert.True(t, decoded.(*XLogFPI).ForHint)
+		decoded, err = DecodeRmgrData(testRecord(t, RM_XLOG_ID, XLOG_SWITCH, nil))
+		require.NoError(t, err)
+		assert.IsType(t, &XLogSwitch{}, decoded)
+		decoded, err = DecodeRmgrData(testRecord(t, RM_XLOG_ID, XLOG_OVERWRITE_CONTRECORD, le(uint64(0x3000028), uint64(42))))
+		require.NoError(t, err)
+		assert.Equal(t, XLogRecPtr(0x3000028), decoded.(*XLogOverwriteContrecord).OverwrittenLsn)
+	})
+	t.Run("checkpoint redo", func(t *testing.T) {
+		decoded, err := DecodeRmgrData(versioned(testRecord(t, RM_XLOG_ID, XLOG_CHECKPOINT_REDO, le(uint32(WAL_LEVEL_REPLICA))), PG17))
+		require.NoError(t, err)
+		assert.EqualValues(t, WAL_LEVEL_REPLICA, decoded.(*XLogCheckpointRedo).WalLevel)
+		_, err = DecodeRmgrData(testRecord(t, RM_XLOG_ID, XLOG_CHECKPOINT_REDO, le(uint32(WAL_LEVEL_REPLICA))))
+		assert.ErrorIs(t, err, ErrInvalidRecord)
+	})
+}
@@ -4,7 +4,8 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
-	"os"
+	"io"
+	"io/fs"
 )
 
 // XLogReader need a startpoint which is a beginning of page or a valid XLogRecPtr
@@ -14,52 +15,413 @@ type XLogReader struct {
 	segmentSize uint32
 	blockSize   uint32
 
-	read   uint32
-	cur    XLogRecPtr
-	reader *BufFile
+	tli     TimeLineID
+	history TimeLineHistory
+	source  WALSource
+
+	noCRC   bool
+	sysid   uint64
+	version PgVersion
+
+	read    uint32
+	cur     XLogRecPtr
+	prev    XLogRecPtr
+	end     XLogRecPtr
+	page    *XLogPageHeaderData
+	reader  *segmentReader
+	pending *RawRecord
 }
 
+// NewXLogReader reads the single segment file at path.
 func NewXLogReader(path string, align uint8) (*XLogReader, error) {
-	f, err := os.Open(path)
+	return NewXLogReaderFiles([]string{path}, align)
+}
+
+// NewXLogReaderFiles starts at the first of paths and moves on to the
+// following segments as long as they are found in paths.
+func NewXLogReaderFiles(paths []string, align uint8) (*XLogReader, error) {
+	if len(paths) == 0 {
+		return nil, errors.New("no segment file given")
+	}
+	source, err := newListSource(paths)
 	if err != nil {
 		return nil, err
 	}
+	return newXLogReaderName(source, source.first, align)
+}
 
-	hdr, err := ReadXLogLongPageHeader(f)
+// NewXLogReaderDir starts at the segment walname of a pg_wal or archive
+// directory and moves on to the following segments of the same directory.
+func NewXLogReaderDir(dir string, walname string, align uint8) (*XLogReader, error) {
+	return newXLogReaderName(NewDirSource(dir), walname, align)
+}
+
+// NewXLogReaderAt starts at lsn of timeline tli, reading the segments of a
+// pg_wal or archive directory. If lsn is not the start of a record, the
+// reader begins at the first record after it.
+func NewXLogReaderAt(dir string, tli TimeLineID, lsn XLogRecPtr, align uint8) (*XLogReader, error) {
+	return NewXLogReaderSource(NewDirSource(dir), tli, lsn, align)
+}
+
+// NewXLogReaderSource starts at lsn of timeline tli, reading the segments
+// provided by source. If lsn is not the start of a record, the reader begins
+// at the first record after it.
+func NewXLogReaderSource(source WALSource, tli TimeLineID, lsn XLogRecPtr, align uint8) (*XLogReader, error) {
+	return newXLogReaderSource(source, tli, nil, lsn, align)
+}
+
+// NewXLogReaderHistory starts at lsn and follows the timelines of history
+// the way recovery does: each segment is read from the newest timeline
+// which had begun by then, so that the reader moves on to a child timeline
+// at its switch point. See ReadTimeLineHistory to get history.
+func NewXLogReaderHistory(source WALSource, history TimeLineHistory, lsn XLogRecPtr, align uint8) (*XLogReader, error) {
+	if len(history) == 0 {
+		return nil, errors.New("empty timeline history")
+	}
+	return newXLogReaderSource(source, 0, history, lsn, align)
+}
+
+func newXLogReaderSource(source WALSource, tli TimeLineID, history TimeLineHistory, lsn XLogRecPtr, align uint8) (*XLogReader, error) {
+	segmentSize, err := source.SegmentSize()
 	if err != nil {
 		return nil, err
 	}
-	if !IsValidXLogPageHeader(hdr) {
-		return nil, fmt.Errorf("invalid segment file %s", path)
+	if !isValidWalSegSize(segmentSize) {
+		return nil, fmt.Errorf("invalid segment size %d", segmentSize)
+	}
+	r := &XLogReader{
+		alignment:   align,
+		segmentSize: segmentSize,
+		tli:         tli,
+		history:     history,
+		source:      source,
+	}
+	err = r.open(XLogSegNo(lsn / XLogRecPtr(segmentSize)))
+	if err != nil {
+		return nil, err
+	}
+	err = r.seek(lsn)
+	if err != nil {
+		r.Close()
+		return nil, err
+	}
+	return r, nil
+}
+
+func newXLogReaderName(source WALSource, walname string, align uint8) (*XLogReader, error) {
+	segmentSize, err := source.SegmentSize()
+	if err != nil {
+		return nil, err
+	}
+	tli, segno, err := ParseWalName(walname, segmentSize)
+	if err != nil {
+		return nil, err
+	}
+	return NewXLogReaderSource(source, tli, XLogRecPtr(segno)*XLogRecPtr(segmentSize), align)
+}
+
+// open opens the first segment, segno, and positions the reader right after
+// its long page header.
+func (r *XLogReader) open(segno XLogSegNo) error {
+	tli, f, err := r.openSegment(segno)
+	if err != nil {
+		return err
+	}
+	walname := SegmentName(tli, segno, r.segmentSize)
+
+	hdr, err := ReadXLogLongPageHeader(f)
+	if err != nil {
+		f.Close()
+		return err
+	}
+	if !IsValidXLogPageHeader(hdr) || hdr.XlpSegSize != r.segmentSize ||
+		hdr.XlpXLogBlcksz == 0 || hdr.XlpSegSize%hdr.XlpXLogBlcksz != 0 {
+		f.Close()
+		return fmt.Errorf("invalid segment file %s", walname)
 	}
 
-	cur := hdr.Std.XlpPageAddr + XLogRecPtr(SizeofXLogLongPageHeaderData())
-	// reader := bufio.NewReaderSize(f, int(hdr.XlpSegSize))
-	reader := &BufFile{f}
+	r.tli = tli
+	r.version, _ = VersionOfMagic(hdr.Std.XlpMagic)
+	r.blockSize = hdr.XlpXLogBlcksz
+	r.sysid = hdr.XlpSysid
+	r.read = uint32(SizeofXLogLongPageHeaderData())
+	r.reader = &segmentReader{f}
+	expected := XLogRecPtr(segno) * XLogRecPtr(r.segmentSize)
+	err = r.validateLongPageHeader(hdr, expected)
+	if err != nil {
+		f.Close()
+		return err
+	}
+	r.cur = expected + XLogRecPtr(SizeofXLogLongPageHeaderData())
+	r.page = &hdr.Std
+	return nil
+}
+
+// openSegment opens segment segno of the timeline it has to be read from,
+// trying older timelines of the history when it is missing.
+func (r *XLogReader) openSegment(segno XLogSegNo) (TimeLineID, io.ReadCloser, error) {
+	if r.history == nil {
+		f, err := r.source.OpenSegment(r.tli, segno, r.segmentSize)
+		return r.tli, f, err
+	}
+
+	var first error
+	for _, tli := range r.history.segmentTimeLines(segno, r.segmentSize, r.tli) {
+		f, err := r.source.OpenSegment(tli, segno, r.segmentSize)
+		if err == nil {
+			return tli, f, nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return 0, nil, err
+		}
+		if first == nil {
+			first = err
+		}
+	}
+	if first == nil {
+		first = &fs.PathError{Op: "open", Path: SegmentName(r.tli, segno, r.segmentSize), Err: fs.ErrNotExist}
+	}
+	return 0, nil, first
+}
+
+// Version returns the server version which wrote the WAL.
+func (r *XLogReader) Version() PgVersion {
+	return r.version
+}
+
+// TimeLine returns the timeline of the segment file being read.
+func (r *XLogReader) TimeLine() TimeLineID {
+	return r.tli
+}
+
+// SetVerifyCRC turns the CRC-32C check of every record read on or off. It is
+// on by default.
+func (r *XLogReader) SetVerifyCRC(enabled bool) {
+	r.noCRC = !enabled
+}
+
+// EndRecPtr returns the lsn right after the last record read. When reading
+// stops with ErrEndOfWAL, the WAL of the segment is valid up to there, the
+// rest of it is zeroed or left over from an earlier use of the file.
+func (r *XLogReader) EndRecPtr() XLogRecPtr {
+	return r.end
+}
 
-	if hdr.Std.XlpInfo&XLP_FIRST_IS_CONTRECORD != 0 {
-		if rDataLen := int(hdr.Std.XlpRemLen); rDataLen > 0 {
-			_, err = reader.Discard(rDataLen)
+// Close releases the segment file currently being read.
+func (r *XLogReader) Close() error {
+	return r.reader.Close()
+}
+
+// seek moves forward to the first record which starts at or after lsn, like
+// XLogFindNextRecord. Records continued from earlier pages are skipped. The
+// current lsn must be right after the header of a page not after lsn.
+func (r *XLogReader) seek(lsn XLogRecPtr) error {
+	pageAddr := lsn - lsn%XLogRecPtr(r.blockSize)
+	if pageAddr < r.cur-r.cur%XLogRecPtr(r.blockSize) {
+		return fmt.Errorf("cannot seek backward to %s", lsn)
+	}
+	if pageAddr > r.cur {
+		err := r.skip(uint32(pageAddr - r.cur))
+		if err != nil {
+			return err
+		}
+		_, err = r.readPageHeader()
+		if err != nil {
+			return err
+		}
+	}
+
+	for r.page.XlpInfo&XLP_FIRST_IS_CONTRECORD != 0 {
+		free := r.remainBlkSize()
+		if r.page.XlpRemLen < free {
+			err := r.skip(r.page.XlpRemLen)
 			if err != nil {
-				return nil, err
+				return err
 			}
-			cur += XLogRecPtr(rDataLen)
+			break
+		}
+		// the continuation fills up the page, try the next one.
+		err := r.skip(free)
+		if err != nil {
+			return err
+		}
+		_, err = r.readPageHeader()
+		if err != nil {
+			return err
 		}
 	}
+	_, err := r.align()
+	if err != nil {
+		return err
+	}
 
-	ret := &XLogReader{
-		alignment:   align,
-		segmentSize: hdr.XlpSegSize,
-		blockSize:   hdr.XlpXLogBlcksz,
-		cur:         cur,
-		reader:      reader,
+	for r.cur < lsn {
+		record, err := r.ReadRecord()
+		if err != nil {
+			return err
+		}
+		if record.LSN >= lsn {
+			r.pending = record
+			break
+		}
 	}
-	_, err = ret.align()
+	return nil
+}
+
+// skip discards n bytes of the current segment file.
+func (r *XLogReader) skip(n uint32) error {
+	_, err := r.reader.Discard(int(n))
+	if err != nil {
+		return r.ioError(r.cur, err)
+	}
+	r.cur += XLogRecPtr(n)
+	r.read += n
+	return nil
+}
+
+// readPageHeader consumes the page header at the current lsn, switching to
+// the next segment if the current one is exhausted.
+func (r *XLogReader) readPageHeader() (*XLogPageHeaderData, error) {
+	if _, seg := r.isPageHeaderLSN(); seg {
+		err := r.nextSegment()
+		if err != nil {
+			return nil, err
+		}
+		return r.page, nil
+	}
+
+	hdr, err := ReadXLogPageHeader(r.reader)
+	if err != nil {
+		return nil, r.ioError(r.cur, err)
+	}
+	err = r.validatePageHeader(hdr, r.cur)
 	if err != nil {
 		return nil, err
 	}
-	ret.read = uint32(ret.cur - hdr.Std.XlpPageAddr)
-	return ret, nil
+	r.cur += XLogRecPtr(SizeofXLogPageHeaderData())
+	r.read += uint32(SizeofXLogPageHeaderData())
+	r.page = hdr
+	return hdr, nil
+}
+
+// nextSegment switches to the segment starting at the current lsn and
+// consumes its long page header.
+func (r *XLogReader) nextSegment() error {
+	segno := XLogSegNo(r.cur / XLogRecPtr(r.segmentSize))
+	tli, f, err := r.openSegment(segno)
+	if err != nil {
+		return r.ioError(r.cur, err)
+	}
+	// errors are reported against the new segment file
+	prevTli := r.tli
+	r.tli = tli
+
+	hdr, err := ReadXLogLongPageHeader(f)
+	if err != nil {
+		err = r.ioError(r.cur, err)
+	} else {
+		err = r.validateLongPageHeader(hdr, r.cur)
+	}
+	if err != nil {
+		f.Close()
+		r.tli = prevTli
+		return err
+	}
+
+	r.reader.Close()
+	r.reader = &segmentReader{f}
+	r.cur += XLogRecPtr(SizeofXLogLongPageHeaderData())
+	r.read = uint32(SizeofXLogLongPageHeaderData())
+	r.page = &hdr.Std
+	return nil
+}
+
+// validatePageHeader checks the header of the page at lsn the way
+// XLogReaderValidatePageHeader does. Zeroed pages and pages left over from
+// an older use of a recycled segment mark the end of WAL.
+func (r *XLogReader) validatePageHeader(hdr *XLogPageHeaderData, lsn XLogRecPtr) error {
+	if hdr.XlpMagic != r.version.PageMagic() {
+		if *hdr == (XLogPageHeaderData{}) {
+			return r.errorf(lsn, ErrEndOfWAL, "zeroed page")
+		}
+		return r.errorf(lsn, ErrInvalidPageHeader, "invalid magic number %04X", hdr.XlpMagic)
+	}
+	if hdr.XlpInfo&^XLP_ALL_FLAGS != 0 {
+		return r.errorf(lsn, ErrInvalidPageHeader, "invalid info bits %04X", hdr.XlpInfo)
+	}
+	if isLong := lsn%XLogRecPtr(r.segmentSize) == 0; isLong != (hdr.XlpInfo&XLP_LONG_HEADER != 0) {
+		return r.errorf(lsn, ErrInvalidPageHeader, "invalid info bits %04X", hdr.XlpInfo)
+	}
+	if hdr.XlpPageAddr != lsn {
+		if hdr.XlpPageAddr < lsn {
+			return r.errorf(lsn, ErrEndOfWAL, "unexpected pageaddr %s", hdr.XlpPageAddr)
+		}
+		return r.errorf(lsn, ErrInvalidPageHeader, "unexpected pageaddr %s", hdr.XlpPageAddr)
+	}
+	if r.page != nil && hdr.XlpTli < r.page.XlpTli {
+		return r.errorf(lsn, ErrInvalidPageHeader, "out-of-sequence timeline ID %d (after %d)", hdr.XlpTli, r.page.XlpTli)
+	}
+	if r.history != nil && !r.history.Contains(hdr.XlpTli) {
+		return r.errorf(lsn, ErrInvalidPageHeader, "unexpected timeline ID %d", hdr.XlpTli)
+	}
+	return nil
+}
+
+func (r *XLogReader) validateLongPageHeader(hdr *XLogLongPageHeaderData, lsn XLogRecPtr) error {
+	err := r.validatePageHeader(&hdr.Std, lsn)
+	if err != nil {
+		return err
+	}
+	switch {
+	case hdr.XlpSysid != r.sysid:
+		return r.errorf(lsn, ErrInvalidPageHeader, "WAL file is from different database system: WAL file database system identifier is %d, expected %d", hdr.XlpSysid, r.sysid)
+	case hdr.XlpSegSize != r.segmentSize:
+		return r.errorf(lsn, ErrInvalidPageHeader, "incorrect segment size %d in page header", hdr.XlpSegSize)
+	case hdr.XlpXLogBlcksz != r.blockSize:
+		return r.errorf(lsn, ErrInvalidPageHeader, "incorrect XLOG_BLCKSZ %d in page header", hdr.XlpXLogBlcksz)
+	}
+	return nil
+}
+
+// validateRecordHeader checks the header of the record at lsn the way
+// ValidXLogRecordHeader does. A zero length marks the end of WAL.
+func (r *XLogReader) validateRecordHeader(hdr *XLogRecord, lsn XLogRecPtr) error {
+	switch {
+	case hdr.XlTotlen == 0:
+		return r.errorf(lsn, ErrEndOfWAL, "record with zero length")
+	case hdr.XlTotlen < uint32(SizeofXLogRecord()) || hdr.XlTotlen > XLogRecordMaxSize:
+		return r.errorf(lsn, ErrInvalidRecord, "invalid record length %d", hdr.XlTotlen)
+	case hdr.XlRmid > RM_MAX_ID && (r.version < PG15 || hdr.XlRmid < RM_MIN_CUSTOM_ID):
+		return r.errorf(lsn, ErrInvalidRecord, "invalid resource manager ID %d", hdr.XlRmid)
+	case r.prev == 0 && hdr.XlPrev >= lsn:
+		return r.errorf(lsn, ErrInvalidRecord, "record with incorrect prev-link %s", hdr.XlPrev)
+	case r.prev != 0 && hdr.XlPrev != r.prev:
+		return r.errorf(lsn, ErrInvalidRecord, "record with incorrect prev-link %s", hdr.XlPrev)
+	}
+	return nil
+}
+
+func (r *XLogReader) errorf(lsn XLogRecPtr, err error, format string, args ...interface{}) error {
+	segment, _ := WalName(r.tli, lsn, r.segmentSize)
+	return &ReadError{
+		LSN:     lsn,
+		Segment: segment,
+		Offset:  uint32(lsn % XLogRecPtr(r.segmentSize)),
+		Err:     err,
+		Reason:  fmt.Sprintf(format, args...),
+	}
+}
+
+// ioError classifies a failed read at lsn. Running out of segment files, or
+// out of bytes in a segment file, is the end of the available WAL.
+func (r *XLogReader) ioError(lsn XLogRecPtr, err error) error {
+	switch {
+	case errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		return r.errorf(lsn, ErrEndOfWAL, "segment file is truncated")
+	case errors.Is(err, fs.ErrNotExist):
+		return r.errorf(lsn, ErrEndOfWAL, "segment file not found")
+	}
+	return r.errorf(lsn, err, "")
 }
 
 func (r *XLogReader) isPageHeaderLSN() (page bool, seg bool) {
@@ -81,7 +443,9 @@ func (r *XLogReader) remainSegSize() uint32 {
 }
 
 // currrent lsn must start be a record hdr or page header which has no cont record.
-func (r *XLogReader) readN(size uint32) (lsn XLogRecPtr, _ []byte, err error) {
+// first tells whether the bytes read begin a record, otherwise they are the
+// rest of the record being read.
+func (r *XLogReader) readN(size uint32, first bool) (lsn XLogRecPtr, _ []byte, err error) {
 	if size == 0 {
 		return 0, nil, errors.New("size must greater than 0")
 	}
@@ -90,27 +454,25 @@ func (r *XLogReader) readN(size uint32) (lsn XLogRecPtr, _ []byte, err error) {
 	}
 
 	var (
-		dis  int    = 0
 		ret         = make([]byte, size)
 		read uint32 = 0
 	)
 	for read < size {
-		isPageHdr, isLongPageHdr := r.isPageHeaderLSN()
-		switch {
-		case isLongPageHdr:
-			dis = int(SizeofXLogLongPageHeaderData())
-		case isPageHdr:
-			dis = int(SizeofXLogPageHeaderData())
-		}
-
-		if dis > 0 {
-			_, err = r.reader.Discard(dis)
+		if isPageHdr, _ := r.isPageHeaderLSN(); isPageHdr {
+			hdr, err := r.readPageHeader()
 			if err != nil {
 				return 0, nil, err
 			}
-			r.cur += XLogRecPtr(dis)
-			r.read += uint32(dis)
-			dis = 0
+			if first && read == 0 {
+				if hdr.XlpInfo&XLP_FIRST_IS_CONTRECORD != 0 {
+					return 0, nil, r.errorf(r.cur, ErrInvalidRecord, "contrecord is requested")
+				}
+			} else {
+				err = r.checkContRecord(hdr, size-read, !first)
+				if err != nil {
+					return 0, nil, err
+				}
+			}
 		}
 
 		if read == 0 {
@@ -119,18 +481,18 @@ func (r *XLogReader) readN(size uint32) (lsn XLogRecPtr, _ []byte, err error) {
 
 		free := r.remainBlkSize()
 		if (size - read) <= free {
-			_, err := r.reader.Read(ret[read:])
+			_, err := io.ReadFull(r.reader, ret[read:])
 			if err != nil {
-				return 0, nil, err
+				return 0, nil, r.ioError(r.cur, err)
 			}
 
 			r.cur += XLogRecPtr(size - read)
 			r.read += uint32(size - read)
 			return lsn, ret, nil
 		}
-		n, err := r.reader.Read(ret[read : read+free])
-		if err != nil && uint32(n) != free {
-			return 0, nil, err
+		_, err := io.ReadFull(r.reader, ret[read:read+free])
+		if err != nil {
+			return 0, nil, r.ioError(r.cur, err)
 		}
 		read += free
 		r.cur += XLogRecPtr(free)
@@ -139,29 +501,70 @@ func (r *XLogReader) readN(size uint32) (lsn XLogRecPtr, _ []byte, err error) {
 	return 0, nil, errors.New("should not be here")
 }
 
+// errOverwritten tells that the record being read was aborted and
+// overwritten, the next record starts right after the page header.
+var errOverwritten = errors.New("record overwritten")
+
+// checkContRecord checks the header of a page the record being read goes on.
+// remain is the number of bytes of the record still to read, or a lower
+// bound of it if exact is false.
+func (r *XLogReader) checkContRecord(hdr *XLogPageHeaderData, remain uint32, exact bool) error {
+	lsn := r.cur - r.cur%XLogRecPtr(r.blockSize)
+	switch {
+	case hdr.XlpInfo&XLP_FIRST_IS_OVERWRITE_CONTRECORD != 0:
+		return errOverwritten
+	case hdr.XlpInfo&XLP_FIRST_IS_CONTRECORD == 0:
+		return r.errorf(lsn, ErrInvalidRecord, "there is no contrecord flag")
+	case hdr.XlpRemLen < remain || exact && hdr.XlpRemLen != remain:
+		return r.errorf(lsn, ErrInvalidRecord, "invalid contrecord length %d (expected %d)", hdr.XlpRemLen, remain)
+	}
+	return nil
+}
+
 func (r *XLogReader) align() (XLogRecPtr, error) {
 	offset := uint8(r.cur % XLogRecPtr(r.alignment))
 	if offset == 0 {
 		return r.cur, nil
 	}
 
-	step := r.alignment - offset
-	_, err := r.reader.Discard(int(step))
+	err := r.skip(uint32(r.alignment - offset))
 	if err != nil {
 		return 0, err
 	}
-	r.cur += XLogRecPtr(step)
-	r.read += uint32(step)
 	return r.cur, nil
 }
 
+// ReadRecord returns the next record. At the end of the available WAL it
+// returns a *ReadError wrapping ErrEndOfWAL, broken WAL is reported with
+// ErrInvalidRecord, ErrInvalidPageHeader or ErrCRCMismatch.
 func (r *XLogReader) ReadRecord() (*RawRecord, error) {
+	if r.pending != nil {
+		record := r.pending
+		r.pending = nil
+		return record, nil
+	}
+
+	for {
+		record, err := r.readRecord()
+		if err == errOverwritten {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		r.prev = record.LSN
+		r.end = r.cur
+		return record, nil
+	}
+}
+
+func (r *XLogReader) readRecord() (*RawRecord, error) {
 	_, err := r.align()
 	if err != nil {
 		return nil, err
 	}
 
-	lsn, rawhdr, err := r.readN(uint32(SizeofXLogRecord()))
+	lsn, rawhdr, err := r.readN(uint32(SizeofXLogRecord()), true)
 	if err != nil {
 		return nil, err
 	}
@@ -169,19 +572,36 @@ func (r *XLogReader) ReadRecord() (*RawRecord, error) {
 	if err != nil {
 		return nil, err
 	}
+	err = r.validateRecordHeader(hdr, lsn)
+	if err != nil {
+		return nil, err
+	}
 
-	if hdr.XlRmid == RM_XLOG_ID && hdr.XlInfo&(XLR_RMGR_INFO_MASK) == 0x40 {
-		rDataLen := r.remainSegSize()
-		_, err = r.reader.Discard(int(rDataLen))
-		if err != nil {
-			return nil, err
+	if hdr.XlRmid == RM_XLOG_ID && hdr.XlInfo&XLR_RMGR_INFO_MASK == XLOG_SWITCH {
+		// the rest of the segment is unused, the next record begins
+		// at the start of the next segment.
+		if _, seg := r.isPageHeaderLSN(); !seg {
+			err = r.skip(r.remainSegSize())
+			if err != nil {
+				return nil, err
+			}
 		}
-		return &RawRecord{LSN: lsn, Hdr: hdr}, nil
+		return r.verify(&RawRecord{LSN: lsn, Hdr: hdr, Version: r.version}, rawhdr)
 	}
 
-	_, rawdata, err := r.readN(hdr.XlTotlen - uint32(SizeofXLogRecord()))
+	if hdr.XlTotlen == uint32(SizeofXLogRecord()) {
+		return r.verify(&RawRecord{LSN: lsn, Hdr: hdr, Version: r.version}, rawhdr)
+	}
+	_, rawdata, err := r.readN(hdr.XlTotlen-uint32(SizeofXLogRecord()), false)
 	if err != nil {
 		return nil, err
 	}
-	return &RawRecord{LSN: lsn, Hdr: hdr, data: rawdata}, nil
+	return r.verify(&RawRecord{LSN: lsn, Hdr: hdr, Version: r.version, data: rawdata}, rawhdr)
+}
+
+func (r *XLogReader) verify(record *RawRecord, rawhdr []byte) (*RawRecord, error) {
+	if !r.noCRC && XLogRecordCRC(rawhdr, record.data) != record.Hdr.XlCrc {
+		return nil, r.errorf(record.LSN, ErrCRCMismatch, "")
+	}
+	return record, nil
 }
@@ -0,0 +1,286 @@
+package wal
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readAll(t *testing.T, reader *XLogReader) ([]*Record, error) {
+	var ret []*Record
+	for {
+		raw, err := reader.ReadRecord()
+		if err != nil {
+			return ret, err
+		}
+		record, err := raw.Decode()
+		require.NoError(t, err)
+		ret = append(ret, record)
+	}
+}
+
+func TestXLogReaderAcrossSegments(t *testing.T) {
+	b := newWalBuilder(1, 0x300000)
+	var lsns []XLogRecPtr
+	for i := 0; i < 800; i++ {
+		lsns = append(lsns, b.record(RM_HEAP_ID, 0, TransactionId(i), mainData(bytes.Repeat([]byte{byte(i)}, 3000))))
+	}
+	dir := b.dump(t)
+	require.Len(t, b.segments(), 3)
+
+	reader, err := NewXLogReaderDir(dir, "000000010000000000000003", 8)
+	require.NoError(t, err)
+	defer reader.Close()
+
+	records, err := readAll(t, reader)
+	assert.ErrorIs(t, err, ErrEndOfWAL)
+	require.Len(t, records, len(lsns))
+	for i, record := range records {
+		assert.Equal(t, lsns[i], record.LSN)
+		assert.Equal(t, bytes.Repeat([]byte{byte(i)}, 3000), record.MainData)
+	}
+}
+
+func TestXLogReaderFiles(t *testing.T) {
+	b := newWalBuilder(1, 0x300000)
+	var lsns []XLogRecPtr
+	for i := 0; i < 400; i++ {
+		lsns = append(lsns, b.record(RM_HEAP_ID, 0, TransactionId(i), mainData(bytes.Repeat([]byte{byte(i)}, 3000))))
+	}
+	dir := b.dump(t)
+
+	var paths []string
+	for name := range b.segments() {
+		paths = append(paths, filepath.Join(dir, name))
+	}
+	sort.Strings(paths)
+
+	reader, err := NewXLogReaderFiles(paths, 8)
+	require.NoError(t, err)
+	defer reader.Close()
+	records, _ := readAll(t, reader)
+	assert.Len(t, records, len(lsns))
+
+	single, err := NewXLogReader(paths[0], 8)
+	require.NoError(t, err)
+	defer single.Close()
+	records, _ = readAll(t, single)
+	assert.Less(t, len(records), len(lsns))
+}
+
+func TestXLogReaderSwitch(t *testing.T) {
+	b := newWalBuilder(1, 0x300000)
+	first := b.record(RM_HEAP_ID, 0, 1, mainData([]byte("before")))
+	switched := b.xlogSwitch()
+	next := b.record(RM_HEAP_ID, 0, 2, mainData([]byte("after")))
+	dir := b.dump(t)
+
+	reader, err := NewXLogReaderDir(dir, "000000010000000000000003", 8)
+	require.NoError(t, err)
+	defer reader.Close()
+	records, _ := readAll(t, reader)
+	require.Len(t, records, 3)
+	assert.Equal(t, []XLogRecPtr{first, switched, next}, []XLogRecPtr{records[0].LSN, records[1].LSN, records[2].LSN})
+	assert.Equal(t, XLogRecPtr(0x400000+SizeofXLogLongPageHeaderData()), next)
+	assert.Equal(t, []byte("after"), records[2].MainData)
+}
+
+func TestXLogReaderAt(t *testing.T) {
+	b := newWalBuilder(1, 0x300000)
+	var lsns []XLogRecPtr
+	for i := 0; i < 300; i++ {
+		size := 100
+		if i%5 == 0 {
+			// spans several pages
+			size = 20000
+		}
+		lsns = append(lsns, b.record(RM_HEAP_ID, 0, TransactionId(i), mainData(bytes.Repeat([]byte{byte(i)}, size))))
+	}
+	dir := b.dump(t)
+
+	cases := []struct {
+		name  string
+		lsn   XLogRecPtr
+		first int
+	}{
+		{"segment start", 0x300000, 0},
+		{"record start", lsns[42], 42},
+		{"middle of record", lsns[42] + 3, 43},
+		{"continued page", lsns[50] + 9000, 51},
+		{"next segment", 0x400000, sort.Search(len(lsns), func(i int) bool { return lsns[i] >= 0x400000 })},
+	}
+	for _, entry := range cases {
+		t.Run(entry.name, func(t *testing.T) {
+			reader, err := NewXLogReaderAt(dir, 1, entry.lsn, 8)
+			require.NoError(t, err)
+			defer reader.Close()
+
+			records, _ := readAll(t, reader)
+			require.Len(t, records, len(lsns)-entry.first)
+			assert.Equal(t, lsns[entry.first], records[0].LSN)
+			assert.Equal(t, lsns[len(lsns)-1], records[len(records)-1].LSN)
+		})
+	}
+}
+
+func TestXLogReaderCRC(t *testing.T) {
+	b := newWalBuilder(1, 0x300000)
+	b.record(RM_HEAP_ID, 0, 1, mainData([]byte("first")))
+	corrupted := b.record(RM_HEAP_ID, 0, 2, mainData([]byte("second")))
+	b.record(RM_HEAP_ID, 0, 3, mainData([]byte("third")))
+	dir := b.dump(t)
+
+	path := filepath.Join(dir, "000000010000000000000003")
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	data[int64(corrupted-0x300000)+SizeofXLogRecord()+2] ^= 0xFF
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+
+	reader, err := NewXLogReader(path, 8)
+	require.NoError(t, err)
+	defer reader.Close()
+	records, err := readAll(t, reader)
+	assert.Len(t, records, 1)
+	assert.True(t, errors.Is(err, ErrCRCMismatch))
+	var rerr *ReadError
+	require.True(t, errors.As(err, &rerr))
+	assert.Equal(t, corrupted, rerr.LSN)
+
+	unchecked, err := NewXLogReader(path, 8)
+	require.NoError(t, err)
+	defer unchecked.Close()
+	unchecked.SetVerifyCRC(false)
+	records, _ = readAll(t, unchecked)
+	assert.Len(t, records, 3)
+}
+
+func TestXLogReaderEndOfWAL(t *testing.T) {
+	b := newWalBuilder(1, 0x300000)
+	b.record(RM_HEAP_ID, 0, 1, mainData([]byte("first")))
+	// the second record fills up the first page
+	b.record(RM_HEAP_ID, 0, 2, mainData(make([]byte, 0x302000-int(b.pos()+7)&^7-int(SizeofXLogRecord())-5)))
+	require.EqualValues(t, 0x302000, b.pos())
+	third := b.record(RM_HEAP_ID, 0, 3, mainData([]byte("third")))
+	end := (b.pos() + 7) &^ 7
+
+	cases := []struct {
+		name   string
+		modify func(data []byte)
+		lsn    XLogRecPtr
+	}{
+		{"zeroed tail", func(data []byte) {
+			copy(data[third-0x300000:], make([]byte, int(end-third)))
+		}, third},
+		{"zeroed page", func(data []byte) {
+			copy(data[0x2000:], make([]byte, 0x2000))
+		}, 0x302000},
+		{"recycled page", func(data []byte) {
+			binary.LittleEndian.PutUint64(data[0x2008:], 0x202000)
+		}, 0x302000},
+	}
+	for _, entry := range cases {
+		t.Run(entry.name, func(t *testing.T) {
+			data := append([]byte(nil), b.segments()["000000010000000000000003"]...)
+			entry.modify(data)
+			path := filepath.Join(t.TempDir(), "000000010000000000000003")
+			require.NoError(t, os.WriteFile(path, data, 0o600))
+
+			reader, err := NewXLogReader(path, 8)
+			require.NoError(t, err)
+			defer reader.Close()
+			records, err := readAll(t, reader)
+			assert.ErrorIs(t, err, ErrEndOfWAL)
+			var rerr *ReadError
+			require.ErrorAs(t, err, &rerr)
+			assert.Equal(t, entry.lsn, rerr.LSN)
+			assert.Equal(t, "000000010000000000000003", rerr.Segment)
+			assert.EqualValues(t, entry.lsn-0x300000, rerr.Offset)
+			for _, record := range records {
+				assert.Less(t, record.LSN, entry.lsn)
+			}
+		})
+	}
+}
+
+func TestXLogReaderMissingSegment(t *testing.T) {
+	b := newWalBuilder(1, 0x300000)
+	b.record(RM_HEAP_ID, 0, 1, mainData([]byte("first")))
+	b.xlogSwitch()
+	dir := b.dump(t)
+	require.NoError(t, os.Remove(filepath.Join(dir, "000000010000000000000004")))
+
+	reader, err := NewXLogReaderDir(dir, "000000010000000000000003", 8)
+	require.NoError(t, err)
+	defer reader.Close()
+	records, err := readAll(t, reader)
+	assert.Len(t, records, 2)
+	assert.ErrorIs(t, err, ErrEndOfWAL)
+	var rerr *ReadError
+	require.ErrorAs(t, err, &rerr)
+	assert.Equal(t, "000000010000000000000004", rerr.Segment)
+}
+
+func TestXLogReaderInvalid(t *testing.T) {
+	b := newWalBuilder(1, 0x300000)
+	b.record(RM_HEAP_ID, 0, 1, mainData([]byte("first")))
+	second := b.record(RM_HEAP_ID, 0, 2, mainData(bytes.Repeat([]byte{1}, 10000)))
+	b.record(RM_HEAP_ID, 0, 3, mainData([]byte("third")))
+
+	cases := []struct {
+		name   string
+		modify func(data []byte)
+		err    error
+		lsn    XLogRecPtr
+	}{
+		{"prev-link", func(data []byte) { data[second-0x300000+8]++ }, ErrInvalidRecord, second},
+		{"rmid", func(data []byte) { data[second-0x300000+17] = 200 }, ErrInvalidRecord, second},
+		{"length", func(data []byte) { data[second-0x300000+3] = 0x7F }, ErrInvalidRecord, second},
+		{"magic", func(data []byte) { data[0x2000] = 0 }, ErrInvalidPageHeader, 0x302000},
+		{"contrecord", func(data []byte) { data[0x2002] &^= XLP_FIRST_IS_CONTRECORD }, ErrInvalidRecord, 0x302000},
+		{"contrecord length", func(data []byte) { data[0x2010]++ }, ErrInvalidRecord, 0x302000},
+		{"timeline", func(data []byte) { data[0x2004] = 0 }, ErrInvalidPageHeader, 0x302000},
+	}
+	for _, entry := range cases {
+		t.Run(entry.name, func(t *testing.T) {
+			data := b.segments()["000000010000000000000003"]
+			data = append([]byte(nil), data...)
+			entry.modify(data)
+			path := filepath.Join(t.TempDir(), "000000010000000000000003")
+			require.NoError(t, os.WriteFile(path, data, 0o600))
+
+			reader, err := NewXLogReader(path, 8)
+			require.NoError(t, err)
+			defer reader.Close()
+			records, err := readAll(t, reader)
+			assert.Len(t, records, 1)
+			assert.ErrorIs(t, err, entry.err)
+			var rerr *ReadError
+			require.ErrorAs(t, err, &rerr)
+			assert.Equal(t, entry.lsn, rerr.LSN)
+		})
+	}
+}
+
+func TestXLogReaderOverwriteContrecord(t *testing.T) {
+	b := newWalBuilder(1, 0x300000)
+	first := b.record(RM_HEAP_ID, 0, 1, mainData([]byte("first")))
+	b.abortedRecord()
+	next := b.record(RM_XLOG_ID, XLOG_OVERWRITE_CONTRECORD, 0, mainData([]byte("overwrite")))
+	dir := b.dump(t)
+
+	reader, err := NewXLogReaderDir(dir, "000000010000000000000003", 8)
+	require.NoError(t, err)
+	defer reader.Close()
+	records, err := readAll(t, reader)
+	assert.ErrorIs(t, err, ErrEndOfWAL)
+	require.Len(t, records, 2)
+	assert.Equal(t, first, records[0].LSN)
+	assert.Equal(t, next, records[1].LSN)
+}